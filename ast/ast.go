@@ -11,6 +11,10 @@ import (
 type Node interface {
 	TokenLiteral() string
 	String() string
+	// Pos 节点第一个字符的位置
+	Pos() token.Position
+	// End 节点最后一个字符之后的位置
+	End() token.Position
 }
 
 // Expression 表达式接口
@@ -55,6 +59,16 @@ func (i *Identifier) TokenLiteral() string {
 	return i.Token.Literal
 }
 
+// Pos 实现了 Expression 接口的方法
+func (i *Identifier) Pos() token.Position {
+	return i.Token.Pos
+}
+
+// End 实现了 Expression 接口的方法
+func (i *Identifier) End() token.Position {
+	return i.Token.End
+}
+
 // String 实现了 Expression 接口的方法
 func (i *Identifier) String() string {
 	return i.Value
@@ -75,6 +89,16 @@ func (i *IntegerLiteral) TokenLiteral() string {
 	return i.Token.Literal
 }
 
+// Pos 实现了 Expression 接口的方法
+func (i *IntegerLiteral) Pos() token.Position {
+	return i.Token.Pos
+}
+
+// End 实现了 Expression 接口的方法
+func (i *IntegerLiteral) End() token.Position {
+	return i.Token.End
+}
+
 // String 实现了 Expression 接口的方法
 func (i *IntegerLiteral) String() string {
 	return i.Token.Literal
@@ -95,6 +119,16 @@ func (i *FloatLiteral) TokenLiteral() string {
 	return i.Token.Literal
 }
 
+// Pos 实现了 Expression 接口的方法
+func (i *FloatLiteral) Pos() token.Position {
+	return i.Token.Pos
+}
+
+// End 实现了 Expression 接口的方法
+func (i *FloatLiteral) End() token.Position {
+	return i.Token.End
+}
+
 // String 实现了 Expression 接口的方法
 func (i *FloatLiteral) String() string {
 	return i.Token.Literal
@@ -115,11 +149,66 @@ func (sl *StringLiteral) TokenLiteral() string {
 	return sl.Token.Literal
 }
 
+// Pos 实现了 Expression 接口的方法
+func (sl *StringLiteral) Pos() token.Position {
+	return sl.Token.Pos
+}
+
+// End 实现了 Expression 接口的方法
+func (sl *StringLiteral) End() token.Position {
+	return sl.Token.End
+}
+
 // String 实现了 Expression 接口的方法
 func (sl *StringLiteral) String() string {
 	return sl.Token.Literal
 }
 
+// InterpolatedString 插值字符串节点，如 "hello ${name}"
+// Parts 依次是字符串片段和插值表达式，字符串片段是 StringLiteral，空的字符串片段不会出现在 Parts 中
+type InterpolatedString struct {
+	// 第一段字符串，INTERP_START
+	Token token.Token
+	Parts []Expression
+	// 最后一段字符串，INTERP_END
+	EndToken token.Token
+}
+
+// expressionNode 实现了 Expression 接口的方法
+func (is *InterpolatedString) expressionNode() {}
+
+// TokenLiteral 实现了 Expression 接口的方法
+func (is *InterpolatedString) TokenLiteral() string {
+	return is.Token.Literal
+}
+
+// Pos 实现了 Expression 接口的方法
+func (is *InterpolatedString) Pos() token.Position {
+	return is.Token.Pos
+}
+
+// End 实现了 Expression 接口的方法
+func (is *InterpolatedString) End() token.Position {
+	return is.EndToken.End
+}
+
+// String 实现了 Expression 接口的方法
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("\"")
+	for _, part := range is.Parts {
+		if str, ok := part.(*StringLiteral); ok {
+			out.WriteString(str.Value)
+		} else {
+			out.WriteString("${" + part.String() + "}")
+		}
+	}
+	out.WriteString("\"")
+
+	return out.String()
+}
+
 // InfixExpression 中缀表达式节点，如 a + b
 // 包含左右操作数和操作符
 type InfixExpression struct {
@@ -139,6 +228,22 @@ func (p *InfixExpression) TokenLiteral() string {
 	return p.Token.Literal
 }
 
+// Pos 实现了 Expression 接口的方法
+func (p *InfixExpression) Pos() token.Position {
+	if p.Left != nil {
+		return p.Left.Pos()
+	}
+	return p.Token.Pos
+}
+
+// End 实现了 Expression 接口的方法
+func (p *InfixExpression) End() token.Position {
+	if p.Right != nil {
+		return p.Right.End()
+	}
+	return p.Token.End
+}
+
 // String 实现了 Expression 接口的方法
 func (p *InfixExpression) String() string {
 	var out bytes.Buffer
@@ -170,6 +275,19 @@ func (p *PrefixExpression) TokenLiteral() string {
 	return p.Token.Literal
 }
 
+// Pos 实现了 Expression 接口的方法
+func (p *PrefixExpression) Pos() token.Position {
+	return p.Token.Pos
+}
+
+// End 实现了 Expression 接口的方法
+func (p *PrefixExpression) End() token.Position {
+	if p.Right != nil {
+		return p.Right.End()
+	}
+	return p.Token.End
+}
+
 // String 实现了 Expression 接口的方法
 func (p *PrefixExpression) String() string {
 	var out bytes.Buffer
@@ -188,7 +306,16 @@ type FunctionLiteral struct {
 	// fn 关键字
 	Token      token.Token
 	Parameters []*Identifier
-	Body       *BlockStatement
+	// 参数的默认值，和 Parameters 一一对应，没有默认值的参数对应 nil
+	Defaults []Expression
+	// 剩余参数，如 fn(a, ...rest) 中的 rest，可以为 nil
+	Rest *Identifier
+	Body *BlockStatement
+	// 函数的名字，函数字面量直接赋给 let 声明的变量时就是变量名，否则为空
+	Name string
+	// fn 之前的文档注释，没有时为 nil
+	// 函数字面量直接赋给变量时，文档注释通常写在 let 之前，属于 LetStatement
+	Doc *token.CommentGroup
 }
 
 // expressionNode 实现了 Expression 接口的方法
@@ -199,24 +326,60 @@ func (fl *FunctionLiteral) TokenLiteral() string {
 	return fl.Token.Literal
 }
 
+// Pos 实现了 Expression 接口的方法
+func (fl *FunctionLiteral) Pos() token.Position {
+	return fl.Token.Pos
+}
+
+// End 实现了 Expression 接口的方法
+func (fl *FunctionLiteral) End() token.Position {
+	if fl.Body != nil {
+		return fl.Body.End()
+	}
+	return fl.Token.End
+}
+
 // String 实现了 Expression 接口的方法
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
-	params := []string{}
-	for _, p := range fl.Parameters {
-		params = append(params, p.String())
-	}
-
+	writeDoc(&out, fl.Doc)
 	out.WriteString(fl.TokenLiteral())
 	out.WriteString("(")
-	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(ParametersString(fl.Parameters, fl.Defaults, fl.Rest))
 	out.WriteString(")")
 	out.WriteString(fl.Body.String())
 
 	return out.String()
 }
 
+// writeDoc 输出文档注释，每一行注释之后换行，这样节点的字符串表示可以保留文档注释
+func writeDoc(out *bytes.Buffer, doc *token.CommentGroup) {
+	if doc == nil {
+		return
+	}
+	for _, comment := range doc.List {
+		out.WriteString(comment.Text + "\n")
+	}
+}
+
+// ParametersString 返回参数列表的字符串表示，如 a, b = 2, ...rest
+// 函数字面量和函数对象都用它输出参数列表
+func ParametersString(parameters []*Identifier, defaults []Expression, rest *Identifier) string {
+	params := []string{}
+	for i, p := range parameters {
+		if i < len(defaults) && defaults[i] != nil {
+			params = append(params, p.String()+" = "+defaults[i].String())
+		} else {
+			params = append(params, p.String())
+		}
+	}
+	if rest != nil {
+		params = append(params, "..."+rest.String())
+	}
+	return strings.Join(params, ", ")
+}
+
 // Boolean 布尔字面量节点，表示 true 或 false
 // 用于表示布尔类型的值
 type Boolean struct {
@@ -232,6 +395,16 @@ func (b *Boolean) TokenLiteral() string {
 	return b.Token.Literal
 }
 
+// Pos 实现了 Expression 接口的方法
+func (b *Boolean) Pos() token.Position {
+	return b.Token.Pos
+}
+
+// End 实现了 Expression 接口的方法
+func (b *Boolean) End() token.Position {
+	return b.Token.End
+}
+
 // String 实现了 Expression 接口的方法
 func (b *Boolean) String() string {
 	return b.Token.Literal
@@ -255,6 +428,22 @@ func (ie *IfExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
 
+// Pos 实现了 Expression 接口的方法
+func (ie *IfExpression) Pos() token.Position {
+	return ie.Token.Pos
+}
+
+// End 实现了 Expression 接口的方法
+func (ie *IfExpression) End() token.Position {
+	if ie.Alternative != nil {
+		return ie.Alternative.End()
+	}
+	if ie.Consequence != nil {
+		return ie.Consequence.End()
+	}
+	return ie.Token.End
+}
+
 // String 实现了 Expression 接口的方法
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
@@ -279,6 +468,8 @@ type CallExpression struct {
 	Token     token.Token
 	Function  Expression
 	Arguments []Expression
+	// )
+	Rparen token.Token
 }
 
 // expressionNode 实现了 Expression 接口的方法
@@ -289,6 +480,19 @@ func (ce *CallExpression) TokenLiteral() string {
 	return ce.Token.Literal
 }
 
+// Pos 实现了 Expression 接口的方法
+func (ce *CallExpression) Pos() token.Position {
+	if ce.Function != nil {
+		return ce.Function.Pos()
+	}
+	return ce.Token.Pos
+}
+
+// End 实现了 Expression 接口的方法
+func (ce *CallExpression) End() token.Position {
+	return ce.Rparen.End
+}
+
 // String 实现了 Expression 接口的方法
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
@@ -311,7 +515,16 @@ func (ce *CallExpression) String() string {
 type HashLiteral struct {
 	// {
 	Token token.Token
-	Pairs map[Expression]Expression
+	// 键值对，按源码中的顺序排列
+	Pairs []HashPair
+	// }
+	Rbrace token.Token
+}
+
+// HashPair 哈希字面量中的一个键值对
+type HashPair struct {
+	Key   Expression
+	Value Expression
 }
 
 // expressionNode 实现了 Expression 接口的方法
@@ -322,13 +535,23 @@ func (hl *HashLiteral) TokenLiteral() string {
 	return hl.Token.Literal
 }
 
+// Pos 实现了 Expression 接口的方法
+func (hl *HashLiteral) Pos() token.Position {
+	return hl.Token.Pos
+}
+
+// End 实现了 Expression 接口的方法
+func (hl *HashLiteral) End() token.Position {
+	return hl.Rbrace.End
+}
+
 // String 实现了 Expression 接口的方法
 func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, pair := range hl.Pairs {
+		pairs = append(pairs, pair.Key.String()+":"+pair.Value.String())
 	}
 
 	out.WriteString("{")
@@ -344,6 +567,8 @@ type ArrayLiteral struct {
 	// [
 	Token    token.Token
 	Elements []Expression
+	// ]
+	Rbracket token.Token
 }
 
 // expressionNode 实现了 Expression 接口的方法
@@ -354,6 +579,16 @@ func (al *ArrayLiteral) TokenLiteral() string {
 	return al.Token.Literal
 }
 
+// Pos 实现了 Expression 接口的方法
+func (al *ArrayLiteral) Pos() token.Position {
+	return al.Token.Pos
+}
+
+// End 实现了 Expression 接口的方法
+func (al *ArrayLiteral) End() token.Position {
+	return al.Rbracket.End
+}
+
 // String 实现了 Expression 接口的方法
 func (al *ArrayLiteral) String() string {
 	var out bytes.Buffer
@@ -370,6 +605,50 @@ func (al *ArrayLiteral) String() string {
 	return out.String()
 }
 
+// TupleLiteral 元组字面量节点，如 #(1, "a")
+// 元组创建后不能修改，可以作为哈希表的键
+type TupleLiteral struct {
+	// #(
+	Token    token.Token
+	Elements []Expression
+	// )
+	Rparen token.Token
+}
+
+// expressionNode 实现了 Expression 接口的方法
+func (tl *TupleLiteral) expressionNode() {}
+
+// TokenLiteral 实现了 Expression 接口的方法
+func (tl *TupleLiteral) TokenLiteral() string {
+	return tl.Token.Literal
+}
+
+// Pos 实现了 Expression 接口的方法
+func (tl *TupleLiteral) Pos() token.Position {
+	return tl.Token.Pos
+}
+
+// End 实现了 Expression 接口的方法
+func (tl *TupleLiteral) End() token.Position {
+	return tl.Rparen.End
+}
+
+// String 实现了 Expression 接口的方法
+func (tl *TupleLiteral) String() string {
+	var out bytes.Buffer
+
+	var elements []string
+	for _, el := range tl.Elements {
+		elements = append(elements, el.String())
+	}
+
+	out.WriteString("#(")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString(")")
+
+	return out.String()
+}
+
 // IndexExpression 索引表达式节点，如 array[index]
 // 表示对数组，map 或字符串的索引访问，
 type IndexExpression struct {
@@ -377,6 +656,8 @@ type IndexExpression struct {
 	Token token.Token
 	Left  Expression
 	Index Expression
+	// ]
+	Rbracket token.Token
 }
 
 // expressionNode 实现了 Expression 接口的方法
@@ -387,6 +668,19 @@ func (ie *IndexExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
 
+// Pos 实现了 Expression 接口的方法
+func (ie *IndexExpression) Pos() token.Position {
+	if ie.Left != nil {
+		return ie.Left.Pos()
+	}
+	return ie.Token.Pos
+}
+
+// End 实现了 Expression 接口的方法
+func (ie *IndexExpression) End() token.Position {
+	return ie.Rbracket.End
+}
+
 // String 实现了 Expression 接口的方法
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
@@ -401,6 +695,55 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+// AssignExpression 赋值表达式节点，如 x = 5，x += 1，array[0] = 1
+// 给已经声明的变量或者数组、哈希表的元素赋值
+type AssignExpression struct {
+	// 赋值运算符，=，+=，-=，*=，/=，%=
+	Token token.Token
+	// 赋值的目标，只能是标识符或者索引表达式
+	Target Expression
+	// 赋值运算符
+	Operator string
+	Value    Expression
+}
+
+// expressionNode 实现了 Expression 接口的方法
+func (ae *AssignExpression) expressionNode() {}
+
+// TokenLiteral 实现了 Expression 接口的方法
+func (ae *AssignExpression) TokenLiteral() string {
+	return ae.Token.Literal
+}
+
+// Pos 实现了 Expression 接口的方法
+func (ae *AssignExpression) Pos() token.Position {
+	if ae.Target != nil {
+		return ae.Target.Pos()
+	}
+	return ae.Token.Pos
+}
+
+// End 实现了 Expression 接口的方法
+func (ae *AssignExpression) End() token.Position {
+	if ae.Value != nil {
+		return ae.Value.End()
+	}
+	return ae.Token.End
+}
+
+// String 实现了 Expression 接口的方法
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ae.Target.String())
+	out.WriteString(" " + ae.Operator + " ")
+	out.WriteString(ae.Value.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
 // LetStatement let语句节点，如 let x = 5;
 // 用于声明并初始化变量
 type LetStatement struct {
@@ -408,6 +751,8 @@ type LetStatement struct {
 	Token token.Token
 	Name  *Identifier
 	Value Expression
+	// let 之前的文档注释，没有时为 nil
+	Doc *token.CommentGroup
 }
 
 // statementNode 实现 Statement 接口的方法
@@ -418,10 +763,27 @@ func (ls *LetStatement) TokenLiteral() string {
 	return ls.Token.Literal
 }
 
+// Pos 实现 Statement 接口的方法
+func (ls *LetStatement) Pos() token.Position {
+	return ls.Token.Pos
+}
+
+// End 实现 Statement 接口的方法
+func (ls *LetStatement) End() token.Position {
+	if ls.Value != nil {
+		return ls.Value.End()
+	}
+	if ls.Name != nil {
+		return ls.Name.End()
+	}
+	return ls.Token.End
+}
+
 // String 实现 Statement 接口的方法
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
+	writeDoc(&out, ls.Doc)
 	out.WriteString(ls.TokenLiteral() + " ")
 	out.WriteString(ls.Name.String())
 	out.WriteString(" = ")
@@ -435,6 +797,58 @@ func (ls *LetStatement) String() string {
 	return out.String()
 }
 
+// LetTupleStatement 解构的 let 语句节点，如 let #(x, y) = point;
+// 把元组或数组的元素依次赋给多个变量，元素的个数必须和变量的个数相同
+type LetTupleStatement struct {
+	// let
+	Token token.Token
+	Names []*Identifier
+	Value Expression
+}
+
+// statementNode 实现 Statement 接口的方法
+func (ls *LetTupleStatement) statementNode() {}
+
+// TokenLiteral 实现 Statement 接口的方法
+func (ls *LetTupleStatement) TokenLiteral() string {
+	return ls.Token.Literal
+}
+
+// Pos 实现 Statement 接口的方法
+func (ls *LetTupleStatement) Pos() token.Position {
+	return ls.Token.Pos
+}
+
+// End 实现 Statement 接口的方法
+func (ls *LetTupleStatement) End() token.Position {
+	if ls.Value != nil {
+		return ls.Value.End()
+	}
+	return ls.Token.End
+}
+
+// String 实现 Statement 接口的方法
+func (ls *LetTupleStatement) String() string {
+	var out bytes.Buffer
+
+	var names []string
+	for _, name := range ls.Names {
+		names = append(names, name.String())
+	}
+
+	out.WriteString(ls.TokenLiteral() + " #(")
+	out.WriteString(strings.Join(names, ", "))
+	out.WriteString(") = ")
+
+	if ls.Value != nil {
+		out.WriteString(ls.Value.String())
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
+
 // ReturnStatement return 语句节点，如 return x;
 // 用于表示从函数中返回值
 type ReturnStatement struct {
@@ -451,6 +865,19 @@ func (rs *ReturnStatement) TokenLiteral() string {
 	return rs.Token.Literal
 }
 
+// Pos 实现 Statement 接口的方法
+func (rs *ReturnStatement) Pos() token.Position {
+	return rs.Token.Pos
+}
+
+// End 实现 Statement 接口的方法
+func (rs *ReturnStatement) End() token.Position {
+	if rs.ReturnValue != nil {
+		return rs.ReturnValue.End()
+	}
+	return rs.Token.End
+}
+
 // String 实现 Statement 接口的方法
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
@@ -481,6 +908,22 @@ func (es *ExpressionStatement) TokenLiteral() string {
 	return es.Token.Literal
 }
 
+// Pos 实现 Statement 接口的方法
+func (es *ExpressionStatement) Pos() token.Position {
+	if es.Expression != nil {
+		return es.Expression.Pos()
+	}
+	return es.Token.Pos
+}
+
+// End 实现 Statement 接口的方法
+func (es *ExpressionStatement) End() token.Position {
+	if es.Expression != nil {
+		return es.Expression.End()
+	}
+	return es.Token.End
+}
+
 // String 实现 Statement 接口的方法
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
@@ -495,6 +938,8 @@ type BlockStatement struct {
 	// {
 	Token      token.Token
 	Statements []Statement
+	// }
+	Rbrace token.Token
 }
 
 // statementNode 实现 Statement 接口的方法
@@ -505,6 +950,16 @@ func (bs *BlockStatement) TokenLiteral() string {
 	return bs.Token.Literal
 }
 
+// Pos 实现 Statement 接口的方法
+func (bs *BlockStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+
+// End 实现 Statement 接口的方法
+func (bs *BlockStatement) End() token.Position {
+	return bs.Rbrace.End
+}
+
 // String 实现 Statement 接口的方法
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
@@ -516,6 +971,210 @@ func (bs *BlockStatement) String() string {
 	return out.String()
 }
 
+// WhileStatement while 循环语句节点，如 while (x < 10) { x += 1; }
+// 条件为真时重复执行循环体
+type WhileStatement struct {
+	// while
+	Token     token.Token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+// statementNode 实现 Statement 接口的方法
+func (ws *WhileStatement) statementNode() {}
+
+// TokenLiteral 实现 Statement 接口的方法
+func (ws *WhileStatement) TokenLiteral() string {
+	return ws.Token.Literal
+}
+
+// Pos 实现 Statement 接口的方法
+func (ws *WhileStatement) Pos() token.Position {
+	return ws.Token.Pos
+}
+
+// End 实现 Statement 接口的方法
+func (ws *WhileStatement) End() token.Position {
+	if ws.Body != nil {
+		return ws.Body.End()
+	}
+	return ws.Token.End
+}
+
+// String 实现 Statement 接口的方法
+func (ws *WhileStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("while")
+	out.WriteString(ws.Condition.String())
+	out.WriteString(" ")
+	out.WriteString(ws.Body.String())
+
+	return out.String()
+}
+
+// ForStatement C 风格的 for 循环语句节点，如 for (let i = 0; i < 10; i += 1) { ... }
+// 初始化语句、条件和每次循环后执行的表达式都可以省略，省略条件时一直循环
+type ForStatement struct {
+	// for
+	Token token.Token
+	// 初始化语句，let 语句或表达式语句，可以为 nil
+	Init Statement
+	// 循环条件，可以为 nil
+	Condition Expression
+	// 每次循环后执行的表达式，可以为 nil
+	Post Expression
+	Body *BlockStatement
+}
+
+// statementNode 实现 Statement 接口的方法
+func (fs *ForStatement) statementNode() {}
+
+// TokenLiteral 实现 Statement 接口的方法
+func (fs *ForStatement) TokenLiteral() string {
+	return fs.Token.Literal
+}
+
+// Pos 实现 Statement 接口的方法
+func (fs *ForStatement) Pos() token.Position {
+	return fs.Token.Pos
+}
+
+// End 实现 Statement 接口的方法
+func (fs *ForStatement) End() token.Position {
+	if fs.Body != nil {
+		return fs.Body.End()
+	}
+	return fs.Token.End
+}
+
+// String 实现 Statement 接口的方法
+func (fs *ForStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for (")
+	if fs.Init != nil {
+		out.WriteString(strings.TrimSuffix(fs.Init.String(), ";"))
+	}
+	out.WriteString("; ")
+	if fs.Condition != nil {
+		out.WriteString(fs.Condition.String())
+	}
+	out.WriteString("; ")
+	if fs.Post != nil {
+		out.WriteString(fs.Post.String())
+	}
+	out.WriteString(") ")
+	out.WriteString(fs.Body.String())
+
+	return out.String()
+}
+
+// ForInStatement for-in 循环语句节点，如 for (x in array) { ... }
+// 依次把数组的元素、字符串的字符或哈希表的键赋给循环变量，然后执行循环体
+type ForInStatement struct {
+	// for
+	Token token.Token
+	// 循环变量
+	Variable *Identifier
+	// 被遍历的表达式
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+// statementNode 实现 Statement 接口的方法
+func (fs *ForInStatement) statementNode() {}
+
+// TokenLiteral 实现 Statement 接口的方法
+func (fs *ForInStatement) TokenLiteral() string {
+	return fs.Token.Literal
+}
+
+// Pos 实现 Statement 接口的方法
+func (fs *ForInStatement) Pos() token.Position {
+	return fs.Token.Pos
+}
+
+// End 实现 Statement 接口的方法
+func (fs *ForInStatement) End() token.Position {
+	if fs.Body != nil {
+		return fs.Body.End()
+	}
+	return fs.Token.End
+}
+
+// String 实现 Statement 接口的方法
+func (fs *ForInStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for (")
+	out.WriteString(fs.Variable.String())
+	out.WriteString(" in ")
+	out.WriteString(fs.Iterable.String())
+	out.WriteString(") ")
+	out.WriteString(fs.Body.String())
+
+	return out.String()
+}
+
+// BreakStatement break 语句节点，结束所在的循环
+type BreakStatement struct {
+	// break
+	Token token.Token
+}
+
+// statementNode 实现 Statement 接口的方法
+func (bs *BreakStatement) statementNode() {}
+
+// TokenLiteral 实现 Statement 接口的方法
+func (bs *BreakStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+
+// Pos 实现 Statement 接口的方法
+func (bs *BreakStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+
+// End 实现 Statement 接口的方法
+func (bs *BreakStatement) End() token.Position {
+	return bs.Token.End
+}
+
+// String 实现 Statement 接口的方法
+func (bs *BreakStatement) String() string {
+	return bs.TokenLiteral() + ";"
+}
+
+// ContinueStatement continue 语句节点，跳过循环体剩下的语句，开始下一次循环
+type ContinueStatement struct {
+	// continue
+	Token token.Token
+}
+
+// statementNode 实现 Statement 接口的方法
+func (cs *ContinueStatement) statementNode() {}
+
+// TokenLiteral 实现 Statement 接口的方法
+func (cs *ContinueStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+
+// Pos 实现 Statement 接口的方法
+func (cs *ContinueStatement) Pos() token.Position {
+	return cs.Token.Pos
+}
+
+// End 实现 Statement 接口的方法
+func (cs *ContinueStatement) End() token.Position {
+	return cs.Token.End
+}
+
+// String 实现 Statement 接口的方法
+func (cs *ContinueStatement) String() string {
+	return cs.TokenLiteral() + ";"
+}
+
 // TokenLiteral 实现了 Expression 接口的方法
 // 返回程序第一个语句的字面量，如果不存在语句则返回空字符串
 func (p *Program) TokenLiteral() string {
@@ -535,3 +1194,21 @@ func (p *Program) String() string {
 
 	return out.String()
 }
+
+// Pos 实现了 Node 接口的方法
+// 返回程序第一个语句的位置
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
+// End 实现了 Node 接口的方法
+// 返回程序最后一个语句的结束位置
+func (p *Program) End() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[len(p.Statements)-1].End()
+	}
+	return token.Position{}
+}
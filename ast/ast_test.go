@@ -96,6 +96,29 @@ func TestStringLiteral(t *testing.T) {
 	}
 }
 
+// 测试 InterpolatedString
+func TestInterpolatedString(t *testing.T) {
+	interpolatedStrings := []expressions{
+		{
+			expression: &InterpolatedString{
+				Token: token.Token{Type: token.INTERP_START, Literal: "hello "},
+				Parts: []Expression{
+					&StringLiteral{Token: token.Token{Type: token.INTERP_START, Literal: "hello "}, Value: "hello "},
+					&Identifier{Token: token.Token{Type: token.IDENTIFIER, Literal: "name"}, Value: "name"},
+					&StringLiteral{Token: token.Token{Type: token.INTERP_END, Literal: "!"}, Value: "!"},
+				},
+				EndToken: token.Token{Type: token.INTERP_END, Literal: "!"},
+			},
+			expectedLiteral: "hello ",
+			expectedString:  `"hello ${name}!"`,
+		},
+	}
+
+	if !testExpression(t, interpolatedStrings) {
+		return
+	}
+}
+
 // 测试 InfixExpression
 func TestInfixExpression(t *testing.T) {
 	infixes := []expressions{
@@ -303,46 +326,46 @@ func TestHashLiteral(t *testing.T) {
 		{
 			expression: &HashLiteral{
 				Token: token.Token{Type: token.STRING, Literal: ""},
-				Pairs: map[Expression]Expression{
-					&StringLiteral{
+				Pairs: []HashPair{
+					{Key: &StringLiteral{
 						Token: token.Token{
 							Type:    token.STRING,
 							Literal: "a",
 						},
 						Value: "a",
-					}: &StringLiteral{
+					}, Value: &StringLiteral{
 						Token: token.Token{
 							Type:    token.STRING,
 							Literal: "a",
 						},
 						Value: "a",
-					},
-					&StringLiteral{
+					}},
+					{Key: &StringLiteral{
 						Token: token.Token{
 							Type:    token.STRING,
 							Literal: "b",
 						},
 						Value: "b",
-					}: &StringLiteral{
+					}, Value: &StringLiteral{
 						Token: token.Token{
 							Type:    token.STRING,
 							Literal: "b",
 						},
 						Value: "b",
-					},
-					&StringLiteral{
+					}},
+					{Key: &StringLiteral{
 						Token: token.Token{
 							Type:    token.STRING,
 							Literal: "c",
 						},
 						Value: "c",
-					}: &IntegerLiteral{
+					}, Value: &IntegerLiteral{
 						Token: token.Token{
 							Type:    token.INT,
 							Literal: "1",
 						},
 						Value: 1,
-					},
+					}},
 				},
 			},
 			expectedString: "{a:a, b:b, c:1}",
@@ -397,6 +420,32 @@ func TestArrayLiteral(t *testing.T) {
 	}
 }
 
+// 测试 TupleLiteral
+func TestTupleLiteral(t *testing.T) {
+	tuples := []expressions{
+		{
+			expression: &TupleLiteral{
+				Token: token.Token{Type: token.TUPLE_START, Literal: "#("},
+				Elements: []Expression{
+					&IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "1"}, Value: 1},
+					&StringLiteral{Token: token.Token{Type: token.STRING, Literal: "a"}, Value: "a"},
+				},
+			},
+			expectedLiteral: "#(",
+			expectedString:  "#(1, a)",
+		},
+		{
+			expression:      &TupleLiteral{Token: token.Token{Type: token.TUPLE_START, Literal: "#("}},
+			expectedLiteral: "#(",
+			expectedString:  "#()",
+		},
+	}
+
+	if !testExpression(t, tuples) {
+		return
+	}
+}
+
 // 测试 IndexExpression
 func TestIndexExpression(t *testing.T) {
 	maps := []expressions{
@@ -454,46 +503,46 @@ func TestIndexExpression(t *testing.T) {
 				},
 				Left: &HashLiteral{
 					Token: token.Token{Type: token.STRING, Literal: "{"},
-					Pairs: map[Expression]Expression{
-						&StringLiteral{
+					Pairs: []HashPair{
+						{Key: &StringLiteral{
 							Token: token.Token{
 								Type:    token.STRING,
 								Literal: "a",
 							},
 							Value: "a",
-						}: &StringLiteral{
+						}, Value: &StringLiteral{
 							Token: token.Token{
 								Type:    token.STRING,
 								Literal: "a",
 							},
 							Value: "a",
-						},
-						&StringLiteral{
+						}},
+						{Key: &StringLiteral{
 							Token: token.Token{
 								Type:    token.STRING,
 								Literal: "b",
 							},
 							Value: "b",
-						}: &StringLiteral{
+						}, Value: &StringLiteral{
 							Token: token.Token{
 								Type:    token.STRING,
 								Literal: "b",
 							},
 							Value: "b",
-						},
-						&StringLiteral{
+						}},
+						{Key: &StringLiteral{
 							Token: token.Token{
 								Type:    token.STRING,
 								Literal: "c",
 							},
 							Value: "c",
-						}: &IntegerLiteral{
+						}, Value: &IntegerLiteral{
 							Token: token.Token{
 								Type:    token.INT,
 								Literal: "1",
 							},
 							Value: 1,
-						},
+						}},
 					},
 				},
 				Index: &StringLiteral{
@@ -538,6 +587,49 @@ func TestIndexExpression(t *testing.T) {
 	}
 }
 
+// 测试 AssignExpression
+func TestAssignExpression(t *testing.T) {
+	assigns := []expressions{
+		{
+			expression: &AssignExpression{
+				Token:    token.Token{Type: token.ASSIGN, Literal: "="},
+				Target:   &Identifier{Token: token.Token{Type: token.IDENTIFIER, Literal: "x"}, Value: "x"},
+				Operator: "=",
+				Value: &IntegerLiteral{
+					Token: token.Token{Type: token.INT, Literal: "5"},
+					Value: 5,
+				},
+			},
+			expectedLiteral: "=",
+			expectedString:  "(x = 5)",
+		},
+		{
+			expression: &AssignExpression{
+				Token: token.Token{Type: token.PLUS_ASSIGN, Literal: "+="},
+				Target: &IndexExpression{
+					Token: token.Token{Type: token.LBRACKET, Literal: "["},
+					Left:  &Identifier{Token: token.Token{Type: token.IDENTIFIER, Literal: "arr"}, Value: "arr"},
+					Index: &IntegerLiteral{
+						Token: token.Token{Type: token.INT, Literal: "0"},
+						Value: 0,
+					},
+				},
+				Operator: "+=",
+				Value: &IntegerLiteral{
+					Token: token.Token{Type: token.INT, Literal: "1"},
+					Value: 1,
+				},
+			},
+			expectedLiteral: "+=",
+			expectedString:  "((arr[0]) += 1)",
+		},
+	}
+
+	if !testExpression(t, assigns) {
+		return
+	}
+}
+
 // 测试 LetStatement
 func TestLetStatement(t *testing.T) {
 	lets := []statements{
@@ -558,6 +650,28 @@ func TestLetStatement(t *testing.T) {
 	}
 }
 
+// 测试 LetTupleStatement
+func TestLetTupleStatement(t *testing.T) {
+	lets := []statements{
+		{
+			statement: &LetTupleStatement{
+				Token: token.Token{Type: token.LET, Literal: "let"},
+				Names: []*Identifier{
+					{Token: token.Token{Type: token.IDENTIFIER, Literal: "x"}, Value: "x"},
+					{Token: token.Token{Type: token.IDENTIFIER, Literal: "y"}, Value: "y"},
+				},
+				Value: &Identifier{Token: token.Token{Type: token.IDENTIFIER, Literal: "point"}, Value: "point"},
+			},
+			expectedLiteral: "let",
+			expectedString:  "let #(x, y) = point;",
+		},
+	}
+
+	if !testStatement(t, lets) {
+		return
+	}
+}
+
 // 测试 ReturnStatement
 func TestReturnStatement(t *testing.T) {
 	returnPrograms := []statements{
@@ -733,6 +847,56 @@ func TestExpressionStatement(t *testing.T) {
 	}
 }
 
+// 测试循环语句
+func TestLoopStatement(t *testing.T) {
+	body := &BlockStatement{
+		Token: token.Token{Type: token.LBRACE, Literal: "{"},
+		Statements: []Statement{
+			&BreakStatement{Token: token.Token{Type: token.BREAK, Literal: "break"}},
+			&ContinueStatement{Token: token.Token{Type: token.CONTINUE, Literal: "continue"}},
+		},
+	}
+	loops := []statements{
+		{
+			statement: &WhileStatement{
+				Token:     token.Token{Type: token.WHILE, Literal: "while"},
+				Condition: &Boolean{Token: token.Token{Type: token.TRUE, Literal: "true"}, Value: true},
+				Body:      body,
+			},
+			expectedLiteral: "while",
+			expectedString:  "whiletrue break;continue;",
+		},
+		{
+			statement: &ForStatement{
+				Token: token.Token{Type: token.FOR, Literal: "for"},
+				Init: &LetStatement{
+					Token: token.Token{Type: token.LET, Literal: "let"},
+					Name:  &Identifier{Token: token.Token{Type: token.IDENTIFIER, Literal: "i"}, Value: "i"},
+					Value: &IntegerLiteral{Token: token.Token{Type: token.INT, Literal: "0"}, Value: 0},
+				},
+				Condition: &Identifier{Token: token.Token{Type: token.IDENTIFIER, Literal: "i"}, Value: "i"},
+				Body:      body,
+			},
+			expectedLiteral: "for",
+			expectedString:  "for (let i = 0; i; ) break;continue;",
+		},
+		{
+			statement: &ForInStatement{
+				Token:    token.Token{Type: token.FOR, Literal: "for"},
+				Variable: &Identifier{Token: token.Token{Type: token.IDENTIFIER, Literal: "i"}, Value: "i"},
+				Iterable: &Identifier{Token: token.Token{Type: token.IDENTIFIER, Literal: "arr"}, Value: "arr"},
+				Body:     body,
+			},
+			expectedLiteral: "for",
+			expectedString:  "for (i in arr) break;continue;",
+		},
+	}
+
+	if !testStatement(t, loops) {
+		return
+	}
+}
+
 // 测试 BlockStatement
 func TestBlockStatement(t *testing.T) {
 	blocks := []statements{
@@ -0,0 +1,284 @@
+package code
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Instructions 字节码指令序列，每条指令由一个字节的操作码和若干个操作数组成
+type Instructions []byte
+
+// String 返回指令序列的反汇编结果，每行一条指令，如 0000 OpConstant 1
+func (ins Instructions) String() string {
+	var out bytes.Buffer
+
+	i := 0
+	for i < len(ins) {
+		def, err := Lookup(ins[i])
+		if err != nil {
+			fmt.Fprintf(&out, "ERROR: %s\n", err)
+			return out.String()
+		}
+
+		operands, read := ReadOperands(def, ins[i+1:])
+		fmt.Fprintf(&out, "%04d %s\n", i, ins.fmtInstruction(def, operands))
+
+		i += 1 + read
+	}
+
+	return out.String()
+}
+
+// 格式化一条指令
+func (ins Instructions) fmtInstruction(def *Definition, operands []int) string {
+	operandCount := len(def.OperandWidths)
+
+	if len(operands) != operandCount {
+		return fmt.Sprintf("ERROR: operand len %d does not match defined %d\n", len(operands), operandCount)
+	}
+
+	switch operandCount {
+	case 0:
+		return def.Name
+	case 1:
+		return fmt.Sprintf("%s %d", def.Name, operands[0])
+	case 2:
+		return fmt.Sprintf("%s %d %d", def.Name, operands[0], operands[1])
+	}
+
+	return fmt.Sprintf("ERROR: unhandled operandCount for %s\n", def.Name)
+}
+
+// Opcode 操作码
+type Opcode byte
+
+const (
+	// 把常量池中的常量压入栈中，操作数是常量的下标
+	OpConstant Opcode = iota
+	// 弹出栈顶的值
+	OpPop
+	// 复制栈顶的两个值，用于复合赋值时读取元素的当前值
+	OpDup2
+
+	// 算术运算，弹出两个操作数，压入运算结果
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpMod
+	OpPow
+
+	// 把 true、false、null 压入栈中
+	OpTrue
+	OpFalse
+	OpNull
+
+	// 比较运算，弹出两个操作数，压入比较结果
+	OpEqual
+	OpNotEqual
+	OpLessThan
+	OpLessEqual
+	OpGreaterThan
+	OpGreaterEqual
+
+	// 前缀运算，弹出一个操作数，压入运算结果
+	OpMinus
+	OpBang
+
+	// 跳转到操作数指定的位置
+	OpJump
+	// 弹出栈顶的值，值为假时跳转到操作数指定的位置
+	OpJumpNotTruthy
+	// 操作数指定的局部变量已经有值时跳转，用于跳过参数默认值的计算
+	OpJumpIfBound
+
+	// 读取全局变量，操作数是全局变量的下标
+	OpGetGlobal
+	// 声明全局变量，弹出栈顶的值赋给全局变量
+	OpSetGlobal
+	// 给已经声明的全局变量赋值，弹出栈顶的值，变量没有声明时出错
+	OpAssignGlobal
+	// 读取局部变量，操作数是局部变量的下标
+	OpGetLocal
+	// 给局部变量赋值，弹出栈顶的值
+	OpSetLocal
+	// 读取闭包捕获的变量，操作数是捕获变量的下标
+	OpGetFree
+	// 给闭包捕获的变量赋值，弹出栈顶的值
+	OpSetFree
+	// 把内置函数压入栈中，操作数是内置函数的下标
+	OpGetBuiltin
+
+	// 弹出操作数指定数量的元素，压入由这些元素组成的数组
+	OpArray
+	// 弹出操作数指定数量的元素，压入由这些元素组成的元组
+	OpTuple
+	// 弹出元组或数组，依次压入它的元素，元素的个数必须等于操作数，用于解构赋值
+	OpDestructure
+	// 弹出操作数指定数量的键和值，压入由这些键值对组成的哈希表
+	OpHash
+	// 弹出操作数指定数量的值，压入这些值的 Inspect() 连接成的字符串，用于插值字符串
+	OpInterpolate
+	// 弹出被索引的对象和索引，压入索引的结果
+	OpIndex
+	// 弹出被索引的对象、索引和值，给元素赋值后压入值
+	OpSetIndex
+
+	// 调用函数，操作数是参数的数量，栈中依次是函数和参数
+	OpCall
+	// 弹出栈顶的值作为函数的返回值
+	OpReturnValue
+	// 函数没有返回值
+	OpReturn
+	// 创建闭包，第一个操作数是函数在常量池中的下标
+	OpClosure
+	// 关闭下标不小于操作数的局部变量上的捕获变量，之后修改这些局部变量不再影响已经创建的闭包
+	OpCloseUpvalues
+
+	// 弹出被遍历的对象，压入它的迭代器
+	OpIterStart
+	// 第一个操作数是保存迭代器的局部变量，迭代器还有元素时压入下一个元素，
+	// 否则跳转到第二个操作数指定的位置
+	OpIterNext
+)
+
+// Definition 操作码的定义
+type Definition struct {
+	// 操作码的名字，用于反汇编
+	Name string
+	// 每个操作数占用的字节数
+	OperandWidths []int
+}
+
+// 所有操作码的定义
+var definitions = map[Opcode]*Definition{
+	OpConstant: {"OpConstant", []int{2}},
+	OpPop:      {"OpPop", []int{}},
+	OpDup2:     {"OpDup2", []int{}},
+
+	OpAdd: {"OpAdd", []int{}},
+	OpSub: {"OpSub", []int{}},
+	OpMul: {"OpMul", []int{}},
+	OpDiv: {"OpDiv", []int{}},
+	OpMod: {"OpMod", []int{}},
+	OpPow: {"OpPow", []int{}},
+
+	OpTrue:  {"OpTrue", []int{}},
+	OpFalse: {"OpFalse", []int{}},
+	OpNull:  {"OpNull", []int{}},
+
+	OpEqual:        {"OpEqual", []int{}},
+	OpNotEqual:     {"OpNotEqual", []int{}},
+	OpLessThan:     {"OpLessThan", []int{}},
+	OpLessEqual:    {"OpLessEqual", []int{}},
+	OpGreaterThan:  {"OpGreaterThan", []int{}},
+	OpGreaterEqual: {"OpGreaterEqual", []int{}},
+
+	OpMinus: {"OpMinus", []int{}},
+	OpBang:  {"OpBang", []int{}},
+
+	OpJump:          {"OpJump", []int{2}},
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}},
+	OpJumpIfBound:   {"OpJumpIfBound", []int{1, 2}},
+
+	OpGetGlobal:    {"OpGetGlobal", []int{2}},
+	OpSetGlobal:    {"OpSetGlobal", []int{2}},
+	OpAssignGlobal: {"OpAssignGlobal", []int{2}},
+	OpGetLocal:     {"OpGetLocal", []int{1}},
+	OpSetLocal:     {"OpSetLocal", []int{1}},
+	OpGetFree:      {"OpGetFree", []int{1}},
+	OpSetFree:      {"OpSetFree", []int{1}},
+	OpGetBuiltin:   {"OpGetBuiltin", []int{1}},
+
+	OpArray:       {"OpArray", []int{2}},
+	OpTuple:       {"OpTuple", []int{2}},
+	OpDestructure: {"OpDestructure", []int{2}},
+	OpHash:        {"OpHash", []int{2}},
+	OpInterpolate: {"OpInterpolate", []int{2}},
+	OpIndex:       {"OpIndex", []int{}},
+	OpSetIndex:    {"OpSetIndex", []int{}},
+
+	OpCall:          {"OpCall", []int{1}},
+	OpReturnValue:   {"OpReturnValue", []int{}},
+	OpReturn:        {"OpReturn", []int{}},
+	OpClosure:       {"OpClosure", []int{2}},
+	OpCloseUpvalues: {"OpCloseUpvalues", []int{1}},
+
+	OpIterStart: {"OpIterStart", []int{}},
+	OpIterNext:  {"OpIterNext", []int{1, 2}},
+}
+
+// Lookup 查找操作码的定义
+func Lookup(op byte) (*Definition, error) {
+	def, ok := definitions[Opcode(op)]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+
+	return def, nil
+}
+
+// MaxOperand 返回宽度为 width 字节的操作数的最大值
+func MaxOperand(width int) int {
+	return 1<<(8*width) - 1
+}
+
+// Make 生成一条指令，操作数按定义的宽度以大端序写入
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+
+	instructionLen := 1
+	for _, w := range def.OperandWidths {
+		instructionLen += w
+	}
+
+	instruction := make([]byte, instructionLen)
+	instruction[0] = byte(op)
+
+	offset := 1
+	for i, o := range operands {
+		width := def.OperandWidths[i]
+		switch width {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(o))
+		case 1:
+			instruction[offset] = byte(o)
+		}
+		offset += width
+	}
+
+	return instruction
+}
+
+// ReadOperands 按操作码的定义读取指令的操作数，返回操作数和读取的字节数
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+
+	for i, width := range def.OperandWidths {
+		switch width {
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[i] = int(ReadUint8(ins[offset:]))
+		}
+
+		offset += width
+	}
+
+	return operands, offset
+}
+
+// ReadUint16 读取两个字节的操作数
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+// ReadUint8 读取一个字节的操作数
+func ReadUint8(ins Instructions) uint8 {
+	return uint8(ins[0])
+}
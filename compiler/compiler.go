@@ -0,0 +1,920 @@
+package compiler
+
+import (
+	"fmt"
+
+	"holiya/ast"
+	"holiya/code"
+	"holiya/object"
+	"holiya/token"
+)
+
+// Error 编译错误
+type Error struct {
+	// 出错的位置
+	Pos     token.Position
+	Message string
+}
+
+// Error 实现 error 接口
+func (e *Error) Error() string {
+	if e.Pos.IsValid() {
+		return e.Pos.String() + ": " + e.Message
+	}
+	return e.Message
+}
+
+// EmittedInstruction 已经生成的指令
+type EmittedInstruction struct {
+	Opcode   code.Opcode
+	Position int
+}
+
+// CompilationScope 一个函数的编译状态，顶层代码也有自己的编译状态
+type CompilationScope struct {
+	instructions code.Instructions
+	// 指令对应的源码位置
+	positions []object.SourcePosition
+	// 最后两条生成的指令
+	lastInstruction     EmittedInstruction
+	previousInstruction EmittedInstruction
+	// 正在编译的循环，最内层的循环在最后
+	loops []*loopScope
+	// 栈中临时值的数量，break 和 continue 跳出表达式时需要先弹出这些值
+	depth int
+}
+
+// loopScope 一个循环的编译状态
+type loopScope struct {
+	// 需要回填到循环结束位置的 break 跳转指令
+	breaks []int
+	// 需要回填到下一次循环开始位置的 continue 跳转指令
+	continues []int
+	// 循环开始时栈中临时值的数量
+	depth int
+}
+
+// Bytecode 编译的结果
+type Bytecode struct {
+	// 顶层代码编译成的函数
+	Main *object.CompiledFunction
+	// 常量池
+	Constants []object.Object
+	// 全局变量的名字，下标就是全局变量的下标，用于错误信息
+	GlobalNames []string
+}
+
+// Compiler 编译器，把 AST 编译成字节码
+type Compiler struct {
+	constants []object.Object
+
+	symbolTable *SymbolTable
+
+	scopes     []CompilationScope
+	scopeIndex int
+
+	// 正在编译的节点的位置，生成的指令都对应这个位置
+	pos token.Position
+	// 第一个超出宽度的操作数的错误，Compile 返回时报告
+	err *Error
+}
+
+// New 创建编译器
+func New() *Compiler {
+	return &Compiler{
+		constants:   []object.Object{},
+		symbolTable: NewSymbolTable(),
+		scopes:      []CompilationScope{{}},
+	}
+}
+
+// NewWithState 使用已有的符号表和常量池创建编译器
+// REPL 中每次输入都重新编译，但是需要保留前面定义的全局变量
+func NewWithState(s *SymbolTable, constants []object.Object) *Compiler {
+	compiler := New()
+	compiler.symbolTable = s
+	compiler.constants = constants
+	return compiler
+}
+
+// Compile 编译节点，出错时返回 *Error
+func (c *Compiler) Compile(node ast.Node) (err error) {
+	pos := c.pos
+	c.pos = node.Pos()
+	defer func() {
+		c.pos = pos
+		if err == nil && c.err != nil {
+			err = c.err
+		}
+	}()
+
+	switch node := node.(type) {
+	case *ast.Program:
+		return c.compileProgram(node)
+	case *ast.ExpressionStatement:
+		if err := c.Compile(node.Expression); err != nil {
+			return err
+		}
+		c.emit(code.OpPop)
+	case *ast.LetStatement:
+		return c.compileLetStatement(node)
+	case *ast.LetTupleStatement:
+		return c.compileLetTupleStatement(node)
+	case *ast.ReturnStatement:
+		if err := c.Compile(node.ReturnValue); err != nil {
+			return err
+		}
+		c.emit(code.OpReturnValue)
+	case *ast.BlockStatement:
+		return c.compileStatements(node.Statements)
+	case *ast.WhileStatement:
+		return c.compileWhileStatement(node)
+	case *ast.ForStatement:
+		return c.compileForStatement(node)
+	case *ast.ForInStatement:
+		return c.compileForInStatement(node)
+	case *ast.BreakStatement:
+		return c.compileLoopControl("break")
+	case *ast.ContinueStatement:
+		return c.compileLoopControl("continue")
+	case *ast.Identifier:
+		c.loadSymbol(c.resolve(node.Value))
+	case *ast.IntegerLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.Integer{Value: node.Value}))
+	case *ast.FloatLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.Float{Value: node.Value}))
+	case *ast.StringLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.String{Value: node.Value}))
+	case *ast.InterpolatedString:
+		for _, part := range node.Parts {
+			if err := c.Compile(part); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpInterpolate, len(node.Parts))
+	case *ast.Boolean:
+		if node.Value {
+			c.emit(code.OpTrue)
+		} else {
+			c.emit(code.OpFalse)
+		}
+	case *ast.PrefixExpression:
+		return c.compilePrefixExpression(node)
+	case *ast.InfixExpression:
+		return c.compileInfixExpression(node)
+	case *ast.IfExpression:
+		return c.compileIfExpression(node)
+	case *ast.FunctionLiteral:
+		return c.compileFunctionLiteral(node)
+	case *ast.CallExpression:
+		if err := c.Compile(node.Function); err != nil {
+			return err
+		}
+		for _, argument := range node.Arguments {
+			if err := c.Compile(argument); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpCall, len(node.Arguments))
+	case *ast.ArrayLiteral:
+		for _, element := range node.Elements {
+			if err := c.Compile(element); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpArray, len(node.Elements))
+	case *ast.TupleLiteral:
+		for _, element := range node.Elements {
+			if err := c.Compile(element); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpTuple, len(node.Elements))
+	case *ast.HashLiteral:
+		return c.compileHashLiteral(node)
+	case *ast.IndexExpression:
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+		if err := c.Compile(node.Index); err != nil {
+			return err
+		}
+		c.emit(code.OpIndex)
+	case *ast.AssignExpression:
+		return c.compileAssignExpression(node)
+	default:
+		return c.errorf("cannot compile %T", node)
+	}
+	return nil
+}
+
+// Bytecode 返回编译的结果
+func (c *Compiler) Bytecode() *Bytecode {
+	return &Bytecode{
+		Main: &object.CompiledFunction{
+			Instructions: c.currentInstructions(),
+			NumLocals:    c.symbolTable.NumLocals(),
+			LocalNames:   c.symbolTable.LocalNames(),
+			Positions:    c.scopes[c.scopeIndex].positions,
+		},
+		Constants:   c.constants,
+		GlobalNames: c.symbolTable.GlobalNames(),
+	}
+}
+
+// 编译整个程序
+// 最后一条语句是表达式语句时，它的值就是程序的结果，否则程序没有结果
+func (c *Compiler) compileProgram(program *ast.Program) error {
+	if err := c.compileStatements(program.Statements); err != nil {
+		return err
+	}
+	if c.endsWithExpression(program.Statements) {
+		c.replaceLastPop(code.OpReturnValue)
+	} else {
+		c.emit(code.OpReturn)
+	}
+	return nil
+}
+
+// 依次编译语句
+func (c *Compiler) compileStatements(statements []ast.Statement) error {
+	for _, statement := range statements {
+		if err := c.Compile(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 编译代码块，代码块的值留在栈中
+// 最后一条语句是表达式语句时，代码块的值就是它的值，否则是 null
+func (c *Compiler) compileBlockValue(block *ast.BlockStatement) error {
+	pos := c.pos
+	c.pos = block.Pos()
+	defer func() { c.pos = pos }()
+
+	if err := c.compileStatements(block.Statements); err != nil {
+		return err
+	}
+	if c.endsWithExpression(block.Statements) {
+		c.removeLastPop()
+	} else {
+		c.emit(code.OpNull)
+	}
+	return nil
+}
+
+// 判断最后一条语句是否是表达式语句
+func (c *Compiler) endsWithExpression(statements []ast.Statement) bool {
+	if len(statements) == 0 {
+		return false
+	}
+	_, ok := statements[len(statements)-1].(*ast.ExpressionStatement)
+	return ok && c.lastInstructionIs(code.OpPop)
+}
+
+// 编译 let 语句
+// 一般先计算值再定义变量，所以 let x = x + 1 中右边的 x 是外层的变量；
+// 值是函数字面量时先定义变量，这样函数可以递归调用自己
+func (c *Compiler) compileLetStatement(node *ast.LetStatement) error {
+	var symbol Symbol
+	_, isFunction := node.Value.(*ast.FunctionLiteral)
+	if isFunction {
+		symbol = c.symbolTable.Define(node.Name.Value)
+	}
+	if err := c.Compile(node.Value); err != nil {
+		return err
+	}
+	if !isFunction {
+		symbol = c.symbolTable.Define(node.Name.Value)
+	}
+
+	if symbol.Scope == GlobalScope {
+		c.emit(code.OpSetGlobal, symbol.Index)
+	} else {
+		c.emit(code.OpSetLocal, symbol.Index)
+	}
+	return nil
+}
+
+// 编译解构的 let 语句
+// OpDestructure 依次压入元素，所以从最后一个变量开始赋值
+// 和求值器一样，同一个变量出现多次时取最后一次对应的值，前面的值直接丢弃
+func (c *Compiler) compileLetTupleStatement(node *ast.LetTupleStatement) error {
+	if err := c.Compile(node.Value); err != nil {
+		return err
+	}
+	c.emit(code.OpDestructure, len(node.Names))
+
+	symbols := make([]Symbol, len(node.Names))
+	for i, name := range node.Names {
+		symbols[i] = c.symbolTable.Define(name.Value)
+	}
+	assigned := map[string]bool{}
+	for i := len(symbols) - 1; i >= 0; i-- {
+		switch {
+		case assigned[node.Names[i].Value]:
+			c.emit(code.OpPop)
+		case symbols[i].Scope == GlobalScope:
+			c.emit(code.OpSetGlobal, symbols[i].Index)
+		default:
+			c.emit(code.OpSetLocal, symbols[i].Index)
+		}
+		assigned[node.Names[i].Value] = true
+	}
+	return nil
+}
+
+// 编译前缀表达式
+func (c *Compiler) compilePrefixExpression(node *ast.PrefixExpression) error {
+	if err := c.Compile(node.Right); err != nil {
+		return err
+	}
+	switch node.Operator {
+	case "!":
+		c.emit(code.OpBang)
+	case "-":
+		c.emit(code.OpMinus)
+	default:
+		return c.errorf("unknown operator %s", node.Operator)
+	}
+	return nil
+}
+
+// 编译中缀表达式
+func (c *Compiler) compileInfixExpression(node *ast.InfixExpression) error {
+	if node.Operator == "&&" || node.Operator == "||" {
+		return c.compileLogicalExpression(node)
+	}
+
+	op, ok := infixOpcodes[node.Operator]
+	if !ok {
+		return c.errorf("unknown operator %s", node.Operator)
+	}
+	if err := c.Compile(node.Left); err != nil {
+		return err
+	}
+	if err := c.Compile(node.Right); err != nil {
+		return err
+	}
+	c.emit(op)
+	return nil
+}
+
+// 中缀运算符对应的操作码
+var infixOpcodes = map[string]code.Opcode{
+	"+":  code.OpAdd,
+	"-":  code.OpSub,
+	"*":  code.OpMul,
+	"/":  code.OpDiv,
+	"%":  code.OpMod,
+	"**": code.OpPow,
+	"==": code.OpEqual,
+	"!=": code.OpNotEqual,
+	"<":  code.OpLessThan,
+	"<=": code.OpLessEqual,
+	">":  code.OpGreaterThan,
+	">=": code.OpGreaterEqual,
+}
+
+// 编译逻辑运算符 && 和 ||，右边的表达式只在需要时才执行
+// 结果是布尔值，右边的值用两个 OpBang 转换为布尔值
+func (c *Compiler) compileLogicalExpression(node *ast.InfixExpression) error {
+	if err := c.Compile(node.Left); err != nil {
+		return err
+	}
+	jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+	depth := c.scopes[c.scopeIndex].depth
+
+	if node.Operator == "&&" {
+		if err := c.compileTruthiness(node.Right); err != nil {
+			return err
+		}
+		jumpPos := c.emit(code.OpJump, 9999)
+		c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+		c.scopes[c.scopeIndex].depth = depth
+		c.emit(code.OpFalse)
+		c.changeOperand(jumpPos, len(c.currentInstructions()))
+		return nil
+	}
+
+	c.emit(code.OpTrue)
+	jumpPos := c.emit(code.OpJump, 9999)
+	c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+	c.scopes[c.scopeIndex].depth = depth
+	if err := c.compileTruthiness(node.Right); err != nil {
+		return err
+	}
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
+	return nil
+}
+
+// 编译表达式，并把它的值转换为布尔值
+func (c *Compiler) compileTruthiness(node ast.Expression) error {
+	if err := c.Compile(node); err != nil {
+		return err
+	}
+	c.emit(code.OpBang)
+	c.emit(code.OpBang)
+	return nil
+}
+
+// 编译 if 表达式
+func (c *Compiler) compileIfExpression(node *ast.IfExpression) error {
+	if err := c.Compile(node.Condition); err != nil {
+		return err
+	}
+	jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+	depth := c.scopes[c.scopeIndex].depth
+
+	if err := c.compileBlockValue(node.Consequence); err != nil {
+		return err
+	}
+	jumpPos := c.emit(code.OpJump, 9999)
+	c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+
+	c.scopes[c.scopeIndex].depth = depth
+	if node.Alternative == nil {
+		c.emit(code.OpNull)
+	} else if err := c.compileBlockValue(node.Alternative); err != nil {
+		return err
+	}
+	c.changeOperand(jumpPos, len(c.currentInstructions()))
+	return nil
+}
+
+// 编译哈希表字面量，键值对按源码中的顺序编译，和求值器的求值顺序相同
+func (c *Compiler) compileHashLiteral(node *ast.HashLiteral) error {
+	for _, pair := range node.Pairs {
+		if err := c.Compile(pair.Key); err != nil {
+			return err
+		}
+		if err := c.Compile(pair.Value); err != nil {
+			return err
+		}
+	}
+	c.emit(code.OpHash, len(node.Pairs))
+	return nil
+}
+
+// 编译函数字面量
+// 参数是函数的前几个局部变量，剩余参数在参数之后；
+// 有默认值的参数在函数开头检查是否已经传入，没有传入时计算默认值
+func (c *Compiler) compileFunctionLiteral(node *ast.FunctionLiteral) error {
+	c.enterScope()
+
+	for _, param := range node.Parameters {
+		c.symbolTable.Define(param.Value)
+	}
+	if node.Rest != nil {
+		c.symbolTable.Define(node.Rest.Value)
+	}
+
+	required := len(node.Parameters)
+	for i, def := range node.Defaults {
+		if def == nil {
+			continue
+		}
+		if i < required {
+			required = i
+		}
+		jumpPos := c.emit(code.OpJumpIfBound, i, 9999)
+		if err := c.Compile(def); err != nil {
+			c.leaveScope()
+			return err
+		}
+		c.emit(code.OpSetLocal, i)
+		c.changeOperand(jumpPos, i, len(c.currentInstructions()))
+	}
+
+	// 出错时也要离开函数的作用域，之后继续使用这个编译器时，变量仍然定义在外层的作用域中
+	if err := c.compileStatements(node.Body.Statements); err != nil {
+		c.leaveScope()
+		return err
+	}
+	if c.endsWithExpression(node.Body.Statements) {
+		c.replaceLastPop(code.OpReturnValue)
+	} else {
+		c.emit(code.OpReturn)
+	}
+
+	freeSymbols := c.symbolTable.FreeSymbols
+	numLocals := c.symbolTable.NumLocals()
+	localNames := c.symbolTable.LocalNames()
+	positions := c.scopes[c.scopeIndex].positions
+	instructions := c.leaveScope()
+
+	freeVariables := make([]object.FreeVariable, len(freeSymbols))
+	for i, symbol := range freeSymbols {
+		freeVariables[i] = object.FreeVariable{
+			IsLocal: symbol.Scope == LocalScope,
+			Index:   symbol.Index,
+			Name:    symbol.Name,
+		}
+	}
+
+	compiledFn := &object.CompiledFunction{
+		Instructions:  instructions,
+		NumLocals:     numLocals,
+		NumParameters: len(node.Parameters),
+		NumRequired:   required,
+		HasRest:       node.Rest != nil,
+		Name:          node.Name,
+		FreeVariables: freeVariables,
+		LocalNames:    localNames,
+		Positions:     positions,
+	}
+	c.emit(code.OpClosure, c.addConstant(compiledFn))
+	return nil
+}
+
+// 编译赋值表达式，赋值表达式的值是赋给目标的值
+func (c *Compiler) compileAssignExpression(node *ast.AssignExpression) error {
+	var op code.Opcode
+	compound := node.Operator != "="
+	if compound {
+		var ok bool
+		op, ok = infixOpcodes[node.Operator[:len(node.Operator)-1]]
+		if !ok {
+			return c.errorf("unknown operator %s", node.Operator)
+		}
+	}
+
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		symbol := c.resolve(target.Value)
+		if symbol.Scope == BuiltinScope {
+			return c.errorf("cannot assign to undeclared variable: %s", target.Value)
+		}
+		if compound {
+			c.loadSymbol(symbol)
+		}
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		if compound {
+			c.emit(op)
+		}
+		c.storeSymbol(symbol)
+		c.loadSymbol(symbol)
+	case *ast.IndexExpression:
+		if err := c.Compile(target.Left); err != nil {
+			return err
+		}
+		if err := c.Compile(target.Index); err != nil {
+			return err
+		}
+		if compound {
+			c.emit(code.OpDup2)
+			c.emit(code.OpIndex)
+		}
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		if compound {
+			c.emit(op)
+		}
+		c.emit(code.OpSetIndex)
+	default:
+		return c.errorf("cannot assign to %s", node.Target.String())
+	}
+	return nil
+}
+
+// 编译 while 语句
+func (c *Compiler) compileWhileStatement(node *ast.WhileStatement) error {
+	start := len(c.currentInstructions())
+	if err := c.Compile(node.Condition); err != nil {
+		return err
+	}
+	exitPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+	loop := c.enterLoop()
+	if err := c.Compile(node.Body); err != nil {
+		return err
+	}
+	c.leaveLoop(loop, start)
+	c.emit(code.OpJump, start)
+
+	c.changeOperand(exitPos, len(c.currentInstructions()))
+	c.patchJumps(loop.breaks, len(c.currentInstructions()))
+	return nil
+}
+
+// 编译 C 风格的 for 语句
+// 初始化语句中定义的变量在块作用域中，循环结束后关闭闭包捕获的循环变量，
+// 这样再次执行这个 for 语句时不会影响之前创建的闭包
+func (c *Compiler) compileForStatement(node *ast.ForStatement) error {
+	c.symbolTable = NewBlockSymbolTable(c.symbolTable)
+	defer func() { c.symbolTable = c.symbolTable.Outer }()
+	firstLocal := c.symbolTable.NumLocals()
+
+	if node.Init != nil {
+		if err := c.Compile(node.Init); err != nil {
+			return err
+		}
+	}
+
+	start := len(c.currentInstructions())
+	exitPos := -1
+	if node.Condition != nil {
+		if err := c.Compile(node.Condition); err != nil {
+			return err
+		}
+		exitPos = c.emit(code.OpJumpNotTruthy, 9999)
+	}
+
+	loop := c.enterLoop()
+	if err := c.Compile(node.Body); err != nil {
+		return err
+	}
+	c.leaveLoop(loop, len(c.currentInstructions()))
+	if node.Post != nil {
+		if err := c.Compile(node.Post); err != nil {
+			return err
+		}
+		c.emit(code.OpPop)
+	}
+	c.emit(code.OpJump, start)
+
+	end := len(c.currentInstructions())
+	if exitPos != -1 {
+		c.changeOperand(exitPos, end)
+	}
+	c.patchJumps(loop.breaks, end)
+	c.emit(code.OpCloseUpvalues, firstLocal)
+	return nil
+}
+
+// 编译 for-in 语句
+// 迭代器保存在一个临时的局部变量中，每次循环结束后关闭闭包捕获的循环变量，
+// 这样循环体中创建的闭包捕获的是当次循环的值
+func (c *Compiler) compileForInStatement(node *ast.ForInStatement) error {
+	if err := c.Compile(node.Iterable); err != nil {
+		return err
+	}
+	c.emit(code.OpIterStart)
+	iterator := c.symbolTable.DefineTemporary()
+	c.emit(code.OpSetLocal, iterator.Index)
+
+	c.symbolTable = NewBlockSymbolTable(c.symbolTable)
+	defer func() { c.symbolTable = c.symbolTable.Outer }()
+	firstLocal := c.symbolTable.NumLocals()
+
+	start := len(c.currentInstructions())
+	exitPos := c.emit(code.OpIterNext, iterator.Index, 9999)
+	variable := c.symbolTable.Define(node.Variable.Value)
+	c.emit(code.OpSetLocal, variable.Index)
+
+	loop := c.enterLoop()
+	if err := c.Compile(node.Body); err != nil {
+		return err
+	}
+	c.leaveLoop(loop, len(c.currentInstructions()))
+	c.emit(code.OpCloseUpvalues, firstLocal)
+	c.emit(code.OpJump, start)
+
+	end := len(c.currentInstructions())
+	c.changeOperand(exitPos, iterator.Index, end)
+	c.patchJumps(loop.breaks, end)
+	c.emit(code.OpCloseUpvalues, firstLocal)
+	return nil
+}
+
+// 开始编译循环体
+func (c *Compiler) enterLoop() *loopScope {
+	scope := &c.scopes[c.scopeIndex]
+	loop := &loopScope{depth: scope.depth}
+	scope.loops = append(scope.loops, loop)
+	return loop
+}
+
+// 结束编译循环体，把 continue 跳转到 next
+func (c *Compiler) leaveLoop(loop *loopScope, next int) {
+	scope := &c.scopes[c.scopeIndex]
+	scope.loops = scope.loops[:len(scope.loops)-1]
+	c.patchJumps(loop.continues, next)
+}
+
+// 编译 break 和 continue 语句
+// 先弹出循环中的表达式留在栈中的临时值，再跳转，跳转的位置在循环编译完后回填
+func (c *Compiler) compileLoopControl(keyword string) error {
+	scope := &c.scopes[c.scopeIndex]
+	if len(scope.loops) == 0 {
+		return c.errorf("%s statement outside loop", keyword)
+	}
+	loop := scope.loops[len(scope.loops)-1]
+
+	depth := scope.depth
+	for i := loop.depth; i < depth; i++ {
+		c.emit(code.OpPop)
+	}
+	jumpPos := c.emit(code.OpJump, 9999)
+	c.scopes[c.scopeIndex].depth = depth
+
+	if keyword == "break" {
+		loop.breaks = append(loop.breaks, jumpPos)
+	} else {
+		loop.continues = append(loop.continues, jumpPos)
+	}
+	return nil
+}
+
+// 把跳转指令的目标改为 target
+func (c *Compiler) patchJumps(positions []int, target int) {
+	for _, pos := range positions {
+		c.changeOperand(pos, target)
+	}
+}
+
+// 查找变量，找不到时定义为全局变量
+// 这样读取还没有定义的变量时在运行时报告 identifier not found 错误，
+// 而且函数可以使用在它之后才定义的全局变量
+func (c *Compiler) resolve(name string) Symbol {
+	symbol, ok := c.symbolTable.Resolve(name)
+	if !ok {
+		symbol = c.symbolTable.Global().Define(name)
+	}
+	return symbol
+}
+
+// 生成把变量的值压入栈中的指令
+func (c *Compiler) loadSymbol(s Symbol) {
+	switch s.Scope {
+	case GlobalScope:
+		c.emit(code.OpGetGlobal, s.Index)
+	case LocalScope:
+		c.emit(code.OpGetLocal, s.Index)
+	case BuiltinScope:
+		c.emit(code.OpGetBuiltin, s.Index)
+	case FreeScope:
+		c.emit(code.OpGetFree, s.Index)
+	}
+}
+
+// 生成把栈顶的值赋给已经声明的变量的指令
+func (c *Compiler) storeSymbol(s Symbol) {
+	switch s.Scope {
+	case GlobalScope:
+		c.emit(code.OpAssignGlobal, s.Index)
+	case LocalScope:
+		c.emit(code.OpSetLocal, s.Index)
+	case FreeScope:
+		c.emit(code.OpSetFree, s.Index)
+	}
+}
+
+// 把常量添加到常量池中，返回常量的下标
+func (c *Compiler) addConstant(obj object.Object) int {
+	c.constants = append(c.constants, obj)
+	return len(c.constants) - 1
+}
+
+// 生成一条指令，返回指令的位置
+func (c *Compiler) emit(op code.Opcode, operands ...int) int {
+	c.checkOperands(op, operands)
+	ins := code.Make(op, operands...)
+	pos := c.addInstruction(ins)
+	c.setLastInstruction(op, pos)
+	c.scopes[c.scopeIndex].depth += stackEffect(op, operands)
+	return pos
+}
+
+// 检查操作数是否超出操作码定义的宽度，超出时记下第一个错误，
+// 否则 code.Make 会截断操作数，程序会使用错误的常量或者变量
+func (c *Compiler) checkOperands(op code.Opcode, operands []int) {
+	if c.err != nil {
+		return
+	}
+	def, _ := code.Lookup(byte(op))
+	for i, operand := range operands {
+		limit := code.MaxOperand(def.OperandWidths[i])
+		if operand <= limit {
+			continue
+		}
+		switch {
+		case op == code.OpConstant || op == code.OpClosure:
+			c.err = c.errorf("too many constants, the limit is %d", limit+1)
+		case op == code.OpGetGlobal || op == code.OpSetGlobal || op == code.OpAssignGlobal:
+			c.err = c.errorf("too many global variables, the limit is %d", limit+1)
+		case op == code.OpGetLocal || op == code.OpSetLocal:
+			c.err = c.errorf("too many local variables, the limit is %d", limit+1)
+		default:
+			c.err = c.errorf("operand of %s out of range: %d, the limit is %d", def.Name, operand, limit)
+		}
+		return
+	}
+}
+
+// 指令执行后栈中值的数量的变化
+func stackEffect(op code.Opcode, operands []int) int {
+	switch op {
+	case code.OpConstant, code.OpTrue, code.OpFalse, code.OpNull,
+		code.OpGetGlobal, code.OpGetLocal, code.OpGetFree, code.OpGetBuiltin,
+		code.OpClosure, code.OpIterNext:
+		return 1
+	case code.OpDup2:
+		return 2
+	case code.OpPop, code.OpJumpNotTruthy, code.OpSetGlobal, code.OpAssignGlobal,
+		code.OpSetLocal, code.OpSetFree, code.OpReturnValue, code.OpIndex,
+		code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpMod, code.OpPow,
+		code.OpEqual, code.OpNotEqual, code.OpLessThan, code.OpLessEqual,
+		code.OpGreaterThan, code.OpGreaterEqual:
+		return -1
+	case code.OpSetIndex:
+		return -2
+	case code.OpArray, code.OpTuple, code.OpInterpolate:
+		return 1 - operands[0]
+	case code.OpDestructure:
+		return operands[0] - 1
+	case code.OpHash:
+		return 1 - 2*operands[0]
+	case code.OpCall:
+		return -operands[0]
+	}
+	return 0
+}
+
+// 添加指令，同时记录指令对应的源码位置
+func (c *Compiler) addInstruction(ins []byte) int {
+	scope := &c.scopes[c.scopeIndex]
+	posNewInstruction := len(scope.instructions)
+	scope.instructions = append(scope.instructions, ins...)
+
+	if n := len(scope.positions); n == 0 || scope.positions[n-1].Pos != c.pos {
+		scope.positions = append(scope.positions, object.SourcePosition{Offset: posNewInstruction, Pos: c.pos})
+	}
+	return posNewInstruction
+}
+
+// 记录最后两条生成的指令
+func (c *Compiler) setLastInstruction(op code.Opcode, pos int) {
+	previous := c.scopes[c.scopeIndex].lastInstruction
+	last := EmittedInstruction{Opcode: op, Position: pos}
+
+	c.scopes[c.scopeIndex].previousInstruction = previous
+	c.scopes[c.scopeIndex].lastInstruction = last
+}
+
+// 判断最后一条指令是否是 op
+func (c *Compiler) lastInstructionIs(op code.Opcode) bool {
+	if len(c.currentInstructions()) == 0 {
+		return false
+	}
+	return c.scopes[c.scopeIndex].lastInstruction.Opcode == op
+}
+
+// 删除最后一条 OpPop 指令，表达式的值留在栈中
+func (c *Compiler) removeLastPop() {
+	scope := &c.scopes[c.scopeIndex]
+	last := scope.lastInstruction
+
+	scope.instructions = scope.instructions[:last.Position]
+	for n := len(scope.positions); n > 0 && scope.positions[n-1].Offset >= last.Position; n-- {
+		scope.positions = scope.positions[:n-1]
+	}
+	scope.lastInstruction = scope.previousInstruction
+	scope.depth++
+}
+
+// 把最后一条 OpPop 指令替换为 op
+func (c *Compiler) replaceLastPop(op code.Opcode) {
+	c.removeLastPop()
+	c.emit(op)
+}
+
+// 修改指令的操作数
+func (c *Compiler) changeOperand(opPos int, operands ...int) {
+	op := code.Opcode(c.currentInstructions()[opPos])
+	c.checkOperands(op, operands)
+	newInstruction := code.Make(op, operands...)
+	copy(c.scopes[c.scopeIndex].instructions[opPos:], newInstruction)
+}
+
+// 返回当前函数的指令
+func (c *Compiler) currentInstructions() code.Instructions {
+	return c.scopes[c.scopeIndex].instructions
+}
+
+// 开始编译函数
+func (c *Compiler) enterScope() {
+	c.scopes = append(c.scopes, CompilationScope{})
+	c.scopeIndex++
+	c.symbolTable = NewEnclosedSymbolTable(c.symbolTable)
+}
+
+// 结束编译函数，返回函数的指令
+func (c *Compiler) leaveScope() code.Instructions {
+	instructions := c.currentInstructions()
+
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.scopeIndex--
+	c.symbolTable = c.symbolTable.Outer
+
+	return instructions
+}
+
+// 创建编译错误，位置是正在编译的节点的位置
+func (c *Compiler) errorf(format string, a ...interface{}) *Error {
+	return &Error{Pos: c.pos, Message: fmt.Sprintf(format, a...)}
+}
@@ -0,0 +1,509 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"holiya/ast"
+	"holiya/code"
+	"holiya/lexer"
+	"holiya/object"
+	"holiya/parser"
+)
+
+// 编译测试用例
+type compilerTestCase struct {
+	input                string
+	expectedConstants    []interface{}
+	expectedInstructions []code.Instructions
+}
+
+// 测试算术运算和比较运算的编译
+func TestInfixExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "1 + 2",
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAdd),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			input:             "1 ** 2; -1",
+			expectedConstants: []interface{}{1, 2, 1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpPow),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpMinus),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			input:             "1 <= 2.5",
+			expectedConstants: []interface{}{1, 2.5},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpLessEqual),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			input:             "true && false",
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTrue),
+				code.Make(code.OpJumpNotTruthy, 10),
+				code.Make(code.OpFalse),
+				code.Make(code.OpBang),
+				code.Make(code.OpBang),
+				code.Make(code.OpJump, 11),
+				code.Make(code.OpFalse),
+				code.Make(code.OpReturnValue),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+// 测试 if 表达式和 let 语句的编译
+func TestConditionalsAndGlobals(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "if (true) { 10 }; 3333;",
+			expectedConstants: []interface{}{10, 3333},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTrue),
+				code.Make(code.OpJumpNotTruthy, 10),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpJump, 11),
+				code.Make(code.OpNull),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			input:             "let one = 1; one = 2;",
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAssignGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			input:             "let a = [1]; a[0] += 2;",
+			expectedConstants: []interface{}{1, 0, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpDup2),
+				code.Make(code.OpIndex),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpAdd),
+				code.Make(code.OpSetIndex),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			input:             "let x = 1;",
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpReturn),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+// 测试插值字符串的编译，空的字符串片段不会生成指令
+func TestInterpolatedString(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             `"a${1}"`,
+			expectedConstants: []interface{}{"a", 1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpInterpolate, 2),
+				code.Make(code.OpReturnValue),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+// 测试元组字面量和解构的 let 语句的编译，解构时从最后一个变量开始赋值
+func TestTuples(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "#(1, 2)",
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpTuple, 2),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			input:             "let #(x, y) = #(1, 2);",
+			expectedConstants: []interface{}{1, 2},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpTuple, 2),
+				code.Make(code.OpDestructure, 2),
+				code.Make(code.OpSetGlobal, 1),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpReturn),
+			},
+		},
+		{
+			input: "fn(p) { let #(a, a) = p; a }",
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpDestructure, 2),
+					code.Make(code.OpSetLocal, 1),
+					code.Make(code.OpPop),
+					code.Make(code.OpGetLocal, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0),
+				code.Make(code.OpReturnValue),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+// 测试哈希表字面量按源码中的顺序编译
+func TestHashLiteral(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "{2: 3, 1: 4}",
+			expectedConstants: []interface{}{2, 3, 1, 4},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpConstant, 3),
+				code.Make(code.OpHash, 2),
+				code.Make(code.OpReturnValue),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+// 测试函数和闭包的编译
+func TestFunctions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input: "fn(a, b = 2) { a + b }",
+			expectedConstants: []interface{}{
+				2,
+				[]code.Instructions{
+					code.Make(code.OpJumpIfBound, 1, 9),
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpSetLocal, 1),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpGetLocal, 1),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 1),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			input: "fn(a) { fn(b) { a + b } }",
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetFree, 0),
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+				[]code.Instructions{
+					code.Make(code.OpClosure, 0),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 1),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			input: "fn() { len([]) }",
+			expectedConstants: []interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetBuiltin, 0),
+					code.Make(code.OpArray, 0),
+					code.Make(code.OpCall, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpClosure, 0),
+				code.Make(code.OpReturnValue),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+// 测试循环的编译，break 跳出循环前先弹出表达式留在栈中的值
+func TestLoops(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			input:             "while (true) { break; }",
+			expectedConstants: []interface{}{},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpTrue),
+				code.Make(code.OpJumpNotTruthy, 10),
+				code.Make(code.OpJump, 10),
+				code.Make(code.OpJump, 0),
+				code.Make(code.OpReturn),
+			},
+		},
+		{
+			input:             "for (x in []) { 1 + if (true) { continue; }; }",
+			expectedConstants: []interface{}{1},
+			expectedInstructions: []code.Instructions{
+				code.Make(code.OpArray, 0),
+				code.Make(code.OpIterStart),
+				code.Make(code.OpSetLocal, 0),
+				code.Make(code.OpIterNext, 0, 35),
+				code.Make(code.OpSetLocal, 1),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpTrue),
+				code.Make(code.OpJumpNotTruthy, 27),
+				code.Make(code.OpPop),
+				code.Make(code.OpJump, 30),
+				code.Make(code.OpNull),
+				code.Make(code.OpJump, 28),
+				code.Make(code.OpNull),
+				code.Make(code.OpAdd),
+				code.Make(code.OpPop),
+				code.Make(code.OpCloseUpvalues, 1),
+				code.Make(code.OpJump, 6),
+				code.Make(code.OpCloseUpvalues, 1),
+				code.Make(code.OpReturn),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+// 测试编译错误
+func TestCompilerErrors(t *testing.T) {
+	tests := []struct {
+		node     ast.Node
+		expected string
+	}{
+		{
+			&ast.Program{Statements: []ast.Statement{&ast.BreakStatement{}}},
+			"break statement outside loop",
+		},
+		{
+			parse("len = 1"),
+			"1:1: cannot assign to undeclared variable: len",
+		},
+		{
+			parse("fn() { len = 1 }"),
+			"1:8: cannot assign to undeclared variable: len",
+		},
+		{
+			parse("fn(a = len = 1) { a }"),
+			"1:8: cannot assign to undeclared variable: len",
+		},
+	}
+
+	for _, tt := range tests {
+		c := New()
+		err := c.Compile(tt.node)
+		if err == nil {
+			t.Errorf("expected error %q, got none", tt.expected)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("wrong error. got=%q, want=%q", err.Error(), tt.expected)
+		}
+		// 出错后回到最外层的作用域
+		if c.scopeIndex != 0 || len(c.scopes) != 1 || c.symbolTable.Outer != nil {
+			t.Errorf("compiler left in scope %d after error %q", c.scopeIndex, tt.expected)
+		}
+	}
+}
+
+// 测试常量和变量的下标超出操作数的宽度时报告错误，而不是截断后使用错误的常量或者变量
+func TestOperandLimits(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{repeatLines("%d", 65537), "65537:1: too many constants, the limit is 65536"},
+		{repeatLines("let g%d = true", 65537), "65537:1: too many global variables, the limit is 65536"},
+		{"fn() {\n" + repeatLines("let l%d = true", 257) + "\n}", "258:1: too many local variables, the limit is 256"},
+	}
+
+	for _, tt := range tests {
+		err := New().Compile(parse(tt.input))
+		if err == nil {
+			t.Errorf("expected error %q, got none", tt.expected)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("wrong error. got=%q, want=%q", err.Error(), tt.expected)
+		}
+	}
+
+	// 刚好达到上限时可以编译
+	if err := New().Compile(parse(repeatLines("let g%d = true", 65536))); err != nil {
+		t.Errorf("65536 global variables returned error: %v", err)
+	}
+}
+
+// 生成 n 行，每行是用行的下标格式化的 format
+func repeatLines(format string, n int) string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf(format, i)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// 测试生成的指令记录了源码位置
+func TestSourcePositions(t *testing.T) {
+	compiler := New()
+	if err := compiler.Compile(parse("1;\n  x + 2")); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+	main := compiler.Bytecode().Main
+
+	tests := []struct {
+		offset         int
+		expectedLine   int
+		expectedColumn int
+	}{
+		{0, 1, 1},  // 1
+		{4, 2, 3},  // x
+		{7, 2, 7},  // 2
+		{10, 2, 3}, // x + 2
+	}
+	for _, tt := range tests {
+		pos := main.PositionAt(tt.offset)
+		if pos.Line != tt.expectedLine || pos.Column != tt.expectedColumn {
+			t.Errorf("PositionAt(%d) = %s, want %d:%d", tt.offset, pos, tt.expectedLine, tt.expectedColumn)
+		}
+	}
+}
+
+// 执行编译测试用例
+func runCompilerTests(t *testing.T, tests []compilerTestCase) {
+	t.Helper()
+
+	for _, tt := range tests {
+		compiler := New()
+		if err := compiler.Compile(parse(tt.input)); err != nil {
+			t.Fatalf("compiler error for %q: %s", tt.input, err)
+		}
+
+		bytecode := compiler.Bytecode()
+		if err := testInstructions(tt.expectedInstructions, bytecode.Main.Instructions); err != nil {
+			t.Fatalf("testInstructions failed for %q: %s", tt.input, err)
+		}
+		if err := testConstants(tt.expectedConstants, bytecode.Constants); err != nil {
+			t.Fatalf("testConstants failed for %q: %s", tt.input, err)
+		}
+	}
+}
+
+// 解析程序
+func parse(input string) *ast.Program {
+	return parser.New(lexer.New(input)).ParseProgram()
+}
+
+// 把多条指令连接起来
+func concatInstructions(s []code.Instructions) code.Instructions {
+	out := code.Instructions{}
+	for _, ins := range s {
+		out = append(out, ins...)
+	}
+	return out
+}
+
+// 比较生成的指令
+func testInstructions(expected []code.Instructions, actual code.Instructions) error {
+	concatted := concatInstructions(expected)
+	if actual.String() != concatted.String() {
+		return fmt.Errorf("wrong instructions.\nwant=\n%s\ngot=\n%s", concatted, actual)
+	}
+	return nil
+}
+
+// 比较常量池
+func testConstants(expected []interface{}, actual []object.Object) error {
+	if len(expected) != len(actual) {
+		return fmt.Errorf("wrong number of constants. got=%d, want=%d", len(actual), len(expected))
+	}
+
+	for i, constant := range expected {
+		switch constant := constant.(type) {
+		case int:
+			integer, ok := actual[i].(*object.Integer)
+			if !ok || integer.Value != int64(constant) {
+				return fmt.Errorf("constant %d - wrong integer. got=%s, want=%d", i, actual[i].Inspect(), constant)
+			}
+		case string:
+			str, ok := actual[i].(*object.String)
+			if !ok || str.Value != constant {
+				return fmt.Errorf("constant %d - wrong string. got=%s, want=%q", i, actual[i].Inspect(), constant)
+			}
+		case float64:
+			float, ok := actual[i].(*object.Float)
+			if !ok || float.Value != constant {
+				return fmt.Errorf("constant %d - wrong float. got=%s, want=%f", i, actual[i].Inspect(), constant)
+			}
+		case []code.Instructions:
+			fn, ok := actual[i].(*object.CompiledFunction)
+			if !ok {
+				return fmt.Errorf("constant %d - not a function: %T", i, actual[i])
+			}
+			if err := testInstructions(constant, fn.Instructions); err != nil {
+				return fmt.Errorf("constant %d - %s", i, err)
+			}
+		}
+	}
+	return nil
+}
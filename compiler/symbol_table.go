@@ -0,0 +1,160 @@
+package compiler
+
+import "holiya/object"
+
+// SymbolScope 符号的作用域
+type SymbolScope string
+
+const (
+	// 全局变量
+	GlobalScope SymbolScope = "GLOBAL"
+	// 局部变量
+	LocalScope SymbolScope = "LOCAL"
+	// 内置函数
+	BuiltinScope SymbolScope = "BUILTIN"
+	// 闭包捕获的变量
+	FreeScope SymbolScope = "FREE"
+)
+
+// Symbol 符号，记录变量的名字、作用域和下标
+type Symbol struct {
+	Name  string
+	Scope SymbolScope
+	Index int
+}
+
+// SymbolTable 符号表
+// 符号表分为函数作用域和块作用域两种：顶层代码和每个函数都有自己的函数作用域，
+// for 语句使用块作用域，块作用域中定义的变量是所在函数的局部变量，但是只在块中可见
+// 顶层代码的块作用域中定义的变量也是局部变量，保存在顶层代码的栈帧中
+type SymbolTable struct {
+	Outer *SymbolTable
+
+	// 在这个作用域中定义的符号
+	store map[string]Symbol
+	// 所在的函数作用域，函数作用域指向自己
+	function *SymbolTable
+	// 是否是块作用域
+	block bool
+
+	// 以下字段只在函数作用域中使用
+	// 函数的局部变量的名字，下标就是局部变量的下标，局部变量的下标不会重复使用
+	localNames []string
+	// 闭包捕获的外层变量
+	FreeSymbols []Symbol
+
+	// 全局变量的名字，只在最外层的符号表中使用
+	globalNames []string
+}
+
+// NewSymbolTable 创建最外层的符号表，内置函数都定义在这个符号表中
+func NewSymbolTable() *SymbolTable {
+	s := &SymbolTable{store: make(map[string]Symbol)}
+	s.function = s
+	for i, def := range object.Builtins {
+		s.DefineBuiltin(i, def.Name)
+	}
+	return s
+}
+
+// NewEnclosedSymbolTable 创建函数作用域的符号表
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := &SymbolTable{Outer: outer, store: make(map[string]Symbol)}
+	s.function = s
+	return s
+}
+
+// NewBlockSymbolTable 创建块作用域的符号表
+func NewBlockSymbolTable(outer *SymbolTable) *SymbolTable {
+	return &SymbolTable{
+		Outer:    outer,
+		store:    make(map[string]Symbol),
+		function: outer.function,
+		block:    true,
+	}
+}
+
+// Define 在当前作用域中定义变量
+// 变量已经在当前作用域中定义过时，返回原来的符号，所以再次 let 同一个变量会覆盖原来的值
+func (s *SymbolTable) Define(name string) Symbol {
+	if symbol, ok := s.store[name]; ok && (symbol.Scope == GlobalScope || symbol.Scope == LocalScope) {
+		return symbol
+	}
+
+	var symbol Symbol
+	if s.Outer == nil {
+		symbol = Symbol{Name: name, Scope: GlobalScope, Index: len(s.globalNames)}
+		s.globalNames = append(s.globalNames, name)
+	} else {
+		symbol = Symbol{Name: name, Scope: LocalScope, Index: s.function.defineLocal(name)}
+	}
+	s.store[name] = symbol
+	return symbol
+}
+
+// DefineBuiltin 定义内置函数，index 是内置函数在 object.Builtins 中的下标
+func (s *SymbolTable) DefineBuiltin(index int, name string) Symbol {
+	symbol := Symbol{Name: name, Scope: BuiltinScope, Index: index}
+	s.store[name] = symbol
+	return symbol
+}
+
+// DefineTemporary 在所在的函数中分配一个没有名字的局部变量，用于保存编译器生成的临时值
+func (s *SymbolTable) DefineTemporary() Symbol {
+	return Symbol{Scope: LocalScope, Index: s.function.defineLocal("")}
+}
+
+// Resolve 查找变量，先查找当前作用域，再依次查找外层的作用域
+// 在外层函数中找到的局部变量会成为当前函数捕获的变量
+func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
+	symbol, ok := s.store[name]
+	if ok {
+		return symbol, true
+	}
+	if s.Outer == nil {
+		return symbol, false
+	}
+
+	symbol, ok = s.Outer.Resolve(name)
+	if !ok || s.block || symbol.Scope == GlobalScope || symbol.Scope == BuiltinScope {
+		return symbol, ok
+	}
+	return s.defineFree(symbol), true
+}
+
+// Global 返回最外层的符号表
+func (s *SymbolTable) Global() *SymbolTable {
+	for s.Outer != nil {
+		s = s.Outer
+	}
+	return s
+}
+
+// NumLocals 返回所在函数的局部变量的数量
+func (s *SymbolTable) NumLocals() int {
+	return len(s.function.localNames)
+}
+
+// LocalNames 返回所在函数的局部变量的名字
+func (s *SymbolTable) LocalNames() []string {
+	return s.function.localNames
+}
+
+// GlobalNames 返回全局变量的名字，下标就是全局变量的下标
+func (s *SymbolTable) GlobalNames() []string {
+	return s.Global().globalNames
+}
+
+// 在函数作用域中分配一个局部变量，返回局部变量的下标
+func (s *SymbolTable) defineLocal(name string) int {
+	s.localNames = append(s.localNames, name)
+	return len(s.localNames) - 1
+}
+
+// 把外层函数的变量定义为当前函数捕获的变量
+func (s *SymbolTable) defineFree(original Symbol) Symbol {
+	s.FreeSymbols = append(s.FreeSymbols, original)
+	symbol := Symbol{Name: original.Name, Scope: FreeScope, Index: len(s.FreeSymbols) - 1}
+	s.store[original.Name] = symbol
+	return symbol
+}
@@ -0,0 +1,94 @@
+package compiler
+
+import "testing"
+
+// 测试全局变量和局部变量的定义
+func TestDefine(t *testing.T) {
+	global := NewSymbolTable()
+	if a := global.Define("a"); a != (Symbol{Name: "a", Scope: GlobalScope, Index: 0}) {
+		t.Errorf("a = %+v", a)
+	}
+	if b := global.Define("b"); b != (Symbol{Name: "b", Scope: GlobalScope, Index: 1}) {
+		t.Errorf("b = %+v", b)
+	}
+	// 再次定义同一个变量时使用原来的下标
+	if a := global.Define("a"); a.Index != 0 {
+		t.Errorf("redefined a = %+v, want index 0", a)
+	}
+
+	local := NewEnclosedSymbolTable(global)
+	if c := local.Define("c"); c != (Symbol{Name: "c", Scope: LocalScope, Index: 0}) {
+		t.Errorf("c = %+v", c)
+	}
+
+	// 块作用域的变量是所在函数的局部变量
+	block := NewBlockSymbolTable(local)
+	if d := block.Define("d"); d != (Symbol{Name: "d", Scope: LocalScope, Index: 1}) {
+		t.Errorf("d = %+v", d)
+	}
+	if local.NumLocals() != 2 {
+		t.Errorf("local.NumLocals() = %d, want 2", local.NumLocals())
+	}
+	if _, ok := local.Resolve("d"); ok {
+		t.Errorf("d is visible outside its block")
+	}
+
+	// 顶层代码的块作用域的变量也是局部变量
+	topBlock := NewBlockSymbolTable(global)
+	if e := topBlock.Define("e"); e != (Symbol{Name: "e", Scope: LocalScope, Index: 0}) {
+		t.Errorf("e = %+v", e)
+	}
+}
+
+// 测试查找变量和闭包捕获的变量
+func TestResolve(t *testing.T) {
+	global := NewSymbolTable()
+	global.Define("a")
+
+	first := NewEnclosedSymbolTable(global)
+	first.Define("b")
+	block := NewBlockSymbolTable(first)
+	block.Define("c")
+
+	second := NewEnclosedSymbolTable(block)
+	second.Define("d")
+
+	tests := []struct {
+		name     string
+		expected Symbol
+	}{
+		{"a", Symbol{Name: "a", Scope: GlobalScope, Index: 0}},
+		{"len", Symbol{Name: "len", Scope: BuiltinScope, Index: 0}},
+		{"d", Symbol{Name: "d", Scope: LocalScope, Index: 0}},
+		{"c", Symbol{Name: "c", Scope: FreeScope, Index: 0}},
+		{"b", Symbol{Name: "b", Scope: FreeScope, Index: 1}},
+		{"c", Symbol{Name: "c", Scope: FreeScope, Index: 0}},
+	}
+	for _, tt := range tests {
+		symbol, ok := second.Resolve(tt.name)
+		if !ok {
+			t.Errorf("name %s not resolvable", tt.name)
+			continue
+		}
+		if symbol != tt.expected {
+			t.Errorf("%s resolved to %+v, want %+v", tt.name, symbol, tt.expected)
+		}
+	}
+
+	expectedFree := []Symbol{
+		{Name: "c", Scope: LocalScope, Index: 1},
+		{Name: "b", Scope: LocalScope, Index: 0},
+	}
+	if len(second.FreeSymbols) != len(expectedFree) {
+		t.Fatalf("wrong number of free symbols. got=%d, want=%d", len(second.FreeSymbols), len(expectedFree))
+	}
+	for i, symbol := range expectedFree {
+		if second.FreeSymbols[i] != symbol {
+			t.Errorf("free symbol %d = %+v, want %+v", i, second.FreeSymbols[i], symbol)
+		}
+	}
+
+	if _, ok := second.Resolve("x"); ok {
+		t.Errorf("undefined name x resolved")
+	}
+}
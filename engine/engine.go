@@ -0,0 +1,157 @@
+package engine
+
+import (
+	"fmt"
+
+	"holiya/ast"
+	"holiya/compiler"
+	"holiya/evaluator"
+	"holiya/object"
+	"holiya/vm"
+)
+
+const (
+	// 树遍历求值器
+	EVAL = "eval"
+	// 字节码虚拟机
+	VM = "vm"
+)
+
+// Engine 执行程序的引擎
+type Engine interface {
+	// Run 执行程序，返回最后一条表达式语句的值，没有值时返回 nil，出错时返回 *object.Error
+	// 同一个引擎多次执行程序时，前面定义的变量在后面仍然可以使用
+	Run(program *ast.Program) object.Object
+	// Get 返回全局变量的值，没有这个全局变量时返回同名的内置函数
+	Get(name string) (object.Object, bool)
+	// Set 定义全局变量，已经存在时修改它的值，全局变量的数量达到上限时返回错误
+	Set(name string, value object.Object) error
+	// Call 用参数 args 调用函数 fn，返回函数的返回值，出错时返回 *object.Error
+	Call(fn object.Object, args []object.Object) object.Object
+	// SetContext 设置内置函数执行时的上下文，默认使用进程的标准输出、标准错误和标准输入
+	SetContext(ctx *object.Context)
+}
+
+// New 根据名字创建引擎，名字是 EVAL 或 VM
+func New(name string) (Engine, error) {
+	switch name {
+	case EVAL:
+		return &evalEngine{env: object.NewEnvironment()}, nil
+	case VM:
+		return &vmEngine{
+			symbolTable: compiler.NewSymbolTable(),
+			constants:   []object.Object{},
+			globals:     make([]object.Object, vm.GlobalsSize),
+			ctx:         object.DefaultContext(),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown engine: %s", name)
+	}
+}
+
+// evalEngine 使用求值器直接执行 AST
+type evalEngine struct {
+	env *object.Environment
+}
+
+// Run 执行程序
+func (e *evalEngine) Run(program *ast.Program) object.Object {
+	return evaluator.Eval(program, e.env)
+}
+
+// Get 返回全局变量的值
+func (e *evalEngine) Get(name string) (object.Object, bool) {
+	if value, ok := e.env.Get(name); ok {
+		return value, true
+	}
+	if builtin := object.GetBuiltinByName(name); builtin != nil {
+		return builtin, true
+	}
+	return nil, false
+}
+
+// Set 定义全局变量
+func (e *evalEngine) Set(name string, value object.Object) error {
+	e.env.Set(name, value)
+	return nil
+}
+
+// Call 调用函数
+func (e *evalEngine) Call(fn object.Object, args []object.Object) object.Object {
+	return evaluator.Apply(fn, args, e.env.Context())
+}
+
+// SetContext 设置内置函数执行时的上下文
+func (e *evalEngine) SetContext(ctx *object.Context) {
+	e.env.SetContext(ctx)
+}
+
+// vmEngine 先把 AST 编译成字节码，再用虚拟机执行
+// 符号表、常量池和全局变量在多次执行之间保留
+type vmEngine struct {
+	symbolTable *compiler.SymbolTable
+	constants   []object.Object
+	globals     []object.Object
+	ctx         *object.Context
+}
+
+// Run 执行程序，编译出错时返回的错误也是 *object.Error
+func (e *vmEngine) Run(program *ast.Program) object.Object {
+	c := compiler.NewWithState(e.symbolTable, e.constants)
+	if err := c.Compile(program); err != nil {
+		compileErr := err.(*compiler.Error)
+		return &object.Error{Message: compileErr.Message, Pos: compileErr.Pos}
+	}
+
+	bytecode := c.Bytecode()
+	e.constants = bytecode.Constants
+	machine := vm.NewWithGlobalsStore(bytecode, e.globals)
+	machine.SetContext(e.ctx)
+	return machine.Run()
+}
+
+// Get 返回全局变量的值，变量已经声明但还没有赋值时也当作不存在
+func (e *vmEngine) Get(name string) (object.Object, bool) {
+	symbol, ok := e.symbolTable.Resolve(name)
+	if !ok {
+		return nil, false
+	}
+	switch symbol.Scope {
+	case compiler.GlobalScope:
+		value := e.globals[symbol.Index]
+		return value, value != nil
+	case compiler.BuiltinScope:
+		return object.Builtins[symbol.Index].Builtin, true
+	}
+	return nil, false
+}
+
+// Set 定义全局变量，全局变量的数量不能超过 vm.GlobalsSize
+func (e *vmEngine) Set(name string, value object.Object) error {
+	symbol, ok := e.symbolTable.Resolve(name)
+	if !ok || symbol.Scope != compiler.GlobalScope {
+		if len(e.symbolTable.GlobalNames()) >= vm.GlobalsSize {
+			return fmt.Errorf("cannot define %s: too many global variables, the limit is %d", name, vm.GlobalsSize)
+		}
+		symbol = e.symbolTable.Define(name)
+	}
+	e.globals[symbol.Index] = value
+	return nil
+}
+
+// Call 调用函数，函数可以使用之前定义的全局变量
+func (e *vmEngine) Call(fn object.Object, args []object.Object) object.Object {
+	bytecode := &compiler.Bytecode{
+		Main:        &object.CompiledFunction{},
+		Constants:   e.constants,
+		GlobalNames: e.symbolTable.GlobalNames(),
+	}
+	machine := vm.NewWithGlobalsStore(bytecode, e.globals)
+	machine.SetContext(e.ctx)
+	return machine.Call(fn, args...)
+}
+
+// SetContext 设置内置函数执行时的上下文
+func (e *vmEngine) SetContext(ctx *object.Context) {
+	e.ctx = ctx
+}
@@ -0,0 +1,224 @@
+package engine
+
+import (
+	"bytes"
+	goast "go/ast"
+	goparser "go/parser"
+	gotoken "go/token"
+	"strconv"
+	"strings"
+	"testing"
+
+	"holiya/lexer"
+	"holiya/object"
+	"holiya/parser"
+	"holiya/vm"
+)
+
+// 差异测试：求值器测试中所有能解析的程序，用两个引擎执行的结果必须相同
+// 程序直接从求值器的测试文件中提取，所以求值器增加的测试也会自动用于比较两个引擎
+func TestEnginesAgree(t *testing.T) {
+	inputs := evaluatorTestInputs(t)
+	if len(inputs) < 100 {
+		t.Fatalf("found only %d programs in evaluator tests", len(inputs))
+	}
+
+	for _, input := range inputs {
+		p := parser.New(lexer.New(input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 || len(program.Statements) == 0 {
+			continue
+		}
+
+		evalEngine, _ := New(EVAL)
+		expected := describe(evalEngine.Run(program))
+		vmEngine, _ := New(VM)
+		got := describe(vmEngine.Run(program))
+		if got != expected {
+			t.Errorf("engines disagree on %q:\n\teval: %s\n\tvm:   %s", input, expected, got)
+		}
+	}
+}
+
+// 测试同一个引擎多次执行程序时，前面定义的变量仍然可以使用
+func TestEngineKeepsState(t *testing.T) {
+	for _, name := range []string{EVAL, VM} {
+		e, err := New(name)
+		if err != nil {
+			t.Fatalf("New(%q) returned error: %v", name, err)
+		}
+		inputs := []string{
+			"let x = 5;",
+			"let add = fn(a, b = x) { a + b };",
+			"for (i in [1, 2]) { x += i; }",
+			"add(x)",
+		}
+		var result object.Object
+		for _, input := range inputs {
+			result = e.Run(parser.New(lexer.New(input)).ParseProgram())
+		}
+		if describe(result) != "16" {
+			t.Errorf("engine %s: result = %s, want 16", name, describe(result))
+		}
+	}
+}
+
+// 测试全局变量的数量达到上限时，Set 返回错误而不是越界
+func TestEngineSetTooManyGlobals(t *testing.T) {
+	for _, name := range []string{EVAL, VM} {
+		e, _ := New(name)
+		for i := 0; i < vm.GlobalsSize; i++ {
+			if err := e.Set("g"+strconv.Itoa(i), &object.Integer{Value: int64(i)}); err != nil {
+				t.Fatalf("engine %s: Set(g%d) returned error: %v", name, i, err)
+			}
+		}
+
+		err := e.Set("extra", object.NULL)
+		if name == VM {
+			expected := "cannot define extra: too many global variables, the limit is 65536"
+			if err == nil || err.Error() != expected {
+				t.Errorf("engine %s: Set(extra) error = %v, want %s", name, err, expected)
+			}
+			if _, ok := e.Get("extra"); ok {
+				t.Errorf("engine %s: extra is defined after Set failed", name)
+			}
+		} else if err != nil {
+			t.Errorf("engine %s: Set(extra) returned error: %v", name, err)
+		}
+
+		// 已经定义的全局变量仍然可以修改
+		if err := e.Set("g0", &object.Integer{Value: -1}); err != nil {
+			t.Errorf("engine %s: Set(g0) returned error: %v", name, err)
+		}
+		if value, ok := e.Get("g0"); !ok || describe(value) != "-1" {
+			t.Errorf("engine %s: Get(g0) = %v, %t, want -1", name, value, ok)
+		}
+	}
+}
+
+// 测试输出函数的返回值可以继续使用，两个引擎都返回 null 而不是没有值
+func TestOutputBuiltinsReturnNull(t *testing.T) {
+	tests := []struct {
+		input          string
+		expected       string
+		expectedStdout string
+	}{
+		{`puts(print("x"))`, "null", "xnull\n"},
+		{`let x = print("a"); x`, "null", "a"},
+		{`let x = print("a"); len(x)`, "ERROR: argument to `len` not supported, got NULL\n", "a"},
+		{`"${println()}"`, "null", "\n"},
+		{`[eprint(), eprintln(), printf("%d", 1)]`, "[null, null, null]", "1"},
+	}
+
+	for _, name := range []string{EVAL, VM} {
+		for _, tt := range tests {
+			var stdout, stderr bytes.Buffer
+			e, _ := New(name)
+			e.SetContext(&object.Context{Stdout: &stdout, Stderr: &stderr})
+			result := describe(e.Run(parser.New(lexer.New(tt.input)).ParseProgram()))
+			if result != tt.expected {
+				t.Errorf("engine %s: %q = %s, want %s", name, tt.input, result, tt.expected)
+			}
+			if stdout.String() != tt.expectedStdout {
+				t.Errorf("engine %s: %q wrote %q to stdout, want %q", name, tt.input, stdout.String(), tt.expectedStdout)
+			}
+		}
+	}
+}
+
+// 测试接近和超过虚拟机栈的初始大小以及调用深度上限的程序，两个引擎的结果必须相同
+func TestEngineLimits(t *testing.T) {
+	elements := strings.TrimSuffix(strings.Repeat("0, ", 5000), ", ")
+	recursive := "let f = fn(n) { if (n == 0) { 0 } else { 1 + f(n - 1) } }; "
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"len([" + elements + "])", "5000"},
+		{recursive + "f(5000)", "5000"},
+		// f(n) 一共调用 n + 1 次
+		{recursive + "f(" + strconv.Itoa(object.MaxCallDepth-1) + ")", strconv.Itoa(object.MaxCallDepth - 1)},
+		{recursive + "f(" + strconv.Itoa(object.MaxCallDepth) + ")", overflowTrace("f", "1:46", "1:60")},
+		{"let loop = fn() { loop() }; loop()", overflowTrace("loop", "1:19", "1:29")},
+		// 栈扩大后，还没有关闭的捕获变量仍然引用函数的局部变量
+		{"let f = fn() { let x = 1; let inc = fn() { x += 1 }; let big = [" + elements + "]; inc(); x + len(big) }; f()", "5002"},
+	}
+
+	for _, tt := range tests {
+		for _, name := range []string{EVAL, VM} {
+			e, _ := New(name)
+			result := describe(e.Run(parser.New(lexer.New(tt.input)).ParseProgram()))
+			if result != tt.expected {
+				t.Errorf("engine %s: %.60q = %s, want %s", name, tt.input, result, tt.expected)
+			}
+		}
+	}
+}
+
+// 返回无限递归的函数 name 的 stack overflow 错误，递归调用的位置是 inner，最外层调用的位置是 outer
+func overflowTrace(name, inner, outer string) string {
+	return "ERROR: stack overflow\n" +
+		"traceback (most recent call first):\n" +
+		"  `" + name + "` called at " + inner + "\n" +
+		"  ... repeated " + strconv.Itoa(object.MaxCallDepth-2) + " more times\n" +
+		"  `" + name + "` called at " + outer + "\n"
+}
+
+// 测试未知的引擎名字
+func TestUnknownEngine(t *testing.T) {
+	if _, err := New("jit"); err == nil || err.Error() != "unknown engine: jit" {
+		t.Errorf("New(\"jit\") error = %v, want unknown engine: jit", err)
+	}
+}
+
+// 提取求值器测试文件中的所有字符串字面量
+func evaluatorTestInputs(t *testing.T) []string {
+	file, err := goparser.ParseFile(gotoken.NewFileSet(), "../evaluator/evaluator_test.go", nil, 0)
+	if err != nil {
+		t.Fatalf("cannot parse evaluator tests: %v", err)
+	}
+
+	seen := map[string]bool{}
+	inputs := []string{}
+	goast.Inspect(file, func(node goast.Node) bool {
+		lit, ok := node.(*goast.BasicLit)
+		if !ok || lit.Kind != gotoken.STRING {
+			return true
+		}
+		value, err := strconv.Unquote(lit.Value)
+		if err == nil && !seen[value] {
+			seen[value] = true
+			inputs = append(inputs, value)
+		}
+		return true
+	})
+	return inputs
+}
+
+// 返回结果的规范化表示，用于比较两个引擎的结果
+// 没有值和 null 相同；错误只比较错误信息和调用栈；函数只比较类型，因为编译后的函数没有源码；
+// 哈希表的键值对按插入的顺序比较
+func describe(obj object.Object) string {
+	switch obj := obj.(type) {
+	case nil, *object.Null:
+		return "null"
+	case *object.Error:
+		return "ERROR: " + obj.Message + "\n" + object.FormatTrace(obj.Trace)
+	case *object.Function, *object.Closure:
+		return "FUNCTION"
+	case *object.Array:
+		elements := []string{}
+		for _, element := range obj.Elements {
+			elements = append(elements, describe(element))
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *object.Hashmap:
+		pairs := []string{}
+		for _, pair := range obj.Pairs {
+			pairs = append(pairs, describe(pair.Key)+": "+describe(pair.Value))
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+	default:
+		return obj.Inspect()
+	}
+}
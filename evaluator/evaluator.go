@@ -5,19 +5,37 @@ import (
 	"holiya/ast"
 	"holiya/object"
 	"math"
+	"strings"
 )
 
 var (
 	// null 实例，null是唯一的，所以可以先初始化
-	NULL = &object.Null{}
+	NULL = object.NULL
 	// true 实例，true也是唯一的，也先初始化
-	TRUE = &object.Boolean{Value: true}
+	TRUE = object.TRUE
 	// false 实例，false也是唯一的，也先初始化
-	FALSE = &object.Boolean{Value: false}
+	FALSE = object.FALSE
+	// break 信号实例，break 信号不带任何值，所以也是唯一的
+	BREAK = &object.Break{}
+	// continue 信号实例，continue 信号不带任何值，所以也是唯一的
+	CONTINUE = &object.Continue{}
 )
 
 // 递归函数，用于对 AST 节点进行求值
+// 求值出错时，错误的位置是最内层出错的节点的位置，同时记下这时的调用栈
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	result := eval(node, env)
+	if err, ok := result.(*object.Error); ok && !err.Pos.IsValid() {
+		err.Pos = node.Pos()
+		if !err.Exit {
+			err.Trace = env.Context().Trace()
+		}
+	}
+	return result
+}
+
+// 根据节点的类型对节点进行求值
+func eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
 		// 处理整个程序节点
@@ -29,6 +47,19 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return value
 		}
 		env.Set(node.Name.Value, value)
+	case *ast.LetTupleStatement:
+		// 处理解构的 let 语句，将元组或数组的元素依次绑定到变量
+		value := Eval(node.Value, env)
+		if isError(value) {
+			return value
+		}
+		elements, err := object.Unpack(value, len(node.Names))
+		if err != nil {
+			return err
+		}
+		for i, name := range node.Names {
+			env.Set(name.Value, elements[i])
+		}
 	case *ast.BlockStatement:
 		// 处理代码块语句
 		return evalBlockStatement(node, env)
@@ -42,6 +73,21 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return value
 		}
 		return &object.ReturnValue{Value: value}
+	case *ast.WhileStatement:
+		// 处理 while 语句
+		return evalWhileStatement(node, env)
+	case *ast.ForStatement:
+		// 处理 C 风格的 for 语句
+		return evalForStatement(node, env)
+	case *ast.ForInStatement:
+		// 处理 for-in 语句
+		return evalForInStatement(node, env)
+	case *ast.BreakStatement:
+		// 处理 break 语句，返回 break 信号，由所在的循环处理
+		return BREAK
+	case *ast.ContinueStatement:
+		// 处理 continue 语句，返回 continue 信号，由所在的循环处理
+		return CONTINUE
 	case *ast.Identifier:
 		// 处理标识符
 		return evalIdentifier(node, env)
@@ -54,6 +100,17 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.StringLiteral:
 		// 处理字符串字面量
 		return &object.String{Value: node.Value}
+	case *ast.InterpolatedString:
+		// 处理插值字符串，每一部分的值用 Inspect() 转换成字符串后连接起来
+		parts := evalExpressions(node.Parts, env)
+		if len(parts) == 1 && isError(parts[0]) {
+			return parts[0]
+		}
+		var out strings.Builder
+		for _, part := range parts {
+			out.WriteString(part.Inspect())
+		}
+		return &object.String{Value: out.String()}
 	case *ast.PrefixExpression:
 		// 处理前缀表达式（如 -1, !true）
 		right := Eval(node.Right, env)
@@ -69,9 +126,14 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalIfExpression(node, env)
 	case *ast.FunctionLiteral:
 		// 处理函数字面量
-		params := node.Parameters
-		body := node.Body
-		return &object.Function{Parameters: params, Body: body, Env: env}
+		return &object.Function{
+			Parameters: node.Parameters,
+			Defaults:   node.Defaults,
+			Rest:       node.Rest,
+			Body:       node.Body,
+			Env:        env,
+			Name:       node.Name,
+		}
 	case *ast.ArrayLiteral:
 		// 处理数组字面量
 		elements := evalExpressions(node.Elements, env)
@@ -79,10 +141,21 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return elements[0]
 		}
 		return &object.Array{Elements: elements}
+	case *ast.TupleLiteral:
+		// 处理元组字面量
+		elements := evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
+		}
+		return &object.Tuple{Elements: elements}
 	case *ast.HashLiteral:
 		// 处理哈希表字面量
 		return evalHashLiteral(node, env)
 	case *ast.InfixExpression:
+		// 处理逻辑运算符，右边的表达式只在需要时才求值
+		if node.Operator == "&&" || node.Operator == "||" {
+			return evalLogicalExpression(node, env)
+		}
 		// 处理中缀表达式（如 1 + 2, a == b）
 		left := Eval(node.Left, env)
 		if isError(left) {
@@ -103,7 +176,11 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if len(args) == 1 && isError(args[0]) {
 			return args[0]
 		}
-		return applyFunction(function, args)
+		ctx := env.Context()
+		ctx.PushCall(object.Frame{Function: functionName(function), Pos: node.Pos()})
+		result := applyFunction(function, args, ctx)
+		ctx.PopCall()
+		return result
 	case *ast.IndexExpression:
 		// 处理索引表达式（如 array[0], hash["key"], string[1]）
 		left := Eval(node.Left, env)
@@ -115,6 +192,9 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return index
 		}
 		return evalIndexExpression(left, index)
+	case *ast.AssignExpression:
+		// 处理赋值表达式（如 x = 1, x += 1, array[0] = 1）
+		return evalAssignExpression(node, env)
 	}
 	// 对于未处理的节点类型，返回 nil
 	return nil
@@ -139,6 +219,9 @@ func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 		case *object.Error:
 			// 如果遇到错误，直接返回错误
 			return result
+		case *object.Break, *object.Continue:
+			// break 和 continue 不在循环中
+			return newError("%s statement outside loop", result.Inspect())
 		}
 	}
 
@@ -160,8 +243,9 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 		if result != nil {
 			// 获取结果类型
 			resultType := result.Type()
-			// 如果是返回值或错误，则立即返回，不执行后续语句
-			if resultType == object.RETURN_VALUE_OBJ || resultType == object.ERROR_OBJ {
+			// 如果是返回值、错误、break 或 continue 信号，则立即返回，不执行后续语句
+			if resultType == object.RETURN_VALUE_OBJ || resultType == object.ERROR_OBJ ||
+				resultType == object.BREAK_OBJ || resultType == object.CONTINUE_OBJ {
 				return result
 			}
 		}
@@ -171,6 +255,102 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 	return result
 }
 
+// 执行 while 语句，条件为真时重复执行循环体
+// 循环本身没有值，循环体中有 return 或出错时返回对应的结果
+func evalWhileStatement(node *ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		condition := Eval(node.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+		if !isTruthy(condition) {
+			return nil
+		}
+		if result, done := evalLoopBody(node.Body, env); done {
+			return result
+		}
+	}
+}
+
+// 执行 C 风格的 for 语句
+// 初始化语句在新的环境中执行，所以循环变量在循环结束后不可见
+func evalForStatement(node *ast.ForStatement, env *object.Environment) object.Object {
+	loopEnv := object.NewEnclosedEnvironment(env)
+	if node.Init != nil {
+		if init := Eval(node.Init, loopEnv); isError(init) {
+			return init
+		}
+	}
+	for {
+		if node.Condition != nil {
+			condition := Eval(node.Condition, loopEnv)
+			if isError(condition) {
+				return condition
+			}
+			if !isTruthy(condition) {
+				return nil
+			}
+		}
+		if result, done := evalLoopBody(node.Body, loopEnv); done {
+			return result
+		}
+		if node.Post != nil {
+			if post := Eval(node.Post, loopEnv); isError(post) {
+				return post
+			}
+		}
+	}
+}
+
+// 执行 for-in 语句，依次把数组或元组的元素、字符串的字符或哈希表的键赋给循环变量
+// 每次循环都在新的环境中绑定循环变量，循环体中创建的闭包捕获的是当次循环的值
+func evalForInStatement(node *ast.ForInStatement, env *object.Environment) object.Object {
+	iterable := Eval(node.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	var items []object.Object
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		// 复制一份元素，循环体中修改数组不影响遍历
+		items = append(items, iterable.Elements...)
+	case *object.Tuple:
+		items = iterable.Elements
+	case *object.String:
+		items = iterable.Chars()
+	case *object.Hashmap:
+		for _, pair := range iterable.Pairs {
+			items = append(items, pair.Key)
+		}
+	default:
+		return newError("cannot iterate over %s", iterable.Type())
+	}
+
+	for _, item := range items {
+		iterEnv := object.NewEnclosedEnvironment(env)
+		iterEnv.Set(node.Variable.Value, item)
+		if result, done := evalLoopBody(node.Body, iterEnv); done {
+			return result
+		}
+	}
+	return nil
+}
+
+// 执行一次循环体，第二个返回值表示循环是否要结束
+// 遇到 break 时结束循环，循环没有值；遇到 return 或出错时结束循环，并返回对应的结果
+// 遇到 continue 时和循环体正常执行完一样，继续下一次循环
+func evalLoopBody(body *ast.BlockStatement, env *object.Environment) (object.Object, bool) {
+	result := Eval(body, env)
+	switch result.(type) {
+	case *object.Break:
+		return nil, true
+	case *object.ReturnValue, *object.Error:
+		return result, true
+	}
+	return nil, false
+}
+
 // 用于计算标识符节点的值
 // 它首先在当前环境中查找标识符，如果找不到，则检查是否为内置函数
 // 如果都找不到，则返回一个错误
@@ -181,7 +361,7 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 	}
 
 	// 如果在环境中找不到，则检查是否为内置函数
-	if builtin, ok := builtins[node.Value]; ok {
+	if builtin := object.GetBuiltinByName(node.Value); builtin != nil {
 		return builtin
 	}
 
@@ -299,36 +479,59 @@ func evalExpressions(expressions []ast.Expression, env *object.Environment) []ob
 
 // 计算哈希表表达式的值
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
-	// 创建存储哈希键值对的映射
-	pairs := make(map[object.HashKey]object.HashPair)
+	// 创建哈希表，键值对按源码中的顺序插入
+	hash := &object.Hashmap{}
 
-	// 遍历所有键值对
-	for keyNode, valueNode := range node.Pairs {
+	// 按顺序遍历所有键值对，先计算键再计算值
+	for _, pairNode := range node.Pairs {
 		// 计算键的值
-		key := Eval(keyNode, env)
+		key := Eval(pairNode.Key, env)
 		if isError(key) {
 			return key
 		}
 
 		// 检查键是否可哈希
-		hashKey, ok := key.(object.Hashable)
+		hashKey, ok := object.AsHashable(key)
 		if !ok {
 			return newError("unusable as hash key: %s", key.Type())
 		}
 
 		// 计算值的值
-		value := Eval(valueNode, env)
+		value := Eval(pairNode.Value, env)
 		if isError(value) {
-			return newError("error in hash literal: %s", value)
+			return value
 		}
 
 		// 将键值对存储到哈希表中
-		hashIndex := hashKey.GetHashKey()
-		pairs[hashIndex] = object.HashPair{Key: key, Value: value}
+		hash.Set(hashKey, value)
 	}
 
 	// 返回构造好的哈希表对象
-	return &object.Hashmap{Pairs: pairs}
+	return hash
+}
+
+// 计算逻辑运算符 && 和 || 的值，结果是布尔值
+// 左边的值已经能决定结果时，不再对右边的表达式求值：
+// - a && b：a 为假时结果为 false
+// - a || b：a 为真时结果为 true
+// 真假的判断和 if 的条件相同，见 isTruthy
+func evalLogicalExpression(node *ast.InfixExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+	if node.Operator == "&&" && !isTruthy(left) {
+		return FALSE
+	}
+	if node.Operator == "||" && isTruthy(left) {
+		return TRUE
+	}
+
+	right := Eval(node.Right, env)
+	if isError(right) {
+		return right
+	}
+	return nativeBoolToBooleanObject(isTruthy(right))
 }
 
 // 计算中缀表达式的值（如 a + b, c == d 等）
@@ -349,21 +552,23 @@ func evalInfixExpression(operator string, left, right object.Object) object.Obje
 
 	// 当左操作数都是浮点数，右操作数是整数时，调用数字专用处理函数
 	case left.Type() == object.FLOAT_OBJ && right.Type() == object.INTEGER_OBJ:
-		return evalNumberInfixExpression(operator, right, left)
+		return evalNumberInfixExpression(operator, left, right)
 
 	// 当左右操作数都是字符串时，调用字符串专用处理函数
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
 
-	// 处理相等性比较操作符 "=="
+	// 处理相等性比较操作符 "=="，按值比较，数组和哈希表逐个比较元素
 	case operator == "==":
-		// 直接比较两个对象是否是同一个对象实例
-		return nativeBoolToBooleanObject(left == right)
+		return nativeBoolToBooleanObject(object.Equal(left, right))
 
 	// 处理不等性比较操作符 "!="
 	case operator == "!=":
-		// 直接比较两个对象是否不是同一个对象实例
-		return nativeBoolToBooleanObject(left != right)
+		return nativeBoolToBooleanObject(!object.Equal(left, right))
+
+	// 当左右操作数都是数组或者都是元组时，只能按字典序比较大小
+	case left.Type() == right.Type() && (left.Type() == object.ARRAY_OBJ || left.Type() == object.TUPLE_OBJ):
+		return evalComparisonExpression(operator, left, right)
 
 	// 当左右操作数类型不匹配时，返回类型不匹配错误
 	case left.Type() != right.Type():
@@ -399,8 +604,21 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 		}
 		return &object.Integer{Value: leftValue / rightValue}
 	case "%":
-		// 取模运算
+		// 取模运算，检查除零错误
+		if rightValue == 0 {
+			return newError("Division by zero")
+		}
 		return &object.Integer{Value: leftValue % rightValue}
+	case "**":
+		// 幂运算，指数是负数时结果是浮点数，结果超出整数的范围时报错
+		if rightValue < 0 {
+			return &object.Float{Value: math.Pow(float64(leftValue), float64(rightValue))}
+		}
+		result, ok := integerPow(leftValue, rightValue)
+		if !ok {
+			return newError("integer overflow: %d ** %d", leftValue, rightValue)
+		}
+		return &object.Integer{Value: result}
 	case ">":
 		// 大于比较运算
 		return nativeBoolToBooleanObject(leftValue > rightValue)
@@ -449,8 +667,14 @@ func evalFloatInfixExpression(operator string, left, right object.Object) object
 		}
 		return &object.Float{Value: leftValue / rightValue}
 	case "%":
-		// 取模运算（使用 math.Mod 处理浮点数取模）
+		// 取模运算（使用 math.Mod 处理浮点数取模），检查除零错误
+		if rightValue == 0 {
+			return newError("Division by zero")
+		}
 		return &object.Float{Value: math.Mod(leftValue, rightValue)}
+	case "**":
+		// 幂运算
+		return &object.Float{Value: math.Pow(leftValue, rightValue)}
 	case ">":
 		// 大于比较运算
 		return nativeBoolToBooleanObject(leftValue > rightValue)
@@ -476,10 +700,56 @@ func evalFloatInfixExpression(operator string, left, right object.Object) object
 }
 
 // 处理整数对象和浮点数对象之间的中缀表达式
+// 比较运算用 object.Equal 和 object.Compare 按准确的数值比较，和数组元素、contains 的比较结果一致，
+// 只是和 NaN 比较大小时仍然按浮点数的规则，结果都是 false；
+// 其他运算中整数先转换为浮点数，左右操作数的顺序保持不变
 func evalNumberInfixExpression(operator string, left, right object.Object) object.Object {
-	leftValue := &object.Float{Value: float64(left.(*object.Integer).Value)}
+	leftValue, rightValue := toFloat(left), toFloat(right)
+	if operator == "==" || operator == "!=" ||
+		isComparisonOperator(operator) && !math.IsNaN(leftValue.Value) && !math.IsNaN(rightValue.Value) {
+		return evalComparisonExpression(operator, left, right)
+	}
+	return evalFloatInfixExpression(operator, leftValue, rightValue)
+}
 
-	return evalFloatInfixExpression(operator, leftValue, right)
+// 把数字对象转换为浮点数对象
+func toFloat(obj object.Object) *object.Float {
+	if integer, ok := obj.(*object.Integer); ok {
+		return &object.Float{Value: float64(integer.Value)}
+	}
+	return obj.(*object.Float)
+}
+
+// 计算整数的幂，指数不能是负数，结果超出 int64 的范围时返回 false
+func integerPow(base, exponent int64) (int64, bool) {
+	result := int64(1)
+	ok := true
+	for exponent > 0 {
+		if exponent&1 == 1 {
+			if result, ok = multiplyInt64(result, base); !ok {
+				return 0, false
+			}
+		}
+		exponent >>= 1
+		if exponent > 0 {
+			if base, ok = multiplyInt64(base, base); !ok {
+				return 0, false
+			}
+		}
+	}
+	return result, true
+}
+
+// 计算两个整数的积，结果超出 int64 的范围时返回 false
+func multiplyInt64(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	c := a * b
+	if c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return c, true
 }
 
 // 将原生布尔值转换为对象系统的布尔对象
@@ -493,9 +763,9 @@ func nativeBoolToBooleanObject(input bool) object.Object {
 
 // 处理字符串类型的中缀表达式运算
 func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
-	// 检查是否为支持的运算符，目前只支持字符串连接运算符"+"
+	// "+" 连接字符串，其他运算符按字典序比较
 	if operator != "+" {
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return evalComparisonExpression(operator, left, right)
 	}
 
 	// 获取左右操作数的字符串值
@@ -506,18 +776,83 @@ func evalStringInfixExpression(operator string, left, right object.Object) objec
 	return &object.String{Value: leftValue + rightValue}
 }
 
-// 将函数对象应用于给定的参数并返回结果
-func applyFunction(fn object.Object, args []object.Object) object.Object {
+// 用 object.Compare 计算比较运算的结果，用于字符串、数组和元组
+func evalComparisonExpression(operator string, left, right object.Object) object.Object {
+	if operator == "==" || operator == "!=" {
+		return nativeBoolToBooleanObject(object.Equal(left, right) == (operator == "=="))
+	}
+	if !isComparisonOperator(operator) {
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+	result, ok := object.Compare(left, right)
+	if !ok {
+		return newError("cannot compare %s %s %s: elements are not ordered", left.Type(), operator, right.Type())
+	}
+	switch operator {
+	case "<":
+		return nativeBoolToBooleanObject(result < 0)
+	case "<=":
+		return nativeBoolToBooleanObject(result <= 0)
+	case ">":
+		return nativeBoolToBooleanObject(result > 0)
+	default:
+		return nativeBoolToBooleanObject(result >= 0)
+	}
+}
+
+// 判断是否是比较大小的运算符
+func isComparisonOperator(operator string) bool {
+	return operator == "<" || operator == "<=" || operator == ">" || operator == ">="
+}
+
+// Apply 用参数 args 调用函数 fn 并返回结果，用于在 Go 代码中调用 Holiya 函数
+// ctx 是调用内置函数时的上下文；函数没有返回值时返回 NULL，出错时返回 *object.Error
+func Apply(fn object.Object, args []object.Object, ctx *object.Context) object.Object {
+	ctx.PushCall(object.Frame{Function: functionName(fn)})
+	result := applyFunction(fn, args, ctx)
+	ctx.PopCall()
+	if result == nil {
+		return NULL
+	}
+	return result
+}
+
+// 返回函数的名字，用于调用栈，匿名函数返回空字符串
+func functionName(fn object.Object) string {
+	switch fn := fn.(type) {
+	case *object.Function:
+		return fn.Name
+	case *object.Builtin:
+		return fn.Name
+	}
+	return ""
+}
+
+// 将函数对象应用于给定的参数并返回结果，ctx 是调用内置函数时的上下文
+func applyFunction(fn object.Object, args []object.Object, ctx *object.Context) object.Object {
 	// 使用类型断言来处理不同类型的函数
 	switch fn := fn.(type) {
 	case *object.Function:
-		// 处理用户定义的函数
-		extendedEnv := extendFunctionEnv(fn, args)
+		// 处理用户定义的函数，调用的深度有上限，和虚拟机相同
+		if ctx.Depth() > object.MaxCallDepth {
+			return newError("stack overflow")
+		}
+		extendedEnv, err := extendFunctionEnv(fn, args)
+		if err != nil {
+			return err
+		}
 		evaluated := Eval(fn.Body, extendedEnv)
+		// break 和 continue 不能跳出函数
+		if evaluated == BREAK || evaluated == CONTINUE {
+			return newError("%s statement outside loop", evaluated.Inspect())
+		}
 		return unwrapReturnValue(evaluated)
 	case *object.Builtin:
-		// 处理内置函数，直接调用其Fn字段
-		return fn.Fn(args...)
+		// 处理内置函数，直接调用其Fn字段，没有返回值时返回 NULL，和虚拟机相同
+		if result := fn.Fn(ctx, args...); result != nil {
+			return result
+		}
+		return NULL
 	default:
 		// 如果对象不是函数，则返回错误
 		return newError("not a function: %s", fn.Type())
@@ -525,12 +860,70 @@ func applyFunction(fn object.Object, args []object.Object) object.Object {
 }
 
 // 创建函数执行环境，将函数参数绑定到封闭环境中的变量
-func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+// 参数数量不对时返回错误；没有传入的参数使用默认值，默认值在调用时按顺序求值，
+// 可以使用前面的参数；多出的参数放到剩余参数的数组中
+func extendFunctionEnv(fn *object.Function, args []object.Object) (*object.Environment, object.Object) {
+	if err := checkArity(fn, len(args)); err != nil {
+		return nil, err
+	}
+
 	env := object.NewEnclosedEnvironment(fn.Env)
 	for paramIdx, param := range fn.Parameters {
-		env.Set(param.Value, args[paramIdx])
+		if paramIdx < len(args) {
+			env.Set(param.Value, args[paramIdx])
+			continue
+		}
+		value := Eval(fn.Defaults[paramIdx], env)
+		if isError(value) {
+			return nil, value
+		}
+		env.Set(param.Value, value)
+	}
+
+	if fn.Rest != nil {
+		rest := []object.Object{}
+		if len(args) > len(fn.Parameters) {
+			rest = append(rest, args[len(fn.Parameters):]...)
+		}
+		env.Set(fn.Rest.Value, &object.Array{Elements: rest})
 	}
-	return env
+	return env, nil
+}
+
+// 检查调用函数时传入的参数数量
+// 参数数量在必需参数的数量和全部参数的数量之间才是合法的，有剩余参数时没有上限
+func checkArity(fn *object.Function, got int) *object.Error {
+	required := requiredParameters(fn)
+	total := len(fn.Parameters)
+	if got >= required && (got <= total || fn.Rest != nil) {
+		return nil
+	}
+
+	var want string
+	switch {
+	case fn.Rest != nil:
+		want = fmt.Sprintf(">=%d", required)
+	case required == total:
+		want = fmt.Sprintf("%d", total)
+	default:
+		want = fmt.Sprintf("%d..%d", required, total)
+	}
+
+	name := "anonymous function"
+	if fn.Name != "" {
+		name = "`" + fn.Name + "`"
+	}
+	return newError("wrong number of arguments to %s. got=%d, want=%s", name, got, want)
+}
+
+// 返回函数必需参数的数量，也就是第一个有默认值的参数之前的参数的数量
+func requiredParameters(fn *object.Function) int {
+	for i := range fn.Parameters {
+		if i < len(fn.Defaults) && fn.Defaults[i] != nil {
+			return i
+		}
+	}
+	return len(fn.Parameters)
 }
 
 // 从返回值对象中提取实际的值
@@ -544,6 +937,94 @@ func unwrapReturnValue(obj object.Object) object.Object {
 	return obj
 }
 
+// 计算赋值表达式的值，结果是赋给目标的值
+// 复合赋值运算符（如 +=）先用对应的中缀运算符计算出新的值，再赋值
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	// 和编译器相同，从左到右求值：先求目标的对象和索引，复合赋值再读取当前值，最后求右边的值
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		var current object.Object
+		if node.Operator != "=" {
+			var ok bool
+			current, ok = env.Get(target.Value)
+			if !ok {
+				return newError("identifier not found: " + target.Value)
+			}
+		}
+		value := evalAssignValue(node, current, env)
+		if isError(value) {
+			return value
+		}
+		if _, ok := env.Assign(target.Value, value); !ok {
+			return newError("cannot assign to undeclared variable: %s", target.Value)
+		}
+		return value
+	case *ast.IndexExpression:
+		left := Eval(target.Left, env)
+		if isError(left) {
+			return left
+		}
+		index := Eval(target.Index, env)
+		if isError(index) {
+			return index
+		}
+		var current object.Object
+		if node.Operator != "=" {
+			current = evalIndexExpression(left, index)
+			if isError(current) {
+				return current
+			}
+		}
+		value := evalAssignValue(node, current, env)
+		if isError(value) {
+			return value
+		}
+		return evalIndexAssignment(left, index, value)
+	default:
+		return newError("cannot assign to %s", node.Target.String())
+	}
+}
+
+// 求赋值表达式右边的值，复合赋值时和目标的当前值 current 运算得到新值
+func evalAssignValue(node *ast.AssignExpression, current object.Object, env *object.Environment) object.Object {
+	value := Eval(node.Value, env)
+	if isError(value) || node.Operator == "=" {
+		return value
+	}
+	return evalCompoundValue(node.Operator, current, value)
+}
+
+// 计算复合赋值运算符的新值，如 x += 1 的新值是 x + 1
+func evalCompoundValue(operator string, current, value object.Object) object.Object {
+	return evalInfixExpression(strings.TrimSuffix(operator, "="), current, value)
+}
+
+// 给数组的元素或者哈希表的键赋值
+// 数组的索引必须在数组的范围内，哈希表的键不存在时会添加新的键值对
+func evalIndexAssignment(left, index, value object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		idx, ok := index.(*object.Integer)
+		if !ok {
+			return newError("array index must be INTEGER, got %s", index.Type())
+		}
+		if idx.Value < 0 || idx.Value >= int64(len(left.Elements)) {
+			return newError("index out of range: %d", idx.Value)
+		}
+		left.Elements[idx.Value] = value
+		return value
+	case *object.Hashmap:
+		key, ok := object.AsHashable(index)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+		left.Set(key, value)
+		return value
+	default:
+		return newError("index assignment not supported: %s", left.Type())
+	}
+}
+
 // 计算索引表达式的值（如 array[0], hash["key"], string[1]）
 // 根据左操作数和索引的类型，调用相应的处理函数
 func evalIndexExpression(left, index object.Object) object.Object {
@@ -552,6 +1033,9 @@ func evalIndexExpression(left, index object.Object) object.Object {
 	// 当左操作数是数组且索引是整数时，调用数组索引处理函数
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	// 当左操作数是元组且索引是整数时，调用元组索引处理函数
+	case left.Type() == object.TUPLE_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalTupleIndexExpression(left, index)
 	// 当左操作数是哈希表时，调用哈希表索引处理函数
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
@@ -583,6 +1067,18 @@ func evalArrayIndexExpression(array, index object.Object) object.Object {
 	return arrayObject.Elements[idx]
 }
 
+// 计算元组索引表达式的值，索引超出元组范围时返回 NULL
+func evalTupleIndexExpression(tuple, index object.Object) object.Object {
+	elements := tuple.(*object.Tuple).Elements
+	idx := index.(*object.Integer).Value
+
+	if idx < 0 || idx >= int64(len(elements)) {
+		return NULL
+	}
+
+	return elements[idx]
+}
+
 // 计算哈希表索引表达式的值
 // 该函数接收一个哈希表对象和一个索引对象，返回哈希表中对应键的值
 // 如果键不可哈希，则返回错误信息，键不存在则返回 NULL
@@ -591,14 +1087,14 @@ func evalHashIndexExpression(hash, index object.Object) object.Object {
 	hashObject := hash.(*object.Hashmap)
 
 	// 检查索引是否可哈希（只有可哈希的对象才能作为哈希表的键）
-	key, ok := index.(object.Hashable)
+	key, ok := object.AsHashable(index)
 	if !ok {
 		// 如果索引不可哈希，返回错误信息
 		return newError("unusable as hash key: %s", index.Type())
 	}
 
 	// 在哈希表中查找对应的键值对
-	pair, ok := hashObject.Pairs[key.GetHashKey()]
+	pair, ok := hashObject.Get(key)
 	if !ok {
 		// 如果键不存在，返回 NULL
 		return NULL
@@ -609,19 +1105,17 @@ func evalHashIndexExpression(hash, index object.Object) object.Object {
 }
 
 // 计算字符串索引表达式，获取字符串中指定位置的字符
+// 索引按字符计算，和 len 返回的长度一致
 func evalStringIndexExpression(stringObject, index object.Object) object.Object {
-	stringValue := stringObject.(*object.String).Value
+	str := stringObject.(*object.String)
 	idx := index.(*object.Integer).Value
 
 	// 检查索引是否越界
-	if idx < 0 || idx > int64(len(stringValue)-1) {
+	if idx < 0 || idx >= int64(str.Len()) {
 		return NULL
 	}
 
-	// 将字符串转换为 rune 切片以正确处理 Unicode 字符，然后返回指定索引的字符
-	runes := []rune(stringValue)
-	return &object.String{Value: string(runes[idx])}
-
+	return &object.String{Value: str.CharAt(int(idx))}
 }
 
 // 检查给定的对象是否为错误类型
@@ -1,6 +1,8 @@
 package evaluator
 
 import (
+	"bytes"
+	"holiya/ast"
 	"holiya/lexer"
 	"holiya/object"
 	"holiya/parser"
@@ -21,6 +23,9 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"5 + 5 + 5 + 5 - 10;", 10},
 		{"2 * 2 * 2 * 2 * 2;", 32},
 		{"-50 + 100 + -50;", 0},
+		{"1+2*3;", 7},
+		{"0xFF + 0o17 + 0b1010;", 280},
+		{"1_000_000 - 1;", 999999},
 		{"5 * 2 + 10;", 20},
 		{"5 + 2 * 10;", 25},
 		{"20 + 2 * -10;", 0},
@@ -29,6 +34,17 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"3 * 3 * 3 + 10;", 37},
 		{"3 * (3 * 3) + 10;", 37},
 		{"(5 + 10 * 2 + 15 / 3) * 2 + -10;", 50},
+		{"7 % 3 * 2;", 2},
+		{"2 ** 10;", 1024},
+		{"2 ** 3 ** 2;", 512},
+		{"-2 ** 2;", -4},
+		{"(-2) ** 3;", -8},
+		{"2 * 3 ** 2;", 18},
+		{"5 ** 0;", 1},
+		{"2 ** 62;", 4611686018427387904},
+		{"(-2) ** 63;", -9223372036854775808},
+		{"1 ** 1000;", 1},
+		{"(-1) ** 1001;", -1},
 	}
 
 	for _, tt := range tests {
@@ -51,10 +67,18 @@ func TestEvalFloatExpression(t *testing.T) {
 		{"5.5 + 5.5;", 11.0},
 		{"2.5 * 2.0;", 5.0},
 		{"-50.0 + 100.5;", 50.5},
+		{"1.5e3-1;", 1499.0},
+		{"2E-2 * 100;", 2.0},
 		{"5.5 * 2 + 10.0;", 21.0},
 		{"5.0 + 2 * 10.5;", 26.0},
 		{"20.0 + 2 * -10.5;", -1.0},
 		{"2.5 * (5.0 + 10.0);", 37.5},
+		{"10.0 - 4;", 6.0},
+		{"9.0 / 2;", 4.5},
+		{"2.0 ** 3;", 8.0},
+		{"2 ** 0.5 ** 2;", 1.189207115002721},
+		{"2 ** -1;", 0.5},
+		{"7.5 % 2;", 1.5},
 	}
 
 	for _, tt := range tests {
@@ -86,9 +110,18 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{"true != false;", true},
 		{"false != true;", true},
 		{"(1 < 2) == true;", true},
+		{"1 + 1 <= 2;", true},
+		{"10 % 4 >= 3;", false},
+		{"1 < 2 == 2 < 3;", true},
+		{"2 ** 3 > 7;", true},
 		{"(1 < 2) == false;", false},
 		{"(1 > 2) == true;", false},
 		{"(1 > 2) == false;", true},
+		{"9007199254740993 == 9007199254740992.0;", false},
+		{"9007199254740993 != 9007199254740992.0;", true},
+		{"9007199254740993 > 9007199254740992.0;", true},
+		{"9007199254740992.0 < 9007199254740993;", true},
+		{"9007199254740992 == 9007199254740992.0;", true},
 	}
 
 	for _, tt := range tests {
@@ -119,6 +152,209 @@ func TestBangOperator(t *testing.T) {
 	}
 }
 
+// TestLogicalOperators 测试逻辑运算符(&&, ||)的求值
+// 结果是布尔值，真假的判断和 if 的条件相同
+func TestLogicalOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"true && true;", true},
+		{"true && false;", false},
+		{"false && true;", false},
+		{"false || true;", true},
+		{"false || false;", false},
+		{"1 && \"a\";", true},
+		{"1 < 2 && 2 < 3;", true},
+		{"1 > 2 || 2 > 3;", false},
+		{"false || true && false;", false},
+		{"if (false) { 1 } || true;", true},
+		{"let a = 5; a > 1 && a < 10;", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+// TestLogicalShortCircuit 测试逻辑运算符的短路求值
+// 左边已经决定结果时，右边的表达式不会被求值，所以不会产生错误
+func TestLogicalShortCircuit(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"false && undefinedVariable;", false},
+		{"true || undefinedVariable;", true},
+		{"false && len(1);", false},
+		{"true || 1 + true;", true},
+		{"true && undefinedVariable;", "identifier not found: undefinedVariable"},
+		{"false || len(1);", "argument to `len` not supported, got INTEGER"},
+		{"undefinedVariable || true;", "identifier not found: undefinedVariable"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+// TestAssignExpressions 测试赋值和复合赋值表达式的求值
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let x = 1; x = 2; x;", 2},
+		{"let x = 1; x = 2;", 2},
+		{"let x = 1; x += 2; x;", 3},
+		{"let x = 5; x -= 2; x;", 3},
+		{"let x = 5; x *= 2; x;", 10},
+		{"let x = 9; x /= 2; x;", 4},
+		{"let x = 9; x %= 4; x;", 1},
+		{"let s = \"a\"; s += \"b\"; s;", "ab"},
+		{"let a = 1; let b = 2; a = b = 3; a + b;", 6},
+		{"let count = 0; let inc = fn() { count += 1; }; inc(); inc(); count;", 2},
+		{"let x = 1; let f = fn() { let x = 10; x = 20; }; f(); x;", 1},
+		{"let arr = [1, 2, 3]; arr[1] = 5; arr[1];", 5},
+		{"let arr = [1, 2, 3]; arr[2] *= 10; arr;", "[1, 2, 30]"},
+		{"let h = {\"a\": 1}; h[\"a\"] += 1; h[\"a\"];", 2},
+		{"let h = {}; h[\"b\"] = 2; h[\"b\"];", 2},
+		{"x = 1;", "cannot assign to undeclared variable: x"},
+		{"x += 1;", "identifier not found: x"},
+		{"let f = fn() { y = 1; }; f();", "cannot assign to undeclared variable: y"},
+		{"let arr = [1]; arr[1] = 2;", "index out of range: 1"},
+		{"let arr = [1]; arr[\"a\"] = 2;", "array index must be INTEGER, got STRING"},
+		{"let h = {}; h[fn(x) { x }] = 1;", "unusable as hash key: FUNCTION"},
+		{"let s = \"abc\"; s[0] = \"b\";", "index assignment not supported: STRING"},
+		{"let x = 1; x += true;", "type mismatch: INTEGER + BOOLEAN"},
+		// 从左到右求值：先求目标的索引，复合赋值先读取当前值，再求右边的值
+		{"let log = []; let a = [0, 0]; let idx = fn() { log = push(log, 1); 1 }; let val = fn() { log = push(log, 2); 5 }; a[idx()] = val(); [log, a];", "[[1, 2], [0, 5]]"},
+		{"let x = 1; let f = fn() { x = 100; 1 }; x += f(); x;", 2},
+		{"let a = [1]; let f = fn() { a[0] = 100; 1 }; a[0] += f(); a[0];", 2},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			switch evaluated := evaluated.(type) {
+			case *object.Error:
+				if evaluated.Message != expected {
+					t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, expected, evaluated.Message)
+				}
+			case *object.String:
+				if evaluated.Value != expected {
+					t.Errorf("String has wrong value. expected=%q, got=%q", expected, evaluated.Value)
+				}
+			default:
+				if evaluated.Inspect() != expected {
+					t.Errorf("evaluated.Inspect() = %q, want %q", evaluated.Inspect(), expected)
+				}
+			}
+		}
+	}
+}
+
+// TestLoopStatements 测试 while、for 和 for-in 循环以及 break、continue
+func TestLoopStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let i = 0; while (i < 10) { i += 1; }; i;", 10},
+		{"let i = 0; while (false) { i += 1; }; i;", 0},
+		{"let sum = 0; for (let i = 1; i <= 100; i += 1) { sum += i; }; sum;", 5050},
+		{"let i = 0; for (; i < 5;) { i += 1; }; i;", 5},
+		{"let i = 0; for (;;) { i += 1; if (i == 3) { break; } }; i;", 3},
+		{"let i = 0; while (true) { i += 1; if (i >= 7) { break; } }; i;", 7},
+		{"let sum = 0; for (let i = 0; i < 10; i += 1) { if (i % 2 == 0) { continue; } sum += i; }; sum;", 25},
+		{"let sum = 0; for (x in [1, 2, 3]) { sum += x; }; sum;", 6},
+		{"let s = \"\"; for (c in \"abc\") { s = c + s; }; s;", "cba"},
+		{"let sum = 0; for (k in {1: true, 2: true, 3: true}) { sum += k; }; sum;", 6},
+		{"let n = 0; for (x in [1, 2, 3, 4]) { if (x == 3) { break; } n += 1; }; n;", 2},
+		{"let f = fn() { for (x in [1, 2, 3]) { if (x == 2) { return x * 10; } } return 0; }; f();", 20},
+		{"let f = fn() { let i = 0; while (true) { i += 1; if (i == 4) { return i; } } }; f();", 4},
+		{"let n = 0; for (let i = 0; i < 3; i += 1) { for (let j = 0; j < 3; j += 1) { if (j == 1) { break; } n += 1; } }; n;", 3},
+		{"let fs = []; for (x in [1, 2]) { fs = push(fs, fn() { x }); }; fs[0]() + fs[1]();", 3},
+		{"let arr = [1, 2]; for (x in arr) { arr = push(arr, x); }; len(arr);", 4},
+		{"let i = 0; while (i < 100000) { i += 1; }; i;", 100000},
+		{"for (let i = 0; i < 1; i += 1) { }; i;", "identifier not found: i"},
+		{"for (x in 5) { }", "cannot iterate over INTEGER"},
+		{"while (y) { }", "identifier not found: y"},
+		{"let i = 0; while (i < 3) { i += true; }", "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			switch evaluated := evaluated.(type) {
+			case *object.Error:
+				if evaluated.Message != expected {
+					t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, expected, evaluated.Message)
+				}
+			case *object.String:
+				if evaluated.Value != expected {
+					t.Errorf("String has wrong value. expected=%q, got=%q", expected, evaluated.Value)
+				}
+			default:
+				t.Errorf("unexpected object for %q: %T(%+v)", tt.input, evaluated, evaluated)
+			}
+		}
+	}
+}
+
+// TestLoopControlOutsideLoop 测试 break 和 continue 跳出函数时的错误
+// 解析器会拒绝这样的程序，这里直接构造语法树检查求值器的处理
+func TestLoopControlOutsideLoop(t *testing.T) {
+	tests := []struct {
+		node     ast.Node
+		expected string
+	}{
+		{&ast.Program{Statements: []ast.Statement{&ast.BreakStatement{}}}, "break statement outside loop"},
+		{&ast.Program{Statements: []ast.Statement{&ast.ContinueStatement{}}}, "continue statement outside loop"},
+	}
+
+	for _, tt := range tests {
+		evaluated := Eval(tt.node, object.NewEnvironment())
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+
+	fn := &object.Function{
+		Body: &ast.BlockStatement{Statements: []ast.Statement{&ast.BreakStatement{}}},
+		Env:  object.NewEnvironment(),
+	}
+	evaluated := applyFunction(fn, nil, object.DefaultContext())
+	errObj, ok := evaluated.(*object.Error)
+	if !ok || errObj.Message != "break statement outside loop" {
+		t.Errorf("applyFunction() = %+v, want break statement outside loop error", evaluated)
+	}
+}
+
 // TestIfElseExpressions 测试条件表达式(if-else)的求值
 // 包括if语句、if-else语句以及返回值的处理
 func TestIfElseExpressions(t *testing.T) {
@@ -195,6 +431,26 @@ func TestErrorHandling(t *testing.T) {
 			"-true",
 			"unknown operator: -BOOLEAN",
 		},
+		{
+			"5 % 0",
+			"Division by zero",
+		},
+		{
+			"5.0 % 0",
+			"Division by zero",
+		},
+		{
+			"5 % 0.0",
+			"Division by zero",
+		},
+		{
+			"2 ** 64",
+			"integer overflow: 2 ** 64",
+		},
+		{
+			"(-3) ** 41",
+			"integer overflow: -3 ** 41",
+		},
 		{
 			"true + false;",
 			"unknown operator: BOOLEAN + BOOLEAN",
@@ -311,6 +567,53 @@ func TestFunctionApplication(t *testing.T) {
 	}
 }
 
+// TestFunctionArity 测试调用函数时参数数量的检查、参数的默认值和剩余参数
+func TestFunctionArity(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let add = fn(a, b) { a + b }; add(1);", "wrong number of arguments to `add`. got=1, want=2"},
+		{"let add = fn(a, b) { a + b }; add(1, 2, 3);", "wrong number of arguments to `add`. got=3, want=2"},
+		{"fn(x) { x }();", "wrong number of arguments to anonymous function. got=0, want=1"},
+		{"let f = fn(a, b = 2) { a + b }; f();", "wrong number of arguments to `f`. got=0, want=1..2"},
+		{"let f = fn(a, ...rest) { a }; f();", "wrong number of arguments to `f`. got=0, want=>=1"},
+		{"let f = fn(a, b = 2) { a + b }; f(1);", 3},
+		{"let f = fn(a, b = 2) { a + b }; f(1, 5);", 6},
+		{"let f = fn(a = 1, b = a * 10) { a + b }; f();", 11},
+		{"let f = fn(a = 1, b = a * 10) { a + b }; f(2);", 22},
+		{"let n = 5; let f = fn(a = n) { a }; n = 7; f();", 7},
+		{"let f = fn(...rest) { len(rest) }; f();", 0},
+		{"let f = fn(...rest) { len(rest) }; f(1, 2, 3);", 3},
+		{"let f = fn(a, ...rest) { a + rest[0] + rest[1] }; f(1, 2, 3);", 6},
+		{"let f = fn(a, b = 10, ...rest) { a + b + len(rest) }; f(1);", 11},
+		{"let f = fn(a, b = 10, ...rest) { a + b + len(rest) }; f(1, 2, 3, 4);", 5},
+		{"let f = fn(a = undefined) { a }; f();", "identifier not found: undefined"},
+		{"let f = fn(a = undefined) { a }; f(1);", 1},
+		// 前面的调用在栈中留下的值不能当作没有传入的参数
+		{"let mk = fn(x) { fn(y = x * 2, ...r) { y + len(r) } }; mk(3)();", 6},
+		{"let g = fn() { let p = 100; let q = 200; p + q }; g(); let f = fn(a, b = 2, ...rest) { a + b + len(rest) }; f(1);", 3},
+		{"let g = fn(x, y, z) { x + y + z }; g(10, 20, 30); let f = fn(a, b = 2, c = 3, ...rest) { a + b + c + len(rest) }; f(1);", 6},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
 // TestStringLiteral 测试字符串字面量的求值
 func TestStringLiteral(t *testing.T) {
 	input := `"Hello World!"`
@@ -326,6 +629,78 @@ func TestStringLiteral(t *testing.T) {
 	}
 }
 
+// TestUnicodeIdentifiers 测试使用 Unicode 字母的标识符
+func TestUnicodeIdentifiers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let 总数 = 0; for (x in [1, 2, 3]) { 总数 += x; }; 总数;", 6},
+		{"let 平方 = fn(数) { 数 * 数 }; 平方(4);", 16},
+		{"let café = 1; let π2 = 2; café + π2;", 3},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+// TestStringEscapes 测试字符串中的转义序列和原始字符串
+func TestStringEscapes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"a\tb\nc"`, "a\tb\nc"},
+		{`"say \"hi\" \\ bye"`, `say "hi" \ bye`},
+		{`"\x41\u{4E2D}\u{1F600}"`, "A中😀"},
+		{`len("\n\t")`, "2"},
+		{"`raw \\n ${x}`", `raw \n ${x}`},
+		{"`line1\nline2`", "line1\nline2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated == nil || evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s. got=%v, want=%q", tt.input, evaluated, tt.expected)
+		}
+	}
+}
+
+// TestInterpolatedString 测试插值字符串，插值表达式在当前环境中求值，结果用 Inspect() 转换成字符串
+func TestInterpolatedString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let name = "holiya"; "hello ${name}"`, "hello holiya"},
+		{`let items = [1, 2]; "you have ${len(items)} items: ${items}"`, "you have 2 items: [1, 2]"},
+		{`"${1 + 2}${true}"`, "3true"},
+		{`let f = fn(x) { "<${x}>" }; f("a") + f(1)`, "<a><1>"},
+		{`"outer ${ "inner ${1 + 1}" }"`, "outer inner 2"},
+		{`"\${not} ${"interpolated"}"`, "${not} interpolated"},
+		{`"${missing}"`, "identifier not found: missing"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if errObj, ok := evaluated.(*object.Error); ok {
+			if errObj.Message != tt.expected {
+				t.Errorf("wrong error for %s. got=%q, want=%q", tt.input, errObj.Message, tt.expected)
+			}
+			continue
+		}
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String for %s. got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("wrong result for %s. got=%q, want=%q", tt.input, str.Value, tt.expected)
+		}
+	}
+}
+
 // TestStringConcatenation 测试字符串连接操作
 func TestStringConcatenation(t *testing.T) {
 	input := `"Hello" + " " + "World!"`
@@ -357,12 +732,45 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`len([])`, 0},
 		{`first([1, 2, 3])`, 1},
 		{`first([])`, nil},
-		{`first(1)`, "argument to `first` must be ARRAY, got INTEGER"},
+		{`first(1)`, "argument to `first` must be ARRAY or TUPLE, got INTEGER"},
 		{`last([1, 2, 3])`, 3},
 		{`last([])`, nil},
-		{`last(1)`, "argument to `last` must be ARRAY, got INTEGER"},
+		{`last(1)`, "argument to `last` must be ARRAY or TUPLE, got INTEGER"},
 		{`rest([1, 2, 3])`, []int{2, 3}},
 		{`rest([])`, nil},
+		{`len("你好")`, 2},
+		{`len("a😀b")`, 3},
+		{`len(slice([1, 2, 3], 1))`, 2},
+		{`slice([1, 2, 3], 1, 2)`, []int{2}},
+		{`slice([1, 2, 3], 0, 3)`, []int{1, 2, 3}},
+		{`slice([1, 2, 3], 2, 1)`, "slice bounds out of range [2:1] with length 3"},
+		{`slice("你好", 0, 3)`, "slice bounds out of range [0:3] with length 2"},
+		{`slice("abc", "a")`, "slice index must be INTEGER, got STRING"},
+		{`slice(1, 0)`, "argument to `slice` must be ARRAY, TUPLE or STRING, got INTEGER"},
+		{`slice([1])`, "wrong number of arguments. got=1, want=2..3"},
+		{`contains([1, 2, 3], 2)`, true},
+		{`contains([1, [2, 3]], [2, 3])`, true},
+		{`contains([1, 2, 3], 2.0)`, true},
+		{`contains([1, 2, 3], "2")`, false},
+		{`contains("你好世界", "世界")`, true},
+		{`contains({"a": 1}, "a")`, true},
+		{`contains({"a": 1}, 1)`, false},
+		{`contains("abc", 1)`, "second argument to `contains` must be STRING, got INTEGER"},
+		{`contains({}, [1])`, "unusable as hash key: ARRAY"},
+		{`contains(1, 1)`, "argument to `contains` must be ARRAY, TUPLE, STRING or HASH, got INTEGER"},
+		{`contains([1])`, "wrong number of arguments. got=1, want=2"},
+		{`sort([3, 1, 2])`, []int{1, 2, 3}},
+		{`let a = [2, 1]; sort(a); a`, []int{2, 1}},
+		{`sort([])`, []int{}},
+		{`sort([1, "a"])`, "`sort` cannot compare STRING with INTEGER"},
+		{`sort(1)`, "argument to `sort` must be ARRAY or TUPLE, got INTEGER"},
+		{`format()`, "wrong number of arguments. got=0, want=>=1"},
+		{`format(1)`, "first argument to `format` must be STRING, got INTEGER"},
+		{`format("%d", "a")`, "`format` cannot format STRING with %d"},
+		{`printf("%d")`, "`printf` missing argument for %d"},
+		{`exit("a")`, "argument to `exit` must be INTEGER, got STRING"},
+		{`exit(1, 2)`, "wrong number of arguments. got=2, want=0..1"},
+		{`exit(256)`, "exit code out of range: 256"},
 	}
 
 	for _, tt := range tests {
@@ -371,6 +779,8 @@ func TestBuiltinFunctions(t *testing.T) {
 		switch expected := tt.expected.(type) {
 		case int:
 			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
 		case nil:
 			testNullObject(t, evaluated)
 		case string:
@@ -402,6 +812,148 @@ func TestBuiltinFunctions(t *testing.T) {
 	}
 }
 
+// TestBuiltinOutput 测试输出的内置函数写到环境的上下文中，而不是进程的标准输出
+func TestBuiltinOutput(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedStdout string
+		expectedStderr string
+	}{
+		{`puts(1, "a")`, "1\na\n", ""},
+		{`print("a", 1, [2]); print("b")`, "a 1 [2]b", ""},
+		{`println("a", 1); println()`, "a 1\n\n", ""},
+		{`eprint("x"); eprintln("y", 2)`, "", "xy 2\n"},
+		{`printf("%s has %d items\n", "cart", 3)`, "cart has 3 items\n", ""},
+		{`let f = fn() { println(format("%.1f", 2)) }; f()`, "2.0\n", ""},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		env := object.NewEnvironment()
+		env.SetContext(&object.Context{Stdout: &stdout, Stderr: &stderr})
+		evaluated := Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env)
+		if isError(evaluated) {
+			t.Errorf("%q returned error: %s", tt.input, evaluated.Inspect())
+			continue
+		}
+		if stdout.String() != tt.expectedStdout {
+			t.Errorf("%q wrote %q to stdout, want %q", tt.input, stdout.String(), tt.expectedStdout)
+		}
+		if stderr.String() != tt.expectedStderr {
+			t.Errorf("%q wrote %q to stderr, want %q", tt.input, stderr.String(), tt.expectedStderr)
+		}
+	}
+}
+
+// TestExit 测试 exit 函数像错误一样中止程序，后面的代码不再执行
+func TestExit(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedCode int
+	}{
+		{`exit()`, 0},
+		{`exit(3); 1`, 3},
+		{`let f = fn() { for (x in [1, 2]) { if (x == 2) { exit(x) } } }; f(); 1`, 2},
+		{`let a = [1, exit(4)]; a`, 4},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		err, ok := evaluated.(*object.Error)
+		if !ok || !err.Exit {
+			t.Errorf("%q did not exit. got=%v", tt.input, evaluated)
+			continue
+		}
+		if err.Code != tt.expectedCode {
+			t.Errorf("%q exited with %d, want %d", tt.input, err.Code, tt.expectedCode)
+		}
+	}
+}
+
+// TestStructuralEquality 测试 == 和 != 按值比较数组、哈希表等复合值
+func TestStructuralEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`[1, 2] == [1, 2]`, true},
+		{`[1, 2] != [1, 2]`, false},
+		{`[1, 2] == [2, 1]`, false},
+		{`[1, 2] == [1, 2, 3]`, false},
+		{`[[1], "a", true] == [[1], "a", true]`, true},
+		{`[1, 2] == [1.0, 2.0]`, true},
+		{`[] == []`, true},
+		{`{"a": 1, "b": 2} == {"b": 2, "a": 1}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": [1]} == {"a": [1]}`, true},
+		{`{"a": 1} == {"a": 1, "b": 2}`, false},
+		{`"abc" == "abc"`, true},
+		{`"abc" != "abd"`, true},
+		{`[1] == 1`, false},
+		{`"1" == 1`, false},
+		{`[][0] == {}["x"]`, true},
+		{`let f = fn() {}; f == f`, true},
+		{`fn() {} == fn() {}`, false},
+		{`let a = [1]; let b = a; b[0] = 2; a == [2]`, true},
+		// 循环引用的数组和哈希表也能比较完
+		{`let a = [1]; let b = [a]; a[0] = b; a == b`, true},
+		{`let a = [1]; a[0] = a; let b = [1]; b[0] = b; a == b`, true},
+		{`let a = [1, 2]; a[0] = a; let b = [1, 3]; b[0] = b; a == b`, false},
+		{`let a = [1]; a[0] = a; contains([1, a], [a])`, true},
+		{`let h = {}; h["self"] = h; let g = {}; g["self"] = g; h == g`, true},
+		{`let a = [1]; a[0] = a; a < [a]`, false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+// TestOrdering 测试字符串和数组按字典序比较大小
+func TestOrdering(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"a" < "b"`, true},
+		{`"abc" < "abd"`, true},
+		{`"ab" < "abc"`, true},
+		{`"b" >= "abc"`, true},
+		{`"Z" < "a"`, true},
+		{`"你" > "a"`, true},
+		{`[1, 2] < [1, 3]`, true},
+		{`[1, 2] < [1, 2, 0]`, true},
+		{`[2] > [1, 9]`, true},
+		{`[1, 2] <= [1, 2]`, true},
+		{`[1.5] > [1]`, true},
+		{`[["a"]] < [["b"]]`, true},
+		{`[] >= []`, true},
+		{`[1] < [true]`, "cannot compare ARRAY < ARRAY: elements are not ordered"},
+		{`[1] < "a"`, "type mismatch: ARRAY < STRING"},
+		{`[1] + [2]`, "unknown operator: ARRAY + ARRAY"},
+		{`{} < {}`, "unknown operator: HASH < HASH"},
+		{`true < false`, "unknown operator: BOOLEAN < BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("object is not Error for %s. got=%T (%+v)", tt.input, evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message for %s. expected=%q, got=%q", tt.input, expected, errObj.Message)
+			}
+		}
+	}
+}
+
 // TestArrayLiterals 测试数组字面量的求值
 func TestArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
@@ -500,13 +1052,13 @@ func TestHashLiterals(t *testing.T) {
 		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
 	}
 
-	expected := map[object.HashKey]int64{
-		(&object.String{Value: "one"}).GetHashKey():   1,
-		(&object.String{Value: "two"}).GetHashKey():   2,
-		(&object.String{Value: "three"}).GetHashKey(): 3,
-		(&object.Integer{Value: 4}).GetHashKey():      4,
-		TRUE.GetHashKey():                             5,
-		FALSE.GetHashKey():                            6,
+	expected := map[object.Hashable]int64{
+		&object.String{Value: "one"}:   1,
+		&object.String{Value: "two"}:   2,
+		&object.String{Value: "three"}: 3,
+		&object.Integer{Value: 4}:      4,
+		TRUE:                           5,
+		FALSE:                          6,
 	}
 
 	if len(result.Pairs) != len(expected) {
@@ -514,13 +1066,113 @@ func TestHashLiterals(t *testing.T) {
 	}
 
 	for expectedKey, expectedValue := range expected {
-		pair, ok := result.Pairs[expectedKey]
+		pair, ok := result.Get(expectedKey)
 		if !ok {
 			t.Errorf("no pair for given key in Pairs")
 		}
 
 		testIntegerObject(t, pair.Value, expectedValue)
 	}
+
+	// 键值对按源码中的顺序排列
+	if result.Inspect() != "{one: 1, two: 2, three: 3, 4: 4, true: 5, false: 6}" {
+		t.Errorf("wrong order of pairs: %s", result.Inspect())
+	}
+}
+
+// TestHashOrder 测试哈希表保持插入的顺序，键值对按顺序求值，遍历和输出也按插入的顺序
+func TestHashOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"b": 1, "a": 2, "c": 3}`, "{b: 1, a: 2, c: 3}"},
+		{`let h = {3: 1, 1: 2}; h[2] = 3; h[3] = 4; h`, "{3: 4, 1: 2, 2: 3}"},
+		{`{1: "x", 1: "y"}`, "{1: y}"},
+		{`let s = ""; let h = {"z": 1, "y": 2, "x": 3}; for (k in h) { s = s + k; }; s`, "zyx"},
+		{`let log = []; let f = fn(x) { log = push(log, x); x }; {f("k1"): f(1), f("k2"): f(2)}; log`, "[k1, 1, k2, 2]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s. got=%s, want=%s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+// TestFloatHashKeys 测试浮点数作为哈希键，以及整数和浮点数、0 和 -0.0、NaN 的键比较
+func TestFloatHashKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{1.1: "a", 1.9: "b"}`, "{1.100000: a, 1.900000: b}"},
+		{`let h = {1.1: "a", 1.9: "b"}; [h[1.1], h[1.9], h[1.5]]`, "[a, b, null]"},
+		{`{1: "int", 1.0: "float"}`, "{1: float}"},
+		{`let h = {2.0: "two"}; [h[2], h[2.0]]`, "[two, two]"},
+		{`let h = {0: "zero"}; h[-0.0]`, "zero"},
+		{`let inf = 1e308 * 10; let h = {}; h[inf - inf] = 1; h[inf - inf] = 2; h`, "{NaN: 2}"},
+		{`let inf = 1e308 * 10; let h = {inf: "inf"}; [h[inf], h[-inf]]`, "[inf, null]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %s. got=%s, want=%s", tt.input, evaluated.Inspect(), tt.expected)
+		}
+	}
+}
+
+// TestTuples 测试元组的创建、索引、遍历、比较、解构和作为哈希键使用
+func TestTuples(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`#(1, "a", [2])`, "#(1, a, [2])"},
+		{`#()`, "#()"},
+		{`let t = #(1, 2, 3); [t[0], t[2], t[3], t[-1], len(t)]`, "[1, 3, null, null, 3]"},
+		{`let s = 0; for (x in #(1, 2, 3)) { s += x; }; s`, "6"},
+		{`#(1, 2) == #(1, 2)`, "true"},
+		{`#(1, 2) == #(1.0, 2)`, "true"},
+		{`#(1, 2) == [1, 2]`, "false"},
+		{`#(1, 2) < #(1, 3)`, "true"},
+		{`#(2) > #(1, 5)`, "true"},
+		{`let t = #(1, 2); t[0] = 3`, "index assignment not supported: TUPLE"},
+		{`let grid = {#(0, 0): "origin", #(1, 2): "p"}; [grid[#(1, 2)], grid[#(2, 1)], grid[#(0.0, 0)]]`, "[p, null, origin]"},
+		{`let h = {}; h[#(1, "a")] = 1; h[#(1, "a")] = 2; h`, "{#(1, a): 2}"},
+		{`let h = {#(#(1, 2), "x"): true}; h[#(#(1, 2), "x")]`, "true"},
+		{`let seen = {}; for (p in [#(1, 2), #(2, 1), #(1, 2)]) { seen[p] = true; }; seen`, "{#(1, 2): true, #(2, 1): true}"},
+		{`contains({#(1, 2): true}, #(1, 2))`, "true"},
+		{`[first(#(1, 2)), last(#(1, 2)), first(#()), last(#())]`, "[1, 2, null, null]"},
+		{`[rest(#(1, 2, 3)), rest(#())]`, "[#(2, 3), null]"},
+		{`[slice(#(1, 2, 3), 1), slice(#(1, 2, 3), 0, 2), slice(#(), 0)]`, "[#(2, 3), #(1, 2), #()]"},
+		{`slice(#(1, 2), 1, 3)`, "slice bounds out of range [1:3] with length 2"},
+		{`[contains(#(1, [2]), [2]), contains(#(1, 2), 2.0), contains(#(1, 2), 3)]`, "[true, true, false]"},
+		{`let t = #(3, 1, 2); [sort(t), t]`, "[#(1, 2, 3), #(3, 1, 2)]"},
+		{`sort(#(1, "a"))`, "`sort` cannot compare STRING with INTEGER"},
+		{`push(#(1), 2)`, "argument to `push` must be ARRAY, got TUPLE"},
+		{`{#([1]): 1}`, "unusable as hash key: TUPLE"},
+		{`let h = {}; h[#(1, {})]`, "unusable as hash key: TUPLE"},
+		{`let #(x, y) = #(1, 2); x * 10 + y`, "12"},
+		{`let #(a, b) = [3, 4]; a - b`, "-1"},
+		{`let swap = fn(p) { let #(a, b) = p; #(b, a) }; swap(#(1, 2))`, "#(2, 1)"},
+		{`let #(a, a) = #(1, 2); a`, "2"},
+		{`let #(x, y) = #(1, 2, 3)`, "cannot destructure TUPLE of length 3 into 2 variables"},
+		{`let #(x) = 1`, "cannot destructure INTEGER, want TUPLE or ARRAY"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		result := evaluated.Inspect()
+		if errObj, ok := evaluated.(*object.Error); ok {
+			result = errObj.Message
+		}
+		if result != tt.expected {
+			t.Errorf("wrong result for %s. got=%s, want=%s", tt.input, result, tt.expected)
+		}
+	}
 }
 
 // TestHashIndexExpressions 测试哈希表索引表达式的求值
@@ -598,6 +1250,23 @@ func TestStringIndexExpressions(t *testing.T) {
 			`let s = "hello"; s[1]`,
 			"e",
 		},
+		{
+			// 下标按字符计算
+			`"你好"[1]`,
+			"好",
+		},
+		{
+			`"你好"[2]`,
+			nil,
+		},
+		{
+			`"a😀b"[2]`,
+			"b",
+		},
+		{
+			`let s = "héllo"; s[len(s) - 1]`,
+			"o",
+		},
 	}
 
 	for _, tt := range tests {
@@ -611,6 +1280,99 @@ func TestStringIndexExpressions(t *testing.T) {
 	}
 }
 
+// TestUnicodeStrings 测试 len、下标、slice 和遍历对字符串都按字符计算
+func TestUnicodeStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`slice("你好世界", 1, 3)`, "好世"},
+		{`slice("héllo", 1)`, "éllo"},
+		{`slice("abc", 3)`, ""},
+		{`let s = ""; for (c in "你好") { s = s + c + "|"; }; s`, "你|好|"},
+		{`let s = "总数😀"; let n = 0; for (c in s) { n += 1; }; "${n} ${len(s)} ${s[n - 1]}"`, "3 3 😀"},
+		// 不合法的 UTF-8 字节每个算作一个字符
+		{`let s = "a\xffb"; "${len(s)} ${s[2]}"`, "3 b"},
+	}
+
+	for _, tt := range tests {
+		testStringObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+// TestErrorPosition 测试错误信息中的位置
+func TestErrorPosition(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedInspect string
+	}{
+		{"foobar", "ERROR: main.holiya:1:1: identifier not found: foobar"},
+		{"let a = 1;\nlet b = a + true;", "ERROR: main.holiya:2:9: type mismatch: INTEGER + BOOLEAN"},
+		{"let f = fn(x) {\n  x + y;\n};\nf(1);", "ERROR: main.holiya:2:7: identifier not found: y"},
+		{"let a = 1;\n  len(a);", "ERROR: main.holiya:2:3: argument to `len` not supported, got INTEGER"},
+		{"-true", "ERROR: main.holiya:1:1: unknown operator: -BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.NewWithFilename("main.holiya", tt.input)
+		p := parser.New(l)
+		program := p.ParseProgram()
+		evaluated := Eval(program, object.NewEnvironment())
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Inspect() != tt.expectedInspect {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expectedInspect, errObj.Inspect())
+		}
+	}
+}
+
+// TestErrorTrace 测试错误中记录的调用栈，最内层的调用在前面
+func TestErrorTrace(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedTrace string
+	}{
+		{"1 + true", ""},
+		{"let f = fn() { 1 };\nf();\nf() + true", ""},
+		{
+			"let inner = fn(x) {\n  x + true\n};\nlet outer = fn() { inner(1) };\nouter();",
+			"  `inner` called at 4:20\n  `outer` called at 5:1\n",
+		},
+		{
+			"let make = fn() { fn() { len(1) } };\nmake()();",
+			"  anonymous function called at 2:1\n",
+		},
+		{
+			"let f = fn(a) { a };\nlet g = fn() { f() };\ng();",
+			"  `g` called at 3:1\n",
+		},
+		{
+			"let down = fn(n) { if (n == 0) { n + true } else { down(n - 1) } };\ndown(3);",
+			"  `down` called at 1:52\n  ... repeated 2 more times\n  `down` called at 2:1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%q: no error object returned. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		expected := ""
+		if tt.expectedTrace != "" {
+			expected = "traceback (most recent call first):\n" + tt.expectedTrace
+		}
+		if trace := object.FormatTrace(errObj.Trace); trace != expected {
+			t.Errorf("%q: wrong trace. expected=%q, got=%q", tt.input, expected, trace)
+		}
+	}
+}
+
 // testEval 辅助函数：将输入的代码进行词法分析、语法分析并求值
 func testEval(input string) object.Object {
 	l := lexer.New(input)
@@ -1,40 +1,52 @@
 package file
 
 import (
+	"fmt"
 	"io"
 	"os"
 
-	"holiya/evaluator"
+	"holiya/engine"
 	"holiya/lexer"
 	"holiya/object"
 	"holiya/parser"
 )
 
-// ProcessFile 处理指定的文件，逐行读取内容
-func ProcessFile(filename string, out io.Writer) error {
+// ProcessFile 以脚本模式执行文件，返回进程的退出码
+// 只有 puts、print 等内置函数的输出写到 stdout，语法错误和执行时的错误带着位置写到 stderr，执行时的错误后面还有调用栈：
+//   - 读取文件失败或者有语法错误时不执行任何代码，返回 1
+//   - 执行出错时返回 1，调用 exit 函数时返回它的参数，否则返回 0
+func ProcessFile(filename string, stdout, stderr io.Writer, e engine.Engine) int {
 	data, err := os.ReadFile(filename)
 	if err != nil {
-		return err
+		fmt.Fprintln(stderr, err)
+		return 1
 	}
-	content := string(data)
-	env := object.NewEnvironment()
-	l := lexer.New(content)
-	p := parser.New(l)
+
+	p := parser.New(lexer.NewWithFilename(filename, string(data)))
 	program := p.ParseProgram()
 	if len(p.Errors()) != 0 {
-		io.WriteString(out, "parser errors:\n")
-		for _, msg := range p.Errors() {
-			io.WriteString(out, "\t"+msg+"\n")
+		for _, diagnostic := range p.Errors() {
+			fmt.Fprintln(stderr, diagnostic.Error())
 		}
+		return 1
 	}
 
-	for _, statement := range program.Statements {
-		evaluated := evaluator.Eval(statement, env)
-		if evaluated != nil {
-			io.WriteString(out, evaluated.Inspect())
-			io.WriteString(out, "\n")
+	e.SetContext(&object.Context{Stdout: stdout, Stderr: stderr, Stdin: os.Stdin})
+	if err, ok := e.Run(program).(*object.Error); ok {
+		if err.Exit {
+			return err.Code
 		}
+		fmt.Fprintln(stderr, errorMessage(err))
+		io.WriteString(stderr, object.FormatTrace(err.Trace))
+		return 1
 	}
+	return 0
+}
 
-	return nil
+// errorMessage 返回执行时错误的信息，位置有效时以位置开头，如 main.holiya:12:7: identifier not found: x
+func errorMessage(err *object.Error) string {
+	if err.Pos.IsValid() {
+		return err.Pos.String() + ": " + err.Message
+	}
+	return err.Message
 }
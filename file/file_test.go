@@ -0,0 +1,78 @@
+package file
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"holiya/engine"
+)
+
+// TestProcessFile 测试脚本模式的输出和退出码
+func TestProcessFile(t *testing.T) {
+	tests := []struct {
+		src            string
+		expectedCode   int
+		expectedStdout string
+		expectedStderr string
+	}{
+		{
+			// 只输出 puts 等内置函数的内容，不输出表达式语句的值
+			src:            "let a = 1;\na + 1;\nputs(a);\nprintln(\"done\")\n",
+			expectedCode:   0,
+			expectedStdout: "1\ndone\n",
+		},
+		{
+			// 有语法错误时不执行任何代码
+			src:            "puts(1);\nlet = 2;\n",
+			expectedCode:   1,
+			expectedStderr: "main.holiya:2:5: expected next token to be IDENTIFIER, got = instead\n",
+		},
+		{
+			// 执行出错时停止执行，错误带着位置和调用栈写到 stderr
+			src:            "puts(1);\nlet f = fn() {\n  1 + true\n};\nf();\nputs(2);\n",
+			expectedCode:   1,
+			expectedStdout: "1\n",
+			expectedStderr: "main.holiya:3:3: type mismatch: INTEGER + BOOLEAN\n" +
+				"traceback (most recent call first):\n" +
+				"  `f` called at main.holiya:5:1\n",
+		},
+		{
+			// exit 函数的参数是退出码
+			src:            "eprintln(\"bye\");\nexit(3);\nputs(1);\n",
+			expectedCode:   3,
+			expectedStderr: "bye\n",
+		},
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{engine.EVAL, engine.VM} {
+		for i, tt := range tests {
+			filename := filepath.Join(dir, "main.holiya")
+			if err := os.WriteFile(filename, []byte(tt.src), 0644); err != nil {
+				t.Fatal(err)
+			}
+			var stdout, stderr bytes.Buffer
+			e, _ := engine.New(name)
+			code := ProcessFile(filename, &stdout, &stderr, e)
+			// 错误信息中的文件名是完整的路径
+			gotStderr := bytes.ReplaceAll(stderr.Bytes(), []byte(dir+string(filepath.Separator)), nil)
+			if code != tt.expectedCode {
+				t.Errorf("engine %s, test case %d: exit code = %d, want %d", name, i, code, tt.expectedCode)
+			}
+			if stdout.String() != tt.expectedStdout {
+				t.Errorf("engine %s, test case %d: stdout = %q, want %q", name, i, stdout.String(), tt.expectedStdout)
+			}
+			if string(gotStderr) != tt.expectedStderr {
+				t.Errorf("engine %s, test case %d: stderr = %q, want %q", name, i, gotStderr, tt.expectedStderr)
+			}
+		}
+	}
+
+	var stderr bytes.Buffer
+	e, _ := engine.New(engine.EVAL)
+	if code := ProcessFile(filepath.Join(dir, "missing.holiya"), &bytes.Buffer{}, &stderr, e); code != 1 || stderr.Len() == 0 {
+		t.Errorf("missing file: exit code = %d, stderr = %q", code, stderr.String())
+	}
+}
@@ -0,0 +1,394 @@
+package interp
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	"holiya/object"
+)
+
+var (
+	objectType  = reflect.TypeOf((*object.Object)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	contextType = reflect.TypeOf((*object.Context)(nil))
+)
+
+// ToObject 把 Go 的值转换为 Holiya 的值
+//   - nil 和空指针转换为 null，指针转换为它指向的值
+//   - 布尔值、整数、浮点数和字符串转换为对应的类型，大于 int64 范围的无符号整数会出错
+//   - 切片和数组转换为数组，map 转换为哈希表，键按从小到大的顺序排列
+//   - 结构体转换为以字段名为键的哈希表，字段名可以用 holiya 标签修改，标签为 "-" 的字段会被忽略
+//   - error 转换为 Holiya 的错误，函数转换为内置函数，参数和返回值的转换规则见 RegisterFunc
+//   - object.Object 保持不变，FromObject 转换为 object.Object 或它的具体类型时也保持不变
+func ToObject(v interface{}) (object.Object, error) {
+	if v == nil {
+		return object.NULL, nil
+	}
+	return toObject(reflect.ValueOf(v))
+}
+
+// toObject 把 reflect.Value 转换为 Holiya 的值
+func toObject(v reflect.Value) (object.Object, error) {
+	if !v.IsValid() {
+		return object.NULL, nil
+	}
+	if v.Type().Implements(objectType) && v.Kind() != reflect.Interface {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return object.NULL, nil
+		}
+		return v.Interface().(object.Object), nil
+	}
+	if v.Type().Implements(errorType) && v.Kind() != reflect.Interface {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			return object.NULL, nil
+		}
+		return &object.Error{Message: v.Interface().(error).Error()}, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return object.TRUE, nil
+		}
+		return object.FALSE, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &object.Integer{Value: v.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("cannot convert %d to INTEGER: overflows int64", v.Uint())
+		}
+		return &object.Integer{Value: int64(v.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		return &object.Float{Value: v.Float()}, nil
+	case reflect.String:
+		return &object.String{Value: v.String()}, nil
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return object.NULL, nil
+		}
+		return toObject(v.Elem())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return &object.Array{Elements: []object.Object{}}, nil
+		}
+		elements := make([]object.Object, v.Len())
+		for i := range elements {
+			element, err := toObject(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = element
+		}
+		return &object.Array{Elements: elements}, nil
+	case reflect.Map:
+		return mapToObject(v)
+	case reflect.Struct:
+		return structToObject(v)
+	case reflect.Func:
+		if v.IsNil() {
+			return object.NULL, nil
+		}
+		return wrapFunc("anonymous function", v), nil
+	}
+	return nil, fmt.Errorf("cannot convert %s to a Holiya value", v.Type())
+}
+
+// mapToObject 把 map 转换为哈希表
+// Go 的 map 没有顺序，为了让结果确定，能比较大小的键从小到大排列，否则按字符串表示排列
+func mapToObject(v reflect.Value) (object.Object, error) {
+	pairs := make([]object.HashPair, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := toObject(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		value, err := toObject(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, object.HashPair{Key: key, Value: value})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if result, ok := object.Compare(pairs[i].Key, pairs[j].Key); ok {
+			return result < 0
+		}
+		return pairs[i].Key.Inspect() < pairs[j].Key.Inspect()
+	})
+
+	hash := &object.Hashmap{}
+	for _, pair := range pairs {
+		key, ok := object.AsHashable(pair.Key)
+		if !ok {
+			return nil, fmt.Errorf("unusable as hash key: %s", pair.Key.Type())
+		}
+		hash.Set(key, pair.Value)
+	}
+	return hash, nil
+}
+
+// structToObject 把结构体转换为哈希表，只转换导出的字段
+func structToObject(v reflect.Value) (object.Object, error) {
+	hash := &object.Hashmap{}
+	for i := 0; i < v.NumField(); i++ {
+		name, ok := fieldName(v.Type().Field(i))
+		if !ok {
+			continue
+		}
+		value, err := toObject(v.Field(i))
+		if err != nil {
+			return nil, err
+		}
+		hash.Set(&object.String{Value: name}, value)
+	}
+	return hash, nil
+}
+
+// fieldName 返回结构体字段在哈希表中的键，不导出的字段和标签为 "-" 的字段返回 false
+func fieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	switch tag := field.Tag.Get("holiya"); tag {
+	case "-":
+		return "", false
+	case "":
+		return field.Name, true
+	default:
+		return tag, true
+	}
+}
+
+// FromObject 把 Holiya 的值转换为 Go 的类型 t，转换规则和 ToObject 相反
+// t 是 interface{} 时转换为最接近的 Go 类型：整数为 int64，浮点数为 float64，
+// 数组和元组为 []interface{}，键都是字符串的哈希表为 map[string]interface{}，
+// 其他哈希表为 map[interface{}]interface{}，null 为 nil
+func FromObject(obj object.Object, t reflect.Type) (reflect.Value, error) {
+	if t == objectType {
+		return reflect.ValueOf(&obj).Elem(), nil
+	}
+	if t.Implements(objectType) && reflect.TypeOf(obj) == t {
+		return reflect.ValueOf(obj), nil
+	}
+	if t.Kind() == reflect.Interface && t.NumMethod() == 0 {
+		value, err := toValue(obj)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		result := reflect.New(t).Elem()
+		if value != nil {
+			result.Set(reflect.ValueOf(value))
+		}
+		return result, nil
+	}
+
+	result := reflect.New(t).Elem()
+	mismatch := fmt.Errorf("cannot use %s as %s", obj.Type(), t)
+	switch t.Kind() {
+	case reflect.Bool:
+		b, ok := obj.(*object.Boolean)
+		if !ok {
+			return result, mismatch
+		}
+		result.SetBool(b.Value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		integer, ok := obj.(*object.Integer)
+		if !ok {
+			return result, mismatch
+		}
+		if result.OverflowInt(integer.Value) {
+			return result, fmt.Errorf("cannot use %d as %s: overflows", integer.Value, t)
+		}
+		result.SetInt(integer.Value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		integer, ok := obj.(*object.Integer)
+		if !ok {
+			return result, mismatch
+		}
+		if integer.Value < 0 || result.OverflowUint(uint64(integer.Value)) {
+			return result, fmt.Errorf("cannot use %d as %s: overflows", integer.Value, t)
+		}
+		result.SetUint(uint64(integer.Value))
+	case reflect.Float32, reflect.Float64:
+		switch number := obj.(type) {
+		case *object.Integer:
+			result.SetFloat(float64(number.Value))
+		case *object.Float:
+			result.SetFloat(number.Value)
+		default:
+			return result, mismatch
+		}
+	case reflect.String:
+		str, ok := obj.(*object.String)
+		if !ok {
+			return result, mismatch
+		}
+		result.SetString(str.Value)
+	case reflect.Pointer:
+		if obj == object.NULL {
+			return result, nil
+		}
+		elem, err := FromObject(obj, t.Elem())
+		if err != nil {
+			return result, err
+		}
+		result.Set(reflect.New(t.Elem()))
+		result.Elem().Set(elem)
+	case reflect.Slice, reflect.Array:
+		elements, ok := sequenceElements(obj)
+		if !ok {
+			return result, mismatch
+		}
+		if t.Kind() == reflect.Slice {
+			result.Set(reflect.MakeSlice(t, len(elements), len(elements)))
+		} else if len(elements) != t.Len() {
+			return result, fmt.Errorf("cannot use %s of length %d as %s", obj.Type(), len(elements), t)
+		}
+		for i, element := range elements {
+			value, err := FromObject(element, t.Elem())
+			if err != nil {
+				return result, err
+			}
+			result.Index(i).Set(value)
+		}
+	case reflect.Map:
+		hash, ok := obj.(*object.Hashmap)
+		if !ok {
+			return result, mismatch
+		}
+		result.Set(reflect.MakeMapWithSize(t, len(hash.Pairs)))
+		for _, pair := range hash.Pairs {
+			key, err := FromObject(pair.Key, t.Key())
+			if err != nil {
+				return result, err
+			}
+			value, err := FromObject(pair.Value, t.Elem())
+			if err != nil {
+				return result, err
+			}
+			result.SetMapIndex(key, value)
+		}
+	case reflect.Struct:
+		hash, ok := obj.(*object.Hashmap)
+		if !ok {
+			return result, mismatch
+		}
+		if err := hashToStruct(hash, result); err != nil {
+			return result, err
+		}
+	default:
+		return result, mismatch
+	}
+	return result, nil
+}
+
+// sequenceElements 返回数组或元组的元素
+func sequenceElements(obj object.Object) ([]object.Object, bool) {
+	switch obj := obj.(type) {
+	case *object.Array:
+		return obj.Elements, true
+	case *object.Tuple:
+		return obj.Elements, true
+	}
+	return nil, false
+}
+
+// hashToStruct 把哈希表的值赋给结构体的字段，哈希表中的键必须都是结构体的字段，没有出现的字段保持零值
+func hashToStruct(hash *object.Hashmap, result reflect.Value) error {
+	fields := map[string]int{}
+	for i := 0; i < result.NumField(); i++ {
+		if name, ok := fieldName(result.Type().Field(i)); ok {
+			fields[name] = i
+		}
+	}
+	for _, pair := range hash.Pairs {
+		key, ok := pair.Key.(*object.String)
+		if !ok {
+			return fmt.Errorf("cannot use HASH with %s key as %s", pair.Key.Type(), result.Type())
+		}
+		i, ok := fields[key.Value]
+		if !ok {
+			return fmt.Errorf("%s has no field %s", result.Type(), key.Value)
+		}
+		value, err := FromObject(pair.Value, result.Field(i).Type())
+		if err != nil {
+			return err
+		}
+		result.Field(i).Set(value)
+	}
+	return nil
+}
+
+// ToValue 把 Holiya 的值转换为最接近的 Go 类型，规则见 FromObject
+func ToValue(obj object.Object) (interface{}, error) {
+	return toValue(obj)
+}
+
+// toValue 把 Holiya 的值转换为最接近的 Go 类型
+func toValue(obj object.Object) (interface{}, error) {
+	switch obj := obj.(type) {
+	case *object.Null:
+		return nil, nil
+	case *object.Boolean:
+		return obj.Value, nil
+	case *object.Integer:
+		return obj.Value, nil
+	case *object.Float:
+		return obj.Value, nil
+	case *object.String:
+		return obj.Value, nil
+	case *object.Array, *object.Tuple:
+		elements, _ := sequenceElements(obj)
+		values := make([]interface{}, len(elements))
+		for i, element := range elements {
+			value, err := toValue(element)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	case *object.Hashmap:
+		return hashToValue(obj)
+	}
+	return nil, fmt.Errorf("cannot convert %s to a Go value", obj.Type())
+}
+
+// hashToValue 把哈希表转换为 map，键都是字符串时为 map[string]interface{}
+func hashToValue(hash *object.Hashmap) (interface{}, error) {
+	stringKeys := true
+	for _, pair := range hash.Pairs {
+		if pair.Key.Type() != object.STRING_OBJ {
+			stringKeys = false
+			break
+		}
+	}
+
+	var result reflect.Value
+	if stringKeys {
+		result = reflect.ValueOf(make(map[string]interface{}, len(hash.Pairs)))
+	} else {
+		result = reflect.ValueOf(make(map[interface{}]interface{}, len(hash.Pairs)))
+	}
+	for _, pair := range hash.Pairs {
+		key, err := toValue(pair.Key)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.TypeOf(key).Comparable() {
+			return nil, fmt.Errorf("cannot use %s as a Go map key", pair.Key.Type())
+		}
+		value, err := toValue(pair.Value)
+		if err != nil {
+			return nil, err
+		}
+		valueOf := reflect.New(result.Type().Elem()).Elem()
+		if value != nil {
+			valueOf.Set(reflect.ValueOf(value))
+		}
+		result.SetMapIndex(reflect.ValueOf(key), valueOf)
+	}
+	return result.Interface(), nil
+}
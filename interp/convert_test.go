@@ -0,0 +1,174 @@
+package interp
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"holiya/object"
+)
+
+type point struct {
+	X, Y   int
+	Label  string `holiya:"label"`
+	Secret string `holiya:"-"`
+	hidden int
+}
+
+// 测试把 Go 的值转换为 Holiya 的值
+func TestToObject(t *testing.T) {
+	seven := 7
+	var nilPointer *int
+	tests := []struct {
+		input    interface{}
+		expected string
+	}{
+		{nil, "null"},
+		{true, "true"},
+		{42, "42"},
+		{int8(-3), "-3"},
+		{uint16(9), "9"},
+		{2.5, "2.500000"},
+		{float32(0.5), "0.500000"},
+		{"你好", "你好"},
+		{&seven, "7"},
+		{nilPointer, "null"},
+		{[]int{1, 2, 3}, "[1, 2, 3]"},
+		{[2]string{"a", "b"}, "[a, b]"},
+		{[]int(nil), "[]"},
+		{[]interface{}{1, "a", nil}, "[1, a, null]"},
+		{map[string]int{"b": 2, "a": 1, "c": 3}, "{a: 1, b: 2, c: 3}"},
+		{map[int]bool{3: true, 1: false}, "{1: false, 3: true}"},
+		{point{X: 1, Y: 2, Label: "p", Secret: "s", hidden: 3}, "{X: 1, Y: 2, label: p}"},
+		{&object.Integer{Value: 5}, "5"},
+		{errors.New("boom"), "ERROR: boom"},
+	}
+
+	for _, tt := range tests {
+		result, err := ToObject(tt.input)
+		if err != nil {
+			t.Errorf("ToObject(%#v) returned error: %v", tt.input, err)
+			continue
+		}
+		if result.Inspect() != tt.expected {
+			t.Errorf("ToObject(%#v) = %s, want %s", tt.input, result.Inspect(), tt.expected)
+		}
+	}
+
+	errorTests := []struct {
+		input    interface{}
+		expected string
+	}{
+		{uint64(math.MaxUint64), "cannot convert 18446744073709551615 to INTEGER: overflows int64"},
+		{make(chan int), "cannot convert chan int to a Holiya value"},
+		{map[string]chan int{"a": nil}, "cannot convert chan int to a Holiya value"},
+	}
+	for _, tt := range errorTests {
+		_, err := ToObject(tt.input)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("ToObject(%T) returned %v, want %q", tt.input, err, tt.expected)
+		}
+	}
+}
+
+// 测试把 Holiya 的值转换为 Go 的类型
+func TestFromObject(t *testing.T) {
+	integer := func(v int64) *object.Integer { return &object.Integer{Value: v} }
+	str := func(v string) *object.String { return &object.String{Value: v} }
+	hash := func(pairs ...object.Object) *object.Hashmap {
+		h := &object.Hashmap{}
+		for i := 0; i < len(pairs); i += 2 {
+			key, _ := object.AsHashable(pairs[i])
+			h.Set(key, pairs[i+1])
+		}
+		return h
+	}
+	seven := 7
+
+	tests := []struct {
+		input    object.Object
+		expected interface{}
+	}{
+		{integer(42), 42},
+		{integer(-1), int8(-1)},
+		{integer(200), uint8(200)},
+		{integer(2), 2.0},
+		{&object.Float{Value: 1.5}, float32(1.5)},
+		{str("a"), "a"},
+		{object.TRUE, true},
+		{integer(7), &seven},
+		{object.NULL, (*int)(nil)},
+		{&object.Array{Elements: []object.Object{integer(1), integer(2)}}, []int{1, 2}},
+		{&object.Tuple{Elements: []object.Object{str("a"), str("b")}}, [2]string{"a", "b"}},
+		{hash(str("a"), integer(1)), map[string]int{"a": 1}},
+		{hash(str("X"), integer(1), str("label"), str("p")), point{X: 1, Label: "p"}},
+		{integer(1), object.Object(integer(1))},
+		{str("b"), str("b")},
+	}
+
+	for _, tt := range tests {
+		result, err := FromObject(tt.input, reflect.TypeOf(tt.expected))
+		if err != nil {
+			t.Errorf("FromObject(%s) returned error: %v", tt.input.Inspect(), err)
+			continue
+		}
+		if !reflect.DeepEqual(result.Interface(), tt.expected) {
+			t.Errorf("FromObject(%s) = %#v, want %#v", tt.input.Inspect(), result.Interface(), tt.expected)
+		}
+	}
+
+	errorTests := []struct {
+		input    object.Object
+		typ      interface{}
+		expected string
+	}{
+		{str("a"), 0, "cannot use STRING as int"},
+		{integer(300), int8(0), "cannot use 300 as int8: overflows"},
+		{integer(-1), uint(0), "cannot use -1 as uint: overflows"},
+		{&object.Float{Value: 1.5}, 0, "cannot use FLOAT as int"},
+		{&object.Array{Elements: []object.Object{integer(1)}}, [2]int{}, "cannot use ARRAY of length 1 as [2]int"},
+		{&object.Array{Elements: []object.Object{str("a")}}, []int{}, "cannot use STRING as int"},
+		{hash(str("Z"), integer(1)), point{}, "interp.point has no field Z"},
+		{hash(integer(1), integer(1)), point{}, "cannot use HASH with INTEGER key as interp.point"},
+	}
+	for _, tt := range errorTests {
+		_, err := FromObject(tt.input, reflect.TypeOf(tt.typ))
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("FromObject(%s, %T) returned %v, want %q", tt.input.Inspect(), tt.typ, err, tt.expected)
+		}
+	}
+}
+
+// 测试把 Holiya 的值转换为最接近的 Go 类型
+func TestToValue(t *testing.T) {
+	hash := &object.Hashmap{}
+	hash.Set(&object.String{Value: "a"}, &object.Array{Elements: []object.Object{&object.Integer{Value: 1}, object.NULL}})
+	mixed := &object.Hashmap{}
+	mixed.Set(&object.Integer{Value: 1}, &object.Float{Value: 0.5})
+
+	tests := []struct {
+		input    object.Object
+		expected interface{}
+	}{
+		{object.NULL, nil},
+		{&object.Integer{Value: 1}, int64(1)},
+		{&object.Tuple{Elements: []object.Object{object.TRUE, &object.String{Value: "x"}}}, []interface{}{true, "x"}},
+		{hash, map[string]interface{}{"a": []interface{}{int64(1), nil}}},
+		{mixed, map[interface{}]interface{}{int64(1): 0.5}},
+	}
+	for _, tt := range tests {
+		result, err := ToValue(tt.input)
+		if err != nil {
+			t.Errorf("ToValue(%s) returned error: %v", tt.input.Inspect(), err)
+			continue
+		}
+		if !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("ToValue(%s) = %#v, want %#v", tt.input.Inspect(), result, tt.expected)
+		}
+	}
+
+	if _, err := ToValue(&object.Builtin{}); err == nil {
+		t.Errorf("ToValue(builtin) returned no error")
+	}
+}
@@ -0,0 +1,141 @@
+package interp
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"holiya/object"
+)
+
+// Register 把 Go 实现的内置函数注册为当前解释器的全局变量 name，只对当前解释器有效
+// name 和内置函数或已经定义的全局变量重名时返回错误
+func (it *Interpreter) Register(name string, fn object.BuiltinFunction) error {
+	if fn == nil {
+		return fmt.Errorf("cannot register %q: function is nil", name)
+	}
+	if err := it.checkName(name); err != nil {
+		return err
+	}
+	return it.engine.Set(name, &object.Builtin{Name: name, Fn: fn})
+}
+
+// RegisterFunc 把任意的 Go 函数注册为当前解释器的全局变量 name，如 RegisterFunc("sqrt", math.Sqrt)
+// 调用时参数用 FromObject 转换为函数的参数类型，返回值用 ToObject 转换为 Holiya 的值：
+//   - 第一个参数是 *object.Context 时传入执行时的上下文，可以用它读写解释器的标准输入输出
+//   - 最后一个返回值是 error 且不为 nil 时，调用出错
+//   - 除了 error 没有返回值时返回 null，有一个时返回它，有多个时返回元组
+//   - 函数中发生的 panic 也会转换为错误
+//
+// name 和内置函数或已经定义的全局变量重名时返回错误
+func (it *Interpreter) RegisterFunc(name string, fn interface{}) error {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return fmt.Errorf("cannot register %q: %T is not a function", name, fn)
+	}
+	if err := it.checkName(name); err != nil {
+		return err
+	}
+	builtin := wrapFunc("`"+name+"`", v)
+	builtin.Name = name
+	return it.engine.Set(name, builtin)
+}
+
+// SetValue 把 Go 的值用 ToObject 转换后定义为全局变量，已经存在时修改它的值
+func (it *Interpreter) SetValue(name string, value interface{}) error {
+	obj, err := ToObject(value)
+	if err != nil {
+		return err
+	}
+	return it.Set(name, obj)
+}
+
+// checkName 检查注册的名字是否和内置函数或已经定义的全局变量重名
+func (it *Interpreter) checkName(name string) error {
+	if object.GetBuiltinByName(name) != nil {
+		return fmt.Errorf("cannot register %q: name is a builtin function", name)
+	}
+	if _, ok := it.engine.Get(name); ok {
+		return fmt.Errorf("cannot register %q: name is already defined", name)
+	}
+	return nil
+}
+
+// wrapFunc 把 Go 函数包装成内置函数，name 用于错误信息
+// 函数的第一个参数是 *object.Context 时，调用时传入执行时的上下文，不占用 Holiya 的参数
+func wrapFunc(name string, fn reflect.Value) *object.Builtin {
+	t := fn.Type()
+	takesContext := t.NumIn() > 0 && t.In(0) == contextType
+	returnsError := t.NumOut() > 0 && t.Out(t.NumOut()-1) == errorType
+
+	return &object.Builtin{Fn: func(ctx *object.Context, args ...object.Object) (result object.Object) {
+		defer func() {
+			if r := recover(); r != nil {
+				result = &object.Error{Message: fmt.Sprintf("panic in %s: %v", name, r)}
+			}
+		}()
+
+		var in []reflect.Value
+		if takesContext {
+			in = append(in, reflect.ValueOf(ctx))
+		}
+		converted, err := convertArgs(t, len(in), args)
+		if err != nil {
+			return &object.Error{Message: fmt.Sprintf("%s: %s", name, err)}
+		}
+		out := fn.Call(append(in, converted...))
+
+		if returnsError {
+			if err, _ := out[len(out)-1].Interface().(error); err != nil {
+				return objectError(err)
+			}
+			out = out[:len(out)-1]
+		}
+		elements := make([]object.Object, len(out))
+		for i, value := range out {
+			element, err := toObject(value)
+			if err != nil {
+				return &object.Error{Message: fmt.Sprintf("%s: result %d: %s", name, i+1, err)}
+			}
+			elements[i] = element
+		}
+		switch len(elements) {
+		case 0:
+			return object.NULL
+		case 1:
+			return elements[0]
+		}
+		return &object.Tuple{Elements: elements}
+	}}
+}
+
+// convertArgs 检查参数的个数，并把参数转换为函数 t 从第 first 个参数开始的参数类型
+func convertArgs(t reflect.Type, first int, args []object.Object) ([]reflect.Value, error) {
+	fixed := t.NumIn() - first
+	if t.IsVariadic() {
+		fixed--
+	}
+	if len(args) < fixed || (!t.IsVariadic() && len(args) > fixed) {
+		want := strconv.Itoa(fixed)
+		if t.IsVariadic() {
+			want += ".."
+		}
+		return nil, fmt.Errorf("wrong number of arguments. got=%d, want=%s", len(args), want)
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var paramType reflect.Type
+		if i < fixed {
+			paramType = t.In(first + i)
+		} else {
+			paramType = t.In(t.NumIn() - 1).Elem()
+		}
+		value, err := FromObject(arg, paramType)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %s", i+1, err)
+		}
+		in[i] = value
+	}
+	return in, nil
+}
@@ -0,0 +1,145 @@
+package interp
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// 测试注册 Go 函数，参数和返回值自动转换
+func TestRegisterFunc(t *testing.T) {
+	type user struct {
+		Name string
+		Age  int
+	}
+
+	for name, it := range newInterpreters(t) {
+		funcs := map[string]interface{}{
+			"sqrt":  math.Sqrt,
+			"upper": strings.ToUpper,
+			"atoi":  strconv.Atoi,
+			"split": strings.Split,
+			"sum": func(nums ...int) int {
+				total := 0
+				for _, n := range nums {
+					total += n
+				}
+				return total
+			},
+			"divmod": func(a, b int) (int, int) { return a / b, a % b },
+			"older":  func(u user) user { u.Age++; return u },
+			"keys": func(m map[string]int) int {
+				return len(m)
+			},
+			"noop":    func() {},
+			"explode": func() int { panic("oops") },
+		}
+		for fnName, fn := range funcs {
+			if err := it.RegisterFunc(fnName, fn); err != nil {
+				t.Fatalf("%s: RegisterFunc(%s) returned error: %v", name, fnName, err)
+			}
+		}
+
+		tests := []struct {
+			input    string
+			expected string
+		}{
+			{"sqrt(16)", "4.000000"},
+			{"sqrt(2.25)", "1.500000"},
+			{`upper("abc")`, "ABC"},
+			{`atoi("12") + 1`, "13"},
+			{`split("a,b", ",")`, "[a, b]"},
+			{"sum()", "0"},
+			{"sum(1, 2, 3)", "6"},
+			{"divmod(7, 2)", "#(3, 1)"},
+			{`older({"Name": "li", "Age": 1})`, "{Name: li, Age: 2}"},
+			{`keys({"a": 1, "b": 2})`, "2"},
+			{"noop()", "null"},
+			{"let f = fn(g) { g(9) }; f(sqrt)", "3.000000"},
+		}
+		for _, tt := range tests {
+			result, err := it.Eval(tt.input)
+			if err != nil {
+				t.Errorf("%s: Eval(%q) returned error: %v", name, tt.input, err)
+				continue
+			}
+			if result.Inspect() != tt.expected {
+				t.Errorf("%s: Eval(%q) = %s, want %s", name, tt.input, result.Inspect(), tt.expected)
+			}
+		}
+
+		errorTests := []struct {
+			input    string
+			expected string
+		}{
+			{`atoi("x")`, `strconv.Atoi: parsing "x": invalid syntax`},
+			{`sqrt("x")`, "`sqrt`: argument 1: cannot use STRING as float64"},
+			{"sqrt()", "`sqrt`: wrong number of arguments. got=0, want=1"},
+			{`sum(1, "a")`, "`sum`: argument 2: cannot use STRING as int"},
+			{`older({"Name": "li", "Email": ""})`, "`older`: argument 1: interp.user has no field Email"},
+			{"explode()", "panic in `explode`: oops"},
+		}
+		for _, tt := range errorTests {
+			_, err := it.Eval(tt.input)
+			var runtimeError *Error
+			if !errors.As(err, &runtimeError) || runtimeError.Message != tt.expected {
+				t.Errorf("%s: Eval(%q) returned %v, want %q", name, tt.input, err, tt.expected)
+			}
+		}
+	}
+}
+
+// 测试注册的函数只对当前解释器有效，并且不能和内置函数或已有的全局变量重名
+func TestRegisterCollisions(t *testing.T) {
+	for name, it := range newInterpreters(t) {
+		if err := it.RegisterFunc("double", func(n int) int { return n * 2 }); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := it.Eval("let x = 1;"); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name     string
+			fn       interface{}
+			expected string
+		}{
+			{"len", func() {}, `cannot register "len": name is a builtin function`},
+			{"double", func() {}, `cannot register "double": name is already defined`},
+			{"x", func() {}, `cannot register "x": name is already defined`},
+			{"y", 42, `cannot register "y": int is not a function`},
+		}
+		for _, tt := range tests {
+			err := it.RegisterFunc(tt.name, tt.fn)
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("%s: RegisterFunc(%s) returned %v, want %q", name, tt.name, err, tt.expected)
+			}
+		}
+		if err := it.Register("push", nil); err == nil {
+			t.Errorf("%s: Register(push, nil) returned no error", name)
+		}
+
+		other := New()
+		if _, err := other.Eval("double(1)"); err == nil || err.Error() != "1:1: identifier not found: double" {
+			t.Errorf("%s: function registered on another interpreter is visible: %v", name, err)
+		}
+	}
+}
+
+// 测试用 SetValue 把 Go 的值定义为全局变量
+func TestSetValue(t *testing.T) {
+	for name, it := range newInterpreters(t) {
+		if err := it.SetValue("config", map[string]interface{}{"port": 8080, "hosts": []string{"a", "b"}}); err != nil {
+			t.Fatal(err)
+		}
+		result, err := it.Eval(`config["port"] + len(config["hosts"])`)
+		if err != nil || result.Inspect() != "8082" {
+			t.Errorf("%s: Eval = %v, %v, want 8082", name, result, err)
+		}
+		if err := it.SetValue("bad", make(chan int)); err == nil {
+			t.Errorf("%s: SetValue(chan) returned no error", name)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Package interp 提供在 Go 程序中嵌入 Holiya 的接口
+//
+//	it := interp.New()
+//	it.Eval(`let add = fn(a, b) { a + b };`)
+//	result, err := it.Call("add", &object.Integer{Value: 1}, &object.Integer{Value: 2})
+package interp
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"holiya/engine"
+	"holiya/lexer"
+	"holiya/object"
+	"holiya/parser"
+	"holiya/token"
+)
+
+// Error 执行 Holiya 代码时发生的错误，语法错误使用 parser.ErrorList
+type Error struct {
+	Message string
+	// 出错的位置，不知道位置时无效
+	Pos token.Position
+	// 出错时 Holiya 的调用栈，最内层的调用在前面
+	Trace []object.Frame
+}
+
+// Error 实现 error 接口，位置有效时以位置开头，如 main.holiya:12:7: identifier not found: x
+func (e *Error) Error() string {
+	if e.Pos.IsValid() {
+		return e.Pos.String() + ": " + e.Message
+	}
+	return e.Message
+}
+
+// Traceback 返回格式化后的调用栈，在顶层代码中出错时返回空字符串
+func (e *Error) Traceback() string {
+	return object.FormatTrace(e.Trace)
+}
+
+// ExitError 执行的代码调用了 exit 函数，Code 是退出码
+type ExitError struct {
+	Code int
+}
+
+// Error 实现 error 接口
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// Interpreter Holiya 解释器，多次执行代码时，前面定义的全局变量在后面仍然可以使用
+// 一个解释器不能同时在多个 goroutine 中使用
+type Interpreter struct {
+	// 标准输出、标准错误和标准输入，New 创建时是进程的标准输出、标准错误和标准输入
+	// puts、print 等内置函数的输入输出都通过它们进行，每次执行代码时读取最新的值
+	Stdout io.Writer
+	Stderr io.Writer
+	Stdin  io.Reader
+
+	engine engine.Engine
+	// 正在执行的 Eval 和 Call 的数量，注册的 Go 函数中再调用 Eval 或 Call 时大于 0
+	running int
+}
+
+// New 创建使用树遍历求值器的解释器
+func New() *Interpreter {
+	it, _ := NewWithEngine(engine.EVAL)
+	return it
+}
+
+// NewWithEngine 创建使用指定引擎的解释器，引擎的名字是 engine.EVAL 或 engine.VM
+func NewWithEngine(name string) (*Interpreter, error) {
+	e, err := engine.New(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Interpreter{
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+		Stdin:  os.Stdin,
+		engine: e,
+	}, nil
+}
+
+// Eval 执行源码，返回最后一条表达式语句的值，没有值时返回 object.NULL
+// 有语法错误时不执行任何代码，返回 parser.ErrorList；执行出错时返回 *Error；调用 exit 函数时返回 *ExitError
+func (it *Interpreter) Eval(src string) (object.Object, error) {
+	return it.eval(lexer.New(src))
+}
+
+// EvalFile 执行文件，错误的位置中包含文件名
+func (it *Interpreter) EvalFile(filename string) (object.Object, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return it.eval(lexer.NewWithFilename(filename, string(data)))
+}
+
+// eval 解析并执行词法分析器中的源码
+func (it *Interpreter) eval(l *lexer.Lexer) (object.Object, error) {
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if err := p.Errors().Err(); err != nil {
+		return nil, err
+	}
+	defer it.enter()()
+	return result(it.engine.Run(program))
+}
+
+// Set 定义全局变量，已经存在时修改它的值，全局变量的数量达到上限时返回错误
+func (it *Interpreter) Set(name string, value object.Object) error {
+	return it.engine.Set(name, value)
+}
+
+// Get 返回全局变量的值，没有这个全局变量时返回同名的内置函数
+func (it *Interpreter) Get(name string) (object.Object, bool) {
+	return it.engine.Get(name)
+}
+
+// Call 用参数 args 调用名为 fnName 的函数，返回函数的返回值，函数没有返回值时返回 object.NULL
+func (it *Interpreter) Call(fnName string, args ...object.Object) (object.Object, error) {
+	fn, ok := it.engine.Get(fnName)
+	if !ok {
+		return nil, &Error{Message: "identifier not found: " + fnName}
+	}
+	defer it.enter()()
+	return result(it.engine.Call(fn, args))
+}
+
+// enter 开始执行代码，返回执行结束时调用的函数
+// 最外层的执行使用解释器最新的标准输入输出创建上下文；注册的 Go 函数中再执行代码时，
+// 继续使用正在执行的代码的上下文，这样出错时的调用栈包含外层的调用
+func (it *Interpreter) enter() func() {
+	if it.running == 0 {
+		it.engine.SetContext(it.context())
+	}
+	it.running++
+	return func() { it.running-- }
+}
+
+// context 用解释器的标准输出、标准错误和标准输入创建内置函数执行时的上下文
+func (it *Interpreter) context() *object.Context {
+	return &object.Context{Stdout: it.Stdout, Stderr: it.Stderr, Stdin: it.Stdin}
+}
+
+// result 把引擎返回的 *object.Error 转换为 Go 的错误
+func result(obj object.Object) (object.Object, error) {
+	switch obj := obj.(type) {
+	case nil:
+		return object.NULL, nil
+	case *object.Error:
+		if obj.Exit {
+			return nil, &ExitError{Code: obj.Code}
+		}
+		return nil, &Error{Message: obj.Message, Pos: obj.Pos, Trace: obj.Trace}
+	}
+	return obj, nil
+}
+
+// objectError 把 Go 的错误转换为 *object.Error，是 result 的逆过程
+// 注册的 Go 函数中调用 Eval 或 Call 出错时，保留错误的位置、调用栈和退出码，
+// 这样错误和在 Holiya 代码中直接调用时相同
+func objectError(err error) *object.Error {
+	switch err := err.(type) {
+	case *Error:
+		return &object.Error{Message: err.Message, Pos: err.Pos, Trace: err.Trace}
+	case *ExitError:
+		return &object.Error{Message: err.Error(), Exit: true, Code: err.Code}
+	}
+	return &object.Error{Message: err.Error()}
+}
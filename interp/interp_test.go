@@ -0,0 +1,268 @@
+package interp
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"holiya/engine"
+	"holiya/object"
+	"holiya/parser"
+)
+
+// newInterpreters 创建使用两种引擎的解释器，每个测试都在两种引擎上执行
+func newInterpreters(t *testing.T) map[string]*Interpreter {
+	interpreters := map[string]*Interpreter{}
+	for _, name := range []string{engine.EVAL, engine.VM} {
+		it, err := NewWithEngine(name)
+		if err != nil {
+			t.Fatalf("NewWithEngine(%q) returned error: %v", name, err)
+		}
+		interpreters[name] = it
+	}
+	return interpreters
+}
+
+// 测试多次执行代码时保留全局变量
+func TestEval(t *testing.T) {
+	for name, it := range newInterpreters(t) {
+		if _, err := it.Eval("let x = 40;"); err != nil {
+			t.Fatalf("%s: Eval returned error: %v", name, err)
+		}
+		result, err := it.Eval("x + 2")
+		if err != nil {
+			t.Fatalf("%s: Eval returned error: %v", name, err)
+		}
+		if result.Inspect() != "42" {
+			t.Errorf("%s: result = %s, want 42", name, result.Inspect())
+		}
+		result, err = it.Eval("let y = 1;")
+		if err != nil || result != object.NULL {
+			t.Errorf("%s: Eval(let) = %v, %v, want NULL", name, result, err)
+		}
+	}
+}
+
+// 测试语法错误和执行时的错误都以 Go 的错误返回，有语法错误时不执行任何代码
+func TestEvalErrors(t *testing.T) {
+	for name, it := range newInterpreters(t) {
+		_, err := it.Eval("let a = 1; let = 2;")
+		var syntaxErrors parser.ErrorList
+		if !errors.As(err, &syntaxErrors) || len(syntaxErrors) != 1 {
+			t.Errorf("%s: Eval returned %v, want one syntax error", name, err)
+		}
+		if _, ok := it.Get("a"); ok {
+			t.Errorf("%s: program with syntax errors was executed", name)
+		}
+
+		_, err = it.Eval("let b = 1;\nb + true")
+		var runtimeError *Error
+		if !errors.As(err, &runtimeError) {
+			t.Fatalf("%s: Eval returned %v, want *Error", name, err)
+		}
+		if err.Error() != "2:1: type mismatch: INTEGER + BOOLEAN" {
+			t.Errorf("%s: err = %q", name, err.Error())
+		}
+	}
+}
+
+// 测试执行文件时错误的位置包含文件名
+func TestEvalFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "main.holiya")
+	if err := os.WriteFile(filename, []byte("let a = [1, 2];\na[0] + a[1]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for name, it := range newInterpreters(t) {
+		result, err := it.EvalFile(filename)
+		if err != nil || result.Inspect() != "3" {
+			t.Errorf("%s: EvalFile = %v, %v, want 3", name, result, err)
+		}
+		if _, err := it.EvalFile(filepath.Join(t.TempDir(), "missing.holiya")); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s: EvalFile(missing) returned %v", name, err)
+		}
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.holiya")
+	if err := os.WriteFile(bad, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := New().EvalFile(bad)
+	if err == nil || err.Error() != bad+":1:1: identifier not found: x" {
+		t.Errorf("EvalFile(bad) returned %v", err)
+	}
+}
+
+// 测试在 Go 代码中读写全局变量
+func TestGetSet(t *testing.T) {
+	for name, it := range newInterpreters(t) {
+		if err := it.Set("limit", &object.Integer{Value: 10}); err != nil {
+			t.Fatalf("%s: Set(limit) returned error: %v", name, err)
+		}
+		result, err := it.Eval("limit * 2")
+		if err != nil || result.Inspect() != "20" {
+			t.Errorf("%s: Eval(limit * 2) = %v, %v", name, result, err)
+		}
+		if _, err := it.Eval(`let greeting = "hi";`); err != nil {
+			t.Fatal(err)
+		}
+		if value, ok := it.Get("greeting"); !ok || value.Inspect() != "hi" {
+			t.Errorf("%s: Get(greeting) = %v, %t", name, value, ok)
+		}
+		if value, ok := it.Get("len"); !ok || value.Type() != object.BUILTIN_OBJ {
+			t.Errorf("%s: Get(len) = %v, %t", name, value, ok)
+		}
+		if _, ok := it.Get("missing"); ok {
+			t.Errorf("%s: Get(missing) found a value", name)
+		}
+	}
+}
+
+// 测试在 Go 代码中调用 Holiya 函数和内置函数
+func TestCall(t *testing.T) {
+	for name, it := range newInterpreters(t) {
+		_, err := it.Eval(`
+let base = 100;
+let add = fn(a, b = 1) { base + a + b };
+let counter = fn() { let n = 0; fn() { n += 1; n } }();
+let nothing = fn() {};
+`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tests := []struct {
+			fn       string
+			args     []object.Object
+			expected string
+		}{
+			{"add", []object.Object{&object.Integer{Value: 1}, &object.Integer{Value: 2}}, "103"},
+			{"add", []object.Object{&object.Integer{Value: 5}}, "106"},
+			{"counter", nil, "1"},
+			{"counter", nil, "2"},
+			{"nothing", nil, "null"},
+			{"len", []object.Object{&object.String{Value: "你好"}}, "2"},
+		}
+		for _, tt := range tests {
+			result, err := it.Call(tt.fn, tt.args...)
+			if err != nil {
+				t.Errorf("%s: Call(%s) returned error: %v", name, tt.fn, err)
+				continue
+			}
+			if result.Inspect() != tt.expected {
+				t.Errorf("%s: Call(%s) = %s, want %s", name, tt.fn, result.Inspect(), tt.expected)
+			}
+		}
+
+		errorTests := []struct {
+			fn       string
+			args     []object.Object
+			expected string
+		}{
+			{"add", nil, "wrong number of arguments to `add`. got=0, want=1..2"},
+			{"missing", nil, "identifier not found: missing"},
+			{"base", nil, "not a function: INTEGER"},
+		}
+		for _, tt := range errorTests {
+			_, err := it.Call(tt.fn, tt.args...)
+			var runtimeError *Error
+			if !errors.As(err, &runtimeError) || runtimeError.Message != tt.expected {
+				t.Errorf("%s: Call(%s) returned %v, want %q", name, tt.fn, err, tt.expected)
+			}
+		}
+	}
+}
+
+// 测试内置函数和注册的 Go 函数输出到解释器的 Stdout 和 Stderr
+func TestOutput(t *testing.T) {
+	for name, it := range newInterpreters(t) {
+		var stdout, stderr bytes.Buffer
+		it.Stdout, it.Stderr = &stdout, &stderr
+		err := it.RegisterFunc("log", func(ctx *object.Context, msg string) {
+			io.WriteString(ctx.Stderr, "log: "+msg+"\n")
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := it.Eval(`let greet = fn(who) { printf("hello, %s\n", who); log(who) }; puts(1); print("a", "b")`); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := it.Call("greet", &object.String{Value: "go"}); err != nil {
+			t.Fatal(err)
+		}
+		if stdout.String() != "1\na bhello, go\n" {
+			t.Errorf("%s: stdout = %q", name, stdout.String())
+		}
+		if stderr.String() != "log: go\n" {
+			t.Errorf("%s: stderr = %q", name, stderr.String())
+		}
+	}
+}
+
+// 测试执行出错时 *Error 中的调用栈
+func TestErrorTrace(t *testing.T) {
+	for name, it := range newInterpreters(t) {
+		_, err := it.Eval("let check = fn(x) {\n  if (x > 1) { x + true } else { x }\n};\nlet run = fn(x) { check(x) };")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = it.Eval("run(1);\nrun(2);")
+		var runtimeError *Error
+		if !errors.As(err, &runtimeError) {
+			t.Fatalf("%s: Eval returned %v, want *Error", name, err)
+		}
+		expected := "traceback (most recent call first):\n  `check` called at 4:19\n  `run` called at 2:1\n"
+		if runtimeError.Traceback() != expected {
+			t.Errorf("%s: Traceback() = %q, want %q", name, runtimeError.Traceback(), expected)
+		}
+
+		// 从 Go 代码中调用时，最外层的调用没有位置
+		_, err = it.Call("run", &object.Integer{Value: 5})
+		if !errors.As(err, &runtimeError) {
+			t.Fatalf("%s: Call returned %v, want *Error", name, err)
+		}
+		expected = "traceback (most recent call first):\n  `check` called at 4:19\n  `run`\n"
+		if runtimeError.Traceback() != expected {
+			t.Errorf("%s: Traceback() = %q, want %q", name, runtimeError.Traceback(), expected)
+		}
+	}
+}
+
+// 测试注册的 Go 函数中调用 Holiya 函数出错时，错误的位置是被调用的代码中出错的位置，
+// 调用栈包含被调用的函数、注册的函数和外层的调用
+func TestCallFromHost(t *testing.T) {
+	for name, it := range newInterpreters(t) {
+		err := it.RegisterFunc("callback", func(fnName string) error {
+			_, err := it.Call(fnName)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := it.Eval(`let boom = fn() { 1 / 0 }; let quit = fn() { exit(3) };`); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = it.Eval("let run = fn() { callback(\"boom\") };\nrun();")
+		var runtimeError *Error
+		if !errors.As(err, &runtimeError) {
+			t.Fatalf("%s: Eval returned %v, want *Error", name, err)
+		}
+		if runtimeError.Error() != "1:19: Division by zero" {
+			t.Errorf("%s: Error() = %q, want %q", name, runtimeError.Error(), "1:19: Division by zero")
+		}
+		expected := "traceback (most recent call first):\n  `boom`\n  `callback` called at 1:18\n  `run` called at 2:1\n"
+		if runtimeError.Traceback() != expected {
+			t.Errorf("%s: Traceback() = %q, want %q", name, runtimeError.Traceback(), expected)
+		}
+
+		// exit 函数仍然结束整个程序
+		_, err = it.Eval(`callback("quit"); 1`)
+		var exitError *ExitError
+		if !errors.As(err, &exitError) || exitError.Code != 3 {
+			t.Errorf("%s: Eval returned %v, want exit status 3", name, err)
+		}
+	}
+}
@@ -1,22 +1,77 @@
 package lexer
 
 import (
+	"fmt"
 	"holiya/token"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // 结束时返回 0，表示输入的内容已经结束了
-const END byte = 0
+const END rune = 0
 
 // 词法结构体
 type Lexer struct {
+	// 文件名，用于错误信息中的位置，可以为空
+	filename string
 	// 输入的字符串
 	input string
-	// 当前字符的位置，默认为 0
+	// 当前字符的位置，按字节计算，默认为 0
 	position int
-	// 下一个字符的位置，默认为 0
+	// 下一个字符的位置，按字节计算，默认为 0
 	nextPosition int
-	// 当前字符
-	ch byte
+	// 当前字符，输入按 UTF-8 解码，不合法的字节解码为 utf8.RuneError
+	ch rune
+	// 当前字符所在的行，从 1 开始
+	line int
+	// 当前字符所在的列，按字符计算，从 1 开始
+	column int
+	// 词法错误，如字符串没有闭合
+	errors []Error
+	// 还没有附加到 token 上的文档注释
+	doc *token.CommentGroup
+	// 正在读取的插值表达式，插值可以嵌套，如 "a ${ "b ${c}" }"，所以使用栈
+	interpolations []interpolation
+}
+
+// 正在读取的插值表达式
+type interpolation struct {
+	// 插值表达式所在的字符串开头的引号的位置，字符串没有闭合时在这里报告错误
+	start token.Position
+	// 插值表达式中还没有闭合的 { 的数量
+	braces int
+}
+
+// ErrorKind 词法错误的类型
+type ErrorKind int
+
+const (
+	// 字符串没有闭合
+	UNTERMINATED_STRING ErrorKind = iota
+	// 字符串中的转义序列不合法
+	INVALID_ESCAPE
+	// 输入不是合法的 UTF-8 编码
+	INVALID_UTF8
+	// 块注释没有闭合
+	UNTERMINATED_COMMENT
+	// 整数的格式不合法，如 12abc、0xG
+	INVALID_INTEGER
+	// 浮点数的格式不合法，如 1.、.5、1e+
+	INVALID_FLOAT
+)
+
+// Error 词法错误
+// 出错的字符串仍然会返回 token：没有闭合的字符串返回 ILLEGAL token，
+// 转义序列不合法的字符串返回 STRING token，不合法的转义序列原样保留；
+// 格式不合法的数字整体作为一个 ILLEGAL token
+type Error struct {
+	Kind ErrorKind
+	// 出错的开始位置
+	Pos token.Position
+	// 出错的结束位置
+	End     token.Position
+	Message string
 }
 
 // New 创建一个新的 Lexer 实例。
@@ -24,8 +79,16 @@ type Lexer struct {
 // 函数内部会初始化 Lexer 结构体，并调用 readChar 方法读取输入的第一个字符。
 // 返回值是 Lexer 类型的指针，用于进一步处理输入数据。
 func New(input string) *Lexer {
+	return NewWithFilename("", input)
+}
+
+// NewWithFilename 创建一个新的 Lexer 实例，并记录输入内容所在的文件名
+// 文件名会出现在 token 的位置信息中，如 main.holiya:12:7
+func NewWithFilename(filename string, input string) *Lexer {
 	l := &Lexer{
-		input: input,
+		filename: filename,
+		input:    input,
+		line:     1,
 	}
 	l.readChar()
 	return l
@@ -44,6 +107,8 @@ func (l *Lexer) NextToken() token.Token {
 	l.skipWhitespace()
 	// 跳过注释
 	l.skipComment()
+	// 记录 token 的开始位置
+	start := l.currPosition()
 	// 查看当前字符
 	switch l.ch {
 	// 数学运算符
@@ -57,16 +122,28 @@ func (l *Lexer) NextToken() token.Token {
 		} else {
 			tok = newToken(token.ASSIGN, l.ch)
 		}
+	// 当前字符是 "+"，可能是 "+="
 	case '+':
-		tok = newToken(token.PLUS, l.ch)
+		tok = l.readOperatorAssign(token.PLUS, token.PLUS_ASSIGN)
+	// 当前字符是 "-"，可能是 "-="
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		tok = l.readOperatorAssign(token.MINUS, token.MINUS_ASSIGN)
+	// 当前字符是 "*"，可能是 "**" 或 "*="
 	case '*':
-		tok = newToken(token.MUL, l.ch)
+		if l.peekChar() == '*' {
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.POW, Literal: literal}
+		} else {
+			tok = l.readOperatorAssign(token.MUL, token.MUL_ASSIGN)
+		}
+	// 当前字符是 "/"，可能是 "/="，注释已经在前面跳过了
 	case '/':
-		tok = newToken(token.DIV, l.ch)
+		tok = l.readOperatorAssign(token.DIV, token.DIV_ASSIGN)
+	// 当前字符是 "%"，可能是 "%="
 	case '%':
-		tok = newToken(token.MOD, l.ch)
+		tok = l.readOperatorAssign(token.MOD, token.MOD_ASSIGN)
 
 	// 逻辑运算符
 	// !是取非运算符，之后可能是 "="，构成 "!="
@@ -125,8 +202,24 @@ func (l *Lexer) NextToken() token.Token {
 	case ')':
 		tok = newToken(token.RPAREN, l.ch)
 	case '{':
+		if n := len(l.interpolations); n > 0 {
+			l.interpolations[n-1].braces++
+		}
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
+		// 插值表达式结束，继续读取字符串剩下的部分
+		if n := len(l.interpolations); n > 0 && l.interpolations[n-1].braces == 0 {
+			stringStart := l.interpolations[n-1].start
+			l.interpolations = l.interpolations[:n-1]
+			tok = l.readStringToken(start, stringStart, token.INTERP_MIDDLE, token.INTERP_END)
+			if tok.Type == token.ILLEGAL {
+				return l.withPosition(tok, start)
+			}
+			break
+		}
+		if n := len(l.interpolations); n > 0 {
+			l.interpolations[n-1].braces--
+		}
 		tok = newToken(token.RBRACE, l.ch)
 	case '[':
 		tok = newToken(token.LBRACKET, l.ch)
@@ -138,28 +231,94 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.SEMICOLON, l.ch)
 	case ':':
 		tok = newToken(token.COLON, l.ch)
+	// "." 只能出现在 "..." 中，数字中的 "." 由 readNumber 处理
+	case '.':
+		if l.peekChar() == '.' && l.peekCharAt(2) == '.' {
+			l.readChar()
+			l.readChar()
+			tok = token.Token{Type: token.ELLIPSIS, Literal: "..."}
+		} else if isDigit(l.peekChar()) {
+			// 省略了整数部分的浮点数，如 .5，作为不合法的浮点数
+			return l.withPosition(l.readNumber(), start)
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
+	// "#" 只能出现在元组的开始 "#(" 中
+	case '#':
+		if l.peekChar() == '(' {
+			l.readChar()
+			tok = token.Token{Type: token.TUPLE_START, Literal: "#("}
+		} else {
+			tok = newToken(token.ILLEGAL, l.ch)
+		}
 	case '"':
-		tok = token.Token{Type: token.STRING, Literal: l.readString()}
+		tok = l.readStringToken(start, start, token.INTERP_START, token.STRING)
+		if tok.Type == token.ILLEGAL {
+			return l.withPosition(tok, start)
+		}
+	case '`':
+		if value, ok := l.readRawString(); ok {
+			tok = token.Token{Type: token.STRING, Literal: value}
+		} else {
+			l.addError(UNTERMINATED_STRING, start, "raw string literal not terminated")
+			return l.withPosition(token.Token{Type: token.ILLEGAL, Literal: l.input[start.Offset:l.position]}, start)
+		}
 	// 结束符
 	case END:
+		// 插值表达式没有闭合就到了结尾，说明插值表达式所在的字符串没有闭合，
+		// 在最外层的字符串的开头报告错误，并把整个字符串作为一个非法的 token
+		if len(l.interpolations) > 0 {
+			stringStart := l.interpolations[0].start
+			l.interpolations = nil
+			l.addError(UNTERMINATED_STRING, stringStart, "string literal not terminated")
+			return token.Token{
+				Type:    token.ILLEGAL,
+				Literal: l.input[stringStart.Offset:l.position],
+				Pos:     stringStart,
+				End:     l.currPosition(),
+			}
+		}
 		tok.Literal = ""
 		tok.Type = token.EOF
 	default:
 		if isLetter(l.ch) {
 			tok = l.readIdentifier()
-			return tok
+			return l.withPosition(tok, start)
 		} else if isDigit(l.ch) {
 			tok = l.readNumber()
-			return tok
+			return l.withPosition(tok, start)
 		} else {
-			// 非法此法单元
-			tok = newToken(token.ILLEGAL, l.ch)
+			// 非法此法单元，不合法的 UTF-8 字节使用原始的字节作为字面量
+			tok = token.Token{Type: token.ILLEGAL, Literal: l.input[l.position:l.nextPosition]}
 		}
 	}
-	l.readChar()
+	// EOF 没有长度，开始位置和结束位置相同
+	if tok.Type != token.EOF {
+		l.readChar()
+	}
+	return l.withPosition(tok, start)
+}
+
+// withPosition 设置 token 的开始位置和结束位置，并附加 token 之前的文档注释
+// 结束位置是 token 最后一个字符之后的位置，也就是当前字符的位置
+func (l *Lexer) withPosition(tok token.Token, start token.Position) token.Token {
+	tok.Pos = start
+	tok.End = l.currPosition()
+	tok.Doc = l.doc
+	l.doc = nil
 	return tok
 }
 
+// currPosition 返回当前字符的位置
+func (l *Lexer) currPosition() token.Position {
+	return token.Position{
+		Filename: l.filename,
+		Offset:   l.position,
+		Line:     l.line,
+		Column:   l.column,
+	}
+}
+
 // skipWhitespace 跳过输入中的空白字符
 // 该函数通过移动读取指针，跳过如空格、制表符、换行符和回车符等空白字符
 func (l *Lexer) skipWhitespace() {
@@ -170,41 +329,101 @@ func (l *Lexer) skipWhitespace() {
 }
 
 // skipComment 跳过源代码中的注释，支持中文注释
-// 该函数识别并跳过单行注释（以'//'开头）
+// 该函数识别并跳过单行注释（以'//'开头）和块注释（/* ... */，可以嵌套）
 // 对于单行注释，它会跳到行末。
+// 以 /// 开头的单行注释是文档注释，会被保存下来，附加在之后的第一个 token 上
 func (l *Lexer) skipComment() {
-	// 跳过单行注释
-	for l.ch == '/' && l.peekChar() == '/' {
-		// 读取并跳过两个'/'
-		l.readChar()
-		l.readChar()
-		// 跳过注释内容直到行末或文件结束
-		for l.ch == '\r' || l.ch != '\n' {
-			// 防止EOF时无限循环
-			if l.ch == END {
-				break
-			}
-			l.readChar()
+	for l.ch == '/' && (l.peekChar() == '/' || l.peekChar() == '*') {
+		if l.peekChar() == '*' {
+			l.skipBlockComment()
+		} else {
+			l.skipLineComment()
 		}
 		// 跳过空白字符符
 		l.skipWhitespace()
 	}
 }
 
+// skipLineComment 跳过单行注释直到行末或文件结束，文档注释保存到 l.doc 中
+// 开始时当前字符是注释开头的 /
+func (l *Lexer) skipLineComment() {
+	start := l.currPosition()
+	// //// 开头的注释是普通注释，常用作分隔线
+	isDoc := l.peekCharAt(2) == '/' && l.peekCharAt(3) != '/'
+	for l.ch != '\n' && l.ch != END {
+		l.readChar()
+	}
+	if !isDoc {
+		return
+	}
+	if l.doc == nil {
+		l.doc = &token.CommentGroup{}
+	}
+	text := strings.TrimSuffix(l.input[start.Offset:l.position], "\r")
+	l.doc.List = append(l.doc.List, &token.Comment{Pos: start, Text: text})
+}
+
+// skipBlockComment 跳过块注释，块注释可以嵌套，如 /* a /* b */ c */
+// 开始时当前字符是注释开头的 /，没有闭合时记录词法错误并跳到文件结束
+func (l *Lexer) skipBlockComment() {
+	start := l.currPosition()
+	depth := 0
+	for l.ch != END {
+		switch {
+		case l.ch == '/' && l.peekChar() == '*':
+			depth++
+			l.readChar()
+		case l.ch == '*' && l.peekChar() == '/':
+			depth--
+			l.readChar()
+		}
+		l.readChar()
+		if depth == 0 {
+			return
+		}
+	}
+	l.addError(UNTERMINATED_COMMENT, start, "block comment not terminated")
+}
+
 // readChar 读取当前字符并为下一个字符做准备
 // 如果当前读取位置已经到达或超过输入字符串的长度，则将当前字符标记为END
-// 否则，将当前位置的字符赋值给当前字符变量ch
+// 否则，将当前位置按 UTF-8 解码出的字符赋值给当前字符变量ch
 // 随后，将当前位置指针移动到下一个字符位置，准备下一次读取
+// 不合法的 UTF-8 字节解码为 utf8.RuneError，占一个字节，并记录词法错误
 func (l *Lexer) readChar() {
-	if l.nextPosition >= len(l.input) {
-		l.ch = END
-	} else {
-		l.ch = (l.input[l.nextPosition])
+	// 上一个字符是换行符时，进入下一行
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	// 已经到达输入末尾时，位置不再变化
+	if l.position < len(l.input) || l.column == 0 {
+		l.column++
 	}
 	// 注意这里：每次读取字符后，将当前位置指针移动到下一个字符位置
 	// 也就是说读取完一个字符之后，再次读取的话，会读取到下一个字符
 	l.position = l.nextPosition
-	l.nextPosition++
+	if l.position >= len(l.input) {
+		l.ch = END
+		l.nextPosition = l.position + 1
+		return
+	}
+	ch, width := utf8.DecodeRuneInString(l.input[l.position:])
+	l.ch = ch
+	l.nextPosition = l.position + width
+	if ch == utf8.RuneError && width == 1 {
+		l.addInvalidUTF8Error()
+	}
+}
+
+// addInvalidUTF8Error 记录当前字节不是合法的 UTF-8 编码
+func (l *Lexer) addInvalidUTF8Error() {
+	start := l.currPosition()
+	end := start
+	end.Offset++
+	end.Column++
+	message := fmt.Sprintf("invalid UTF-8 encoding: byte %#x", l.input[l.position])
+	l.errors = append(l.errors, Error{Kind: INVALID_UTF8, Pos: start, End: end, Message: message})
 }
 
 // peekChar 返回当前位置之后的一个字符，而不改变当前的位置。
@@ -212,16 +431,37 @@ func (l *Lexer) readChar() {
 // 如果下一个位置超出了输入字符串的长度，则返回END标志。
 // 返回值:
 //
-// byte 类型的字符，表示当前位置之后的字符或是END。
-func (l *Lexer) peekChar() byte {
-	// 检查当前位置之后的位置是否超出了输入字符串的长度
-	if l.nextPosition >= len(l.input) {
-		// 如果超出，返回END标志
+// rune 类型的字符，表示当前位置之后的字符或是END。
+func (l *Lexer) peekChar() rune {
+	return l.peekCharAt(1)
+}
+
+// peekCharAt 返回当前位置之后第 n 个字符，但不移动读取位置
+// peekCharAt(1) 和 peekChar() 相同，超出输入的长度时返回END标志
+func (l *Lexer) peekCharAt(n int) rune {
+	offset := l.nextPosition
+	for ; n > 1 && offset < len(l.input); n-- {
+		_, width := utf8.DecodeRuneInString(l.input[offset:])
+		offset += width
+	}
+	if offset >= len(l.input) {
 		return END
-	} else {
-		// 如果没有超出，将当前位置的字符返回
-		return l.input[l.nextPosition]
 	}
+	ch, _ := utf8.DecodeRuneInString(l.input[offset:])
+	return ch
+}
+
+// readOperatorAssign 读取一个可能和 "=" 组成复合赋值运算符的运算符。
+// 下一个字符是 "=" 时返回 assignType 类型的 token，如 "+="，
+// 否则返回 operatorType 类型的 token，如 "+"。
+func (l *Lexer) readOperatorAssign(operatorType, assignType token.TokenType) token.Token {
+	if l.peekChar() == '=' {
+		ch := l.ch
+		l.readChar()
+		literal := string(ch) + string(l.ch)
+		return token.Token{Type: assignType, Literal: literal}
+	}
+	return newToken(operatorType, l.ch)
 }
 
 // newToken 创建并返回一个新的 token.Token 实例。
@@ -236,48 +476,207 @@ func (l *Lexer) peekChar() byte {
 // 返回值:
 //
 //	返回一个token.Token结构体，其中包含了传入的类型和字符。
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{
 		Type:    tokenType,
 		Literal: string(ch),
 	}
 }
 
-// readString 读取一个字符串字面量。
-// 该方法从当前字符位置开始，读取直到遇到结束引号 '"' 或输入结束标志 END。
-// 它主要用于解析字符串常量，跳过引号内的内容，并返回解析的字符串。
-// 该方法不处理转义字符或其他复杂的字符串内部逻辑。
-func (l *Lexer) readString() string {
-	// 记录字符串开始的位置，跳过开头的引号。
-	position := l.position + 1
-	for {
-		// 逐字符读取输入。
+// readStringToken 读取字符串中从当前字符之后开始的一段，返回对应的 token
+// 开始时当前字符是开头的引号或插值表达式结尾的 }
+// 这一段以 ${ 结束时返回 interpolationType 类型的 token，并开始读取插值表达式，
+// 以引号结束时返回 closedType 类型的 token，没有闭合时返回 ILLEGAL token，字面量是源码中的原始内容
+// stringStart 是整个字符串开头的引号的位置，记录在插值表达式中
+// 字符串 "a ${b} c ${d} e" 依次返回 INTERP_START "a "、b、INTERP_MIDDLE " c "、d、INTERP_END " e"
+func (l *Lexer) readStringToken(start, stringStart token.Position, interpolationType, closedType token.TokenType) token.Token {
+	value, end := l.readString()
+	switch end {
+	case '"':
+		return token.Token{Type: closedType, Literal: value}
+	case '{':
+		l.interpolations = append(l.interpolations, interpolation{start: stringStart})
+		return token.Token{Type: interpolationType, Literal: value}
+	default:
+		l.addError(UNTERMINATED_STRING, start, "string literal not terminated")
+		return token.Token{Type: token.ILLEGAL, Literal: l.input[start.Offset:l.position]}
+	}
+}
+
+// readString 读取一个字符串字面量，返回转义后的字符串内容和结束时的当前字符
+// 开始时当前字符是开头的引号，字符串闭合时结束在结尾的引号上，
+// 遇到插值表达式的开始 ${ 时结束在 { 上，
+// 否则结束在换行符或 END 上，字符串不能跨行，多行的字符串使用反引号
+// 支持的转义序列有 \n、\t、\r、\\、\"、\$、\xNN 和 \u{...}，不合法的转义序列原样保留
+func (l *Lexer) readString() (string, rune) {
+	var out strings.Builder
+	l.readChar()
+	for l.ch != '"' {
+		if l.ch == END || l.ch == '\n' {
+			return out.String(), l.ch
+		}
+		if l.ch == '$' && l.peekChar() == '{' {
+			l.readChar()
+			return out.String(), l.ch
+		}
+		if l.ch == '\\' {
+			l.readEscape(&out)
+		} else {
+			l.writeChar(&out)
+		}
 		l.readChar()
-		// 遇到结束引号或输入结束标志时停止读取。
-		if l.ch == '"' || l.ch == END {
-			break
+	}
+	return out.String(), l.ch
+}
+
+// readEscape 读取一个转义序列，并把转义后的字符写入 out
+// 开始时当前字符是反斜杠，结束时当前字符是转义序列的最后一个字符
+func (l *Lexer) readEscape(out *strings.Builder) {
+	start := l.currPosition()
+	// 反斜杠在行尾时，由 readString 报告字符串没有闭合
+	if next := l.peekChar(); next == END || next == '\n' {
+		out.WriteByte('\\')
+		return
+	}
+	l.readChar()
+
+	switch l.ch {
+	case 'n':
+		out.WriteByte('\n')
+	case 't':
+		out.WriteByte('\t')
+	case 'r':
+		out.WriteByte('\r')
+	case '\\', '"', '$':
+		out.WriteRune(l.ch)
+	case 'x':
+		// \xNN，两位十六进制数表示的字节
+		if isHexDigit(l.peekCharAt(1)) && isHexDigit(l.peekCharAt(2)) {
+			l.readChar()
+			high := hexValue(l.ch)
+			l.readChar()
+			out.WriteByte(byte(high<<4 | hexValue(l.ch)))
+			return
+		}
+		l.addEscapeError(start, `invalid escape sequence \x: expected two hex digits`)
+		out.WriteString(`\x`)
+	case 'u':
+		l.readUnicodeEscape(out, start)
+	default:
+		l.addEscapeError(start, "unknown escape sequence \\"+string(l.ch))
+		out.WriteByte('\\')
+		l.writeChar(out)
+	}
+}
+
+// readUnicodeEscape 读取 \u{...} 转义序列，花括号中是 1 到 6 位十六进制数表示的码点
+// 开始时当前字符是 u，合法时结束在 } 上
+func (l *Lexer) readUnicodeEscape(out *strings.Builder, start token.Position) {
+	n := 0
+	if l.peekChar() == '{' {
+		n = 2
+		for isHexDigit(l.peekCharAt(n)) {
+			n++
 		}
 	}
-	// 返回读取到的字符串内容，不包括引号。
-	// 注意：这里的[]是左闭右开的
-	return l.input[position:l.position]
+	if n < 3 || n > 8 || l.peekCharAt(n) != '}' {
+		l.addEscapeError(start, `invalid escape sequence \u: expected \u{...} with 1 to 6 hex digits`)
+		out.WriteString(`\u`)
+		return
+	}
+
+	var value rune
+	l.readChar()
+	for i := 2; i < n; i++ {
+		l.readChar()
+		value = value<<4 | rune(hexValue(l.ch))
+	}
+	l.readChar()
+	if !utf8.ValidRune(value) {
+		l.addEscapeError(start, "escape sequence is invalid Unicode code point "+l.input[start.Offset:l.position+1])
+		out.WriteString(l.input[start.Offset : l.position+1])
+		return
+	}
+	out.WriteRune(value)
+}
+
+// readRawString 读取一个反引号括起来的原始字符串，返回字符串内容和字符串是否闭合
+// 原始字符串可以跨行，其中的反斜杠没有特殊含义，回车符会被删除，和 Go 的原始字符串相同
+func (l *Lexer) readRawString() (string, bool) {
+	var out strings.Builder
+	l.readChar()
+	for l.ch != '`' {
+		if l.ch == END {
+			return out.String(), false
+		}
+		if l.ch != '\r' {
+			l.writeChar(&out)
+		}
+		l.readChar()
+	}
+	return out.String(), true
+}
+
+// writeChar 把当前字符写入 out，不合法的 UTF-8 字节原样写入
+func (l *Lexer) writeChar(out *strings.Builder) {
+	out.WriteString(l.input[l.position:l.nextPosition])
+}
+
+// isHexDigit 判断字符是否是十六进制数字
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+// hexValue 返回十六进制数字的值
+func hexValue(ch rune) int {
+	switch {
+	case isDigit(ch):
+		return int(ch - '0')
+	case 'a' <= ch && ch <= 'f':
+		return int(ch-'a') + 10
+	default:
+		return int(ch-'A') + 10
+	}
+}
+
+// addEscapeError 记录不合法的转义序列，结束位置是当前字符之后的位置
+func (l *Lexer) addEscapeError(start token.Position, message string) {
+	end := l.currPosition()
+	end.Offset = l.nextPosition
+	end.Column++
+	l.errors = append(l.errors, Error{Kind: INVALID_ESCAPE, Pos: start, End: end, Message: message})
+}
+
+// addError 记录词法错误，结束位置是当前字符的位置
+func (l *Lexer) addError(kind ErrorKind, start token.Position, message string) {
+	l.errors = append(l.errors, Error{Kind: kind, Pos: start, End: l.currPosition(), Message: message})
 }
 
-// isLetter 判断给定的字符是否为字母或下划线。
+// Errors 返回目前为止产生的词法错误
+func (l *Lexer) Errors() []Error {
+	return l.errors
+}
+
+// isLetter 判断给定的字符是否为字母或下划线，字母包括 Unicode 字母，如中文。
 // 参数:
 //
-//	ch byte: 需要判断的字符。
+//	ch rune: 需要判断的字符。
 //
 // 返回值:
 //
 //	bool: 如果字符是字母或下划线，则返回true，否则返回false。
-func isLetter(ch byte) bool {
+func isLetter(ch rune) bool {
 	// 判断字符是否在'a'到'z'、'A'到'Z'的范围内，或者是否为下划线。
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+	if 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' {
+		return true
+	}
+	// 非 ASCII 字符按 Unicode 判断，utf8.RuneError 不是字母
+	return ch >= utf8.RuneSelf && ch != utf8.RuneError && unicode.IsLetter(ch)
 }
 
 // readIdentifier 读取一个标识符或关键字
-// 该方法从当前的字符开始，读取一系列连续的字母或下划线，直到遇到非字母且非下划线的字符为止
+// 该方法从当前的字符开始，读取一系列连续的字母、数字或下划线，直到遇到其他字符为止
+// 第一个字符之后可以是 Unicode 数字，如全角数字
 // 返回读取到的标识符或关键字字符串
 func (l *Lexer) readIdentifier() token.Token {
 	// 记录当前的位置，作为标识符的起始点
@@ -288,7 +687,7 @@ func (l *Lexer) readIdentifier() token.Token {
 	}
 	// 读取当前的字母或下划线
 	l.readChar()
-	for isLetter(l.ch) || isDigit(l.ch) {
+	for isLetter(l.ch) || unicode.IsDigit(l.ch) {
 		l.readChar()
 	}
 	// 这里不是字母，下划线和数字，如果不是分隔符或运算符，则是非法标识符
@@ -307,84 +706,118 @@ func (l *Lexer) readIdentifier() token.Token {
 	}
 }
 
-// isDigit 检查一个字符是否为数字。
+// isDigit 检查一个字符是否为数字。数字字面量只能由 ASCII 数字组成。
 // 参数:
 //
-//	ch byte: 需要检查的字符。
+//	ch rune: 需要检查的字符。
 //
 // 返回值:
 //
 //	bool: 如果字符是数字（0-9），则返回 true，否则返回 false。
-func isDigit(ch byte) bool {
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
 // readNumber 读取一个数字，可能是整数、浮点数或非法的数字
-// 该函数通过读取字符来构建数字的字符串表示，并根据内容决定数字的类型
+// 支持的格式：
+//
+//	123、1_000_000                十进制整数，下划线用于分隔数字
+//	0xFF、0o17、0b1010            十六进制、八进制、二进制整数
+//	1.5、1.5e-3、2E10             浮点数，可以使用科学计数法
+//
+// 数字之后可以紧跟运算符，如 1+2；紧跟字母、数字或 . 时整体是非法的数字，如 1abc、1.5.2
+// 下划线的位置、进制前缀之后的数字是否合法以及数值是否溢出由语法分析器检查
 func (l *Lexer) readNumber() token.Token {
 	// 记录数字开始的位置
+	start := l.currPosition()
 	position := l.position
-	// 是数字就一直读取
-	for isDigit(l.ch) {
+	tokenType := token.INT
+	valid := true
+	if l.ch == '0' && isBasePrefix(l.peekChar()) {
+		// 跳过进制前缀，十六进制数字包含了所有进制的数字，
+		// 数字不符合进制时由语法分析器报告错误
 		l.readChar()
-	}
-	if l.ch == '.' {
 		l.readChar()
-		if !isDigit(l.ch) {
-			l.skipNotEndSeparator()
-			return token.Token{
-				Type:    token.ILLEGAL,
-				Literal: l.input[position:l.position],
-			}
+		for isHexDigit(l.ch) || l.ch == '_' {
+			l.readChar()
 		}
-		for isDigit(l.ch) {
+	} else {
+		l.readDecimalDigits()
+		// 小数点前后都必须是数字，1. 和 .5 都不合法
+		if l.ch == '.' && l.peekChar() != '.' {
+			tokenType = token.FLOAT
+			valid = position != l.position && isDigit(l.peekChar())
 			l.readChar()
+			l.readDecimalDigits()
 		}
-		if !isEndSeparator(l.ch) {
-			l.skipNotEndSeparator()
-			return token.Token{
-				Type:    token.ILLEGAL,
-				Literal: l.input[position:l.position],
+		// 指数部分，如 e10、e-3、E+5，e 之后必须有数字
+		if l.ch == 'e' || l.ch == 'E' {
+			tokenType = token.FLOAT
+			l.readChar()
+			if l.ch == '+' || l.ch == '-' {
+				l.readChar()
+			}
+			if !isDigit(l.ch) {
+				valid = false
 			}
+			l.readDecimalDigits()
 		}
-		return token.Token{
-			Type:    token.FLOAT,
-			Literal: l.input[position:l.position],
+	}
+	// 数字之后紧跟字母、数字或 . 时，一直读到它们结束，整体作为非法的数字
+	if isLetter(l.ch) || unicode.IsDigit(l.ch) || l.ch == '.' {
+		for isLetter(l.ch) || unicode.IsDigit(l.ch) || l.ch == '.' {
+			l.readChar()
 		}
-	} else if !isEndSeparator(l.ch) {
-		l.skipNotEndSeparator()
-		return token.Token{
-			Type:    token.ILLEGAL,
-			Literal: l.input[position:l.position],
+		valid = false
+	}
+	literal := l.input[position:l.position]
+	if !valid {
+		if tokenType == token.FLOAT {
+			l.addError(INVALID_FLOAT, start, "invalid float literal "+literal)
+		} else {
+			l.addError(INVALID_INTEGER, start, "invalid integer literal "+literal)
 		}
+		tokenType = token.ILLEGAL
 	}
 	return token.Token{
-		Type:    token.INT,
-		Literal: l.input[position:l.position],
+		Type:    tokenType,
+		Literal: literal,
+	}
+}
+
+// readDecimalDigits 读取连续的十进制数字和下划线
+func (l *Lexer) readDecimalDigits() {
+	for isDigit(l.ch) || l.ch == '_' {
+		l.readChar()
 	}
 }
 
+// isBasePrefix 判断 0 之后的字符是否是进制前缀，x 表示十六进制，o 表示八进制，b 表示二进制
+func isBasePrefix(ch rune) bool {
+	return ch == 'x' || ch == 'X' || ch == 'o' || ch == 'O' || ch == 'b' || ch == 'B'
+}
+
 // isEndSeparator 是否是结束分隔符
 // 参数：
 //
-//	ch byte: 需要判断的字符
-func isEndSeparator(ch byte) bool {
+//	ch rune: 需要判断的字符
+func isEndSeparator(ch rune) bool {
 	return ch == ')' || ch == '}' || ch == ']' || ch == ',' || ch == ';' || ch == ':' || ch == ' ' || ch == END || ch == '\n'
 }
 
 // isSeparator 是否是分隔符
 // 参数：
 //
-//	ch byte: 需要判断的字符
-func isSeparator(ch byte) bool {
+//	ch rune: 需要判断的字符
+func isSeparator(ch rune) bool {
 	return isEndSeparator(ch) || ch == '(' || ch == '{' || ch == '['
 }
 
 // isOperator 是否是运算符
 // 参数：
 //
-//	ch byte: 需要判断的字符
-func isOperator(ch byte) bool {
+//	ch rune: 需要判断的字符
+func isOperator(ch rune) bool {
 	return ch == '=' || ch == '+' || ch == '-' || ch == '*' || ch == '/' || ch == '%' || ch == '!' || ch == '&' || ch == '|' || ch == '<' || ch == '>'
 }
 
@@ -4,6 +4,7 @@ import (
 	"holiya/token"
 	"reflect"
 	"testing"
+	"unicode/utf8"
 )
 
 // TestNew 测试 New 函数
@@ -15,6 +16,8 @@ func TestNew(t *testing.T) {
 		position:     0,
 		nextPosition: 1,
 		ch:           0, // EOF
+		line:         1,
+		column:       1,
 	}
 	if !reflect.DeepEqual(l, expected) {
 		t.Errorf("Expected %+v, got %+v", expected, l)
@@ -27,6 +30,8 @@ func TestNew(t *testing.T) {
 		position:     0,
 		nextPosition: 1,
 		ch:           'a',
+		line:         1,
+		column:       1,
 	}
 	if !reflect.DeepEqual(l, expected) {
 		t.Errorf("Expected %+v, got %+v", expected, l)
@@ -39,6 +44,8 @@ func TestNew(t *testing.T) {
 		position:     0,
 		nextPosition: 1,
 		ch:           'a',
+		line:         1,
+		column:       1,
 	}
 	if !reflect.DeepEqual(l, expected) {
 		t.Errorf("Expected %+v, got %+v", expected, l)
@@ -71,7 +78,11 @@ if (5 < 10) {
 10 == 10;
 10 != 9;
 >= 10.5 [ ] && || <= : % "hello world"
+2 ** 3;
+x += 1 -= 2 *= 3 /= 4 %= 5;
+...rest .
 & 
+#(1, x) # (
 `
 	tests := []struct {
 		expectedType    token.TokenType
@@ -160,7 +171,33 @@ if (5 < 10) {
 		{token.COLON, ":"},
 		{token.MOD, "%"},
 		{token.STRING, "hello world"},
+		{token.INT, "2"},
+		{token.POW, "**"},
+		{token.INT, "3"},
+		{token.SEMICOLON, ";"},
+		{token.IDENTIFIER, "x"},
+		{token.PLUS_ASSIGN, "+="},
+		{token.INT, "1"},
+		{token.MINUS_ASSIGN, "-="},
+		{token.INT, "2"},
+		{token.MUL_ASSIGN, "*="},
+		{token.INT, "3"},
+		{token.DIV_ASSIGN, "/="},
+		{token.INT, "4"},
+		{token.MOD_ASSIGN, "%="},
+		{token.INT, "5"},
+		{token.SEMICOLON, ";"},
+		{token.ELLIPSIS, "..."},
+		{token.IDENTIFIER, "rest"},
+		{token.ILLEGAL, "."},
 		{token.ILLEGAL, "&"},
+		{token.TUPLE_START, "#("},
+		{token.INT, "1"},
+		{token.COMMA, ","},
+		{token.IDENTIFIER, "x"},
+		{token.RPAREN, ")"},
+		{token.ILLEGAL, "#"},
+		{token.LPAREN, "("},
 		{token.EOF, ""},
 	}
 
@@ -185,7 +222,7 @@ func TestSkipWhitespace(t *testing.T) {
 		input                string
 		expectedPosition     int
 		expectedNextPosition int
-		expectedChar         byte
+		expectedChar         rune
 	}{
 		{
 			// 多个空格后接 'a'
@@ -239,7 +276,7 @@ func TestSkipWhitespace(t *testing.T) {
 func TestSkipComment(t *testing.T) {
 	tests := []struct {
 		input        string
-		expectedChar byte
+		expectedChar rune
 	}{
 		{
 			// 单行注释到行尾
@@ -266,6 +303,26 @@ func TestSkipComment(t *testing.T) {
 			input:        "//    注释    \ny",
 			expectedChar: 'y',
 		},
+		{
+			// 块注释
+			input:        "/* 注释 */ x",
+			expectedChar: 'x',
+		},
+		{
+			// 嵌套的块注释
+			input:        "/* a /* b */ c */y",
+			expectedChar: 'y',
+		},
+		{
+			// 块注释跨行，之后是单行注释
+			input:        "/*\n * 多行\n */\n// 注释\nz",
+			expectedChar: 'z',
+		},
+		{
+			// 没有闭合的块注释跳到文件末尾
+			input:        "/* a /* b */",
+			expectedChar: END,
+		},
 	}
 
 	for i, tt := range tests {
@@ -288,7 +345,7 @@ func TestReadChar(t *testing.T) {
 
 	// 测试用例 2: 单个字符输入
 	l = New("a")
-	expected := byte('a')
+	expected := rune('a')
 	if l.ch != expected {
 		t.Errorf("Expected ch to be '%c', got '%c'", expected, l.ch)
 	}
@@ -334,7 +391,7 @@ func TestPeekChar(t *testing.T) {
 	tests := []struct {
 		input           string
 		initialPosition int
-		expectedPeek    byte
+		expectedPeek    rune
 	}{
 		{
 			// 空输入，nextPosition 超出范围
@@ -386,7 +443,7 @@ func TestPeekChar(t *testing.T) {
 func TestNewToken(t *testing.T) {
 	tests := []struct {
 		tokenType token.TokenType
-		ch        byte
+		ch        rune
 		expected  token.Token
 	}{
 		// EOF
@@ -444,13 +501,16 @@ func TestReadString(t *testing.T) {
 	tests := []struct {
 		input           string
 		expectedLiteral string
+		// 期望字符串是否闭合
+		expectedOK bool
 		// 期望读取结束后当前字符（应为结束引号后的字符或 EOF）
-		expectedChar byte
+		expectedChar rune
 	}{
 		{
 			// 正常字符串
 			input:           `"hello world"`,
 			expectedLiteral: "hello world",
+			expectedOK:      true,
 			// 读完字符串后应到达 EOF
 			expectedChar: END,
 		},
@@ -458,6 +518,7 @@ func TestReadString(t *testing.T) {
 			// 空字符串
 			input:           `""`,
 			expectedLiteral: "",
+			expectedOK:      true,
 			// 读完空字符串后应到达 EOF
 			expectedChar: END,
 		},
@@ -465,12 +526,14 @@ func TestReadString(t *testing.T) {
 			// 包含特殊字符的字符串
 			input:           `"abc!@#$%^&*()"`,
 			expectedLiteral: "abc!@#$%^&*()",
+			expectedOK:      true,
 			expectedChar:    END,
 		},
 		{
 			// 字符串后跟其他字符
 			input:           `"test"abc`,
 			expectedLiteral: "test",
+			expectedOK:      true,
 			// 读完字符串后当前字符应为 'a'
 			expectedChar: 'a',
 		},
@@ -479,24 +542,80 @@ func TestReadString(t *testing.T) {
 			input: `"unclosed`,
 			// 应读取到 EOF 前的内容
 			expectedLiteral: "unclosed",
+			expectedOK:      false,
 			// 当前字符应为 EOF
 			expectedChar: END,
 		},
+		{
+			// 字符串不能跨行，换行符之前没有闭合的字符串是未闭合的
+			input:           "\"line\nx\"",
+			expectedLiteral: "line",
+			expectedOK:      false,
+			// 结束在换行符上，之后的字符是 'x'
+			expectedChar: 'x',
+		},
 		{
 			// 多个连续字符串
 			input:           `"foo" "bar"`,
 			expectedLiteral: "foo",
+			expectedOK:      true,
 			// 第一次读完字符串后当前字符应为 ' '
 			expectedChar: ' ',
 		},
+		{
+			// 转义序列
+			input:           `"a\"b\\c\n\t\r"`,
+			expectedLiteral: "a\"b\\c\n\t\r",
+			expectedOK:      true,
+			expectedChar:    END,
+		},
+		{
+			// 十六进制和 Unicode 转义序列
+			input:           `"\x41\u{4e2d}\u{1F600}"`,
+			expectedLiteral: "A中😀",
+			expectedOK:      true,
+			expectedChar:    END,
+		},
+		{
+			// 不合法的转义序列原样保留
+			input:           `"\q\x4\u{}"`,
+			expectedLiteral: `\q\x4\u{}`,
+			expectedOK:      true,
+			expectedChar:    END,
+		},
+		{
+			// 转义的引号不会结束字符串
+			input:           `"\"`,
+			expectedLiteral: `"`,
+			expectedOK:      false,
+			expectedChar:    END,
+		},
+		{
+			// 插值表达式的开始，结束在 { 上
+			input:           `"a ${b}"`,
+			expectedLiteral: "a ",
+			expectedOK:      false,
+			expectedChar:    'b',
+		},
+		{
+			// 转义的 $ 和单独的 $ 不会开始插值表达式
+			input:           `"\${a} $b"`,
+			expectedLiteral: "${a} $b",
+			expectedOK:      true,
+			expectedChar:    END,
+		},
 	}
 
 	for i, tt := range tests {
 		l := New(tt.input)
-		str := l.readString()
+		str, end := l.readString()
+		ok := end == '"'
 		if str != tt.expectedLiteral {
 			t.Errorf("Test case %d failed. Expected literal=%q, got=%q", i, tt.expectedLiteral, str)
 		}
+		if ok != tt.expectedOK {
+			t.Errorf("Test case %d failed. Expected ok=%t, got=%t", i, tt.expectedOK, ok)
+		}
 		l.readChar()
 		if l.ch != tt.expectedChar {
 			t.Errorf("Test case %d failed. Expected current char='%c', got='%c'", i, tt.expectedChar, l.ch)
@@ -504,72 +623,265 @@ func TestReadString(t *testing.T) {
 	}
 }
 
+// TestReadRawString 测试原始字符串的读取
+func TestReadRawString(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedLiteral string
+		expectedOK      bool
+	}{
+		{"`hello`", "hello", true},
+		{"`a\\n\\\"b`", `a\n\"b`, true},
+		{"`line1\r\nline2`", "line1\nline2", true},
+		{"`unclosed\nstill", "unclosed\nstill", false},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+		str, ok := l.readRawString()
+		if str != tt.expectedLiteral {
+			t.Errorf("Test case %d failed. Expected literal=%q, got=%q", i, tt.expectedLiteral, str)
+		}
+		if ok != tt.expectedOK {
+			t.Errorf("Test case %d failed. Expected ok=%t, got=%t", i, tt.expectedOK, ok)
+		}
+	}
+}
+
+// TestStringErrors 测试字符串的词法错误
+func TestStringErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedTypes []token.TokenType
+		expectedKind  ErrorKind
+		expectedPos   token.Position
+		expectedEnd   token.Position
+		expectedMsg   string
+	}{
+		{
+			"x = \"abc\ny",
+			[]token.TokenType{token.IDENTIFIER, token.ASSIGN, token.ILLEGAL, token.IDENTIFIER, token.EOF},
+			UNTERMINATED_STRING,
+			token.Position{Offset: 4, Line: 1, Column: 5},
+			token.Position{Offset: 8, Line: 1, Column: 9},
+			"string literal not terminated",
+		},
+		{
+			"`abc\n",
+			[]token.TokenType{token.ILLEGAL, token.EOF},
+			UNTERMINATED_STRING,
+			token.Position{Offset: 0, Line: 1, Column: 1},
+			token.Position{Offset: 5, Line: 2, Column: 1},
+			"raw string literal not terminated",
+		},
+		{
+			// 插值表达式没有闭合就到了结尾，整个字符串是一个非法的 token
+			"x = \"a ${b} c ${ {d",
+			[]token.TokenType{token.IDENTIFIER, token.ASSIGN, token.INTERP_START, token.IDENTIFIER, token.INTERP_MIDDLE, token.LBRACE, token.IDENTIFIER, token.ILLEGAL, token.EOF},
+			UNTERMINATED_STRING,
+			token.Position{Offset: 4, Line: 1, Column: 5},
+			token.Position{Offset: 19, Line: 1, Column: 20},
+			"string literal not terminated",
+		},
+		{
+			`"a\qb"`,
+			[]token.TokenType{token.STRING, token.EOF},
+			INVALID_ESCAPE,
+			token.Position{Offset: 2, Line: 1, Column: 3},
+			token.Position{Offset: 4, Line: 1, Column: 5},
+			`unknown escape sequence \q`,
+		},
+		{
+			`"\u{110000}"`,
+			[]token.TokenType{token.STRING, token.EOF},
+			INVALID_ESCAPE,
+			token.Position{Offset: 1, Line: 1, Column: 2},
+			token.Position{Offset: 11, Line: 1, Column: 12},
+			`escape sequence is invalid Unicode code point \u{110000}`,
+		},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+		for j, expectedType := range tt.expectedTypes {
+			tok := l.NextToken()
+			if tok.Type != expectedType {
+				t.Errorf("Test case %d, token %d: expected type %s, got %s (%q)", i, j, expectedType, tok.Type, tok.Literal)
+			}
+		}
+		if len(l.Errors()) != 1 {
+			t.Fatalf("Test case %d: expected 1 error, got %d", i, len(l.Errors()))
+		}
+		err := l.Errors()[0]
+		if err.Kind != tt.expectedKind || err.Pos != tt.expectedPos || err.End != tt.expectedEnd || err.Message != tt.expectedMsg {
+			t.Errorf("Test case %d: wrong error %+v", i, err)
+		}
+	}
+}
+
+// TestStringInterpolation 测试插值字符串被拆分成多个 token
+func TestStringInterpolation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []token.Token
+	}{
+		{
+			`"hello ${name}!"`,
+			[]token.Token{
+				{Type: token.INTERP_START, Literal: "hello "},
+				{Type: token.IDENTIFIER, Literal: "name"},
+				{Type: token.INTERP_END, Literal: "!"},
+			},
+		},
+		{
+			`"${a}-${len(b)}"`,
+			[]token.Token{
+				{Type: token.INTERP_START, Literal: ""},
+				{Type: token.IDENTIFIER, Literal: "a"},
+				{Type: token.INTERP_MIDDLE, Literal: "-"},
+				{Type: token.IDENTIFIER, Literal: "len"},
+				{Type: token.LPAREN, Literal: "("},
+				{Type: token.IDENTIFIER, Literal: "b"},
+				{Type: token.RPAREN, Literal: ")"},
+				{Type: token.INTERP_END, Literal: ""},
+			},
+		},
+		{
+			// 插值表达式中的哈希字面量和嵌套的插值字符串
+			`"${ {1: "x${y}"}[1] }\n"`,
+			[]token.Token{
+				{Type: token.INTERP_START, Literal: ""},
+				{Type: token.LBRACE, Literal: "{"},
+				{Type: token.INT, Literal: "1"},
+				{Type: token.COLON, Literal: ":"},
+				{Type: token.INTERP_START, Literal: "x"},
+				{Type: token.IDENTIFIER, Literal: "y"},
+				{Type: token.INTERP_END, Literal: ""},
+				{Type: token.RBRACE, Literal: "}"},
+				{Type: token.LBRACKET, Literal: "["},
+				{Type: token.INT, Literal: "1"},
+				{Type: token.RBRACKET, Literal: "]"},
+				{Type: token.INTERP_END, Literal: "\n"},
+			},
+		},
+		{
+			// 插值表达式之后的部分没有闭合
+			"\"a ${b} c\nd",
+			[]token.Token{
+				{Type: token.INTERP_START, Literal: "a "},
+				{Type: token.IDENTIFIER, Literal: "b"},
+				{Type: token.ILLEGAL, Literal: "} c"},
+				{Type: token.IDENTIFIER, Literal: "d"},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+		for j, expected := range tt.expected {
+			tok := l.NextToken()
+			if tok.Type != expected.Type || tok.Literal != expected.Literal {
+				t.Fatalf("tests[%d] token %d wrong. expected=%s %q, got=%s %q", i, j, expected.Type, expected.Literal, tok.Type, tok.Literal)
+			}
+		}
+		if tok := l.NextToken(); tok.Type != token.EOF {
+			t.Fatalf("tests[%d] expected EOF, got=%s %q", i, tok.Type, tok.Literal)
+		}
+	}
+}
+
 // TestIsLetter 测试 isLetter 函数
 func TestIsLetter(t *testing.T) {
 	// 生成所有小写字母和大写字母的测试用例
 	var tests []struct {
-		ch       byte
+		ch       rune
 		expected bool
 	}
 
 	// 添加 a-z 小写字母
 	for ch := 'a'; ch <= 'z'; ch++ {
 		tests = append(tests, struct {
-			ch       byte
+			ch       rune
 			expected bool
-		}{byte(ch), true})
+		}{ch, true})
 	}
 
 	// 添加 A-Z 大写字母
 	for ch := 'A'; ch <= 'Z'; ch++ {
 		tests = append(tests, struct {
-			ch       byte
+			ch       rune
 			expected bool
-		}{byte(ch), true})
+		}{ch, true})
 	}
 
 	// 手动添加其他特殊情况
 	tests = append(tests,
 		struct {
-			ch       byte
+			ch       rune
 			expected bool
 		}{'_', true},
 		struct {
-			ch       byte
+			ch       rune
 			expected bool
 		}{'0', false},
 		struct {
-			ch       byte
+			ch       rune
 			expected bool
 		}{'9', false},
 		struct {
-			ch       byte
+			ch       rune
 			expected bool
 		}{'!', false},
 		struct {
-			ch       byte
+			ch       rune
 			expected bool
 		}{'@', false},
 		struct {
-			ch       byte
+			ch       rune
 			expected bool
 		}{'#', false},
 		struct {
-			ch       byte
+			ch       rune
 			expected bool
 		}{' ', false},
 		struct {
-			ch       byte
+			ch       rune
 			expected bool
 		}{'\t', false},
 		struct {
-			ch       byte
+			ch       rune
 			expected bool
 		}{'\n', false},
 		struct {
-			ch       byte
+			ch       rune
 			expected bool
 		}{0, false},
+		// Unicode 字母
+		struct {
+			ch       rune
+			expected bool
+		}{'总', true},
+		struct {
+			ch       rune
+			expected bool
+		}{'é', true},
+		struct {
+			ch       rune
+			expected bool
+		}{'π', true},
+		// Unicode 数字和符号不是字母
+		struct {
+			ch       rune
+			expected bool
+		}{'１', false},
+		struct {
+			ch       rune
+			expected bool
+		}{'€', false},
+		struct {
+			ch       rune
+			expected bool
+		}{utf8.RuneError, false},
 	)
 
 	for i, tt := range tests {
@@ -661,7 +973,7 @@ func TestReadIdentifier(t *testing.T) {
 // TestIsDigit 测试 isDigit 函数
 func TestIsDigit(t *testing.T) {
 	tests := []struct {
-		ch       byte
+		ch       rune
 		expected bool
 	}{
 		{'0', true},
@@ -717,8 +1029,34 @@ func TestReadNumber(t *testing.T) {
 		{"1abc", "1abc", token.ILLEGAL, 4},
 		{"123.abc", "123.abc", token.ILLEGAL, 7},
 
-		// 边界情况测试
+		// 边界情况测试，溢出由语法分析器报告
 		{"9999999999999999999999999999999999999999", "9999999999999999999999999999999999999999", token.INT, 40},
+
+		// 其他进制和数字分隔符
+		{"0xFF", "0xFF", token.INT, 4},
+		{"0o17", "0o17", token.INT, 4},
+		{"0B1010", "0B1010", token.INT, 6},
+		{"1_000_000", "1_000_000", token.INT, 9},
+		// 进制前缀之后的数字是否合法由语法分析器检查
+		{"0b102", "0b102", token.INT, 5},
+
+		// 科学计数法
+		{"1.5e-3", "1.5e-3", token.FLOAT, 6},
+		{"2E10", "2E10", token.FLOAT, 4},
+		{"6.02e+23", "6.02e+23", token.FLOAT, 8},
+		{"1e", "1e", token.ILLEGAL, 2},
+		{"1.5.2", "1.5.2", token.ILLEGAL, 5},
+		{"1e+", "1e+", token.ILLEGAL, 3},
+		{"1E-x", "1E-x", token.ILLEGAL, 4},
+		{".5", ".5", token.ILLEGAL, 2},
+		{"0xG", "0xG", token.ILLEGAL, 3},
+
+		// 数字之后紧跟运算符
+		{"1+2", "1", token.INT, 1},
+		{"0x1F*2", "0x1F", token.INT, 4},
+		{"1.5-x", "1.5", token.FLOAT, 3},
+		{"1e3/2", "1e3", token.FLOAT, 3},
+		{"3)", "3", token.INT, 1},
 	}
 
 	for i, tt := range tests {
@@ -734,7 +1072,7 @@ func TestReadNumber(t *testing.T) {
 // TestIsEndSeparator 测试字符是否是结束分隔符
 func TestIsEndSeparator(t *testing.T) {
 	tests := []struct {
-		input    byte
+		input    rune
 		expected bool
 	}{
 		{'(', false},
@@ -765,7 +1103,7 @@ func TestIsEndSeparator(t *testing.T) {
 // TestIsSeparator 测试字符是否为分隔符
 func TestIsSeparator(t *testing.T) {
 	tests := []struct {
-		input    byte
+		input    rune
 		expected bool
 	}{
 		{'(', true},
@@ -795,7 +1133,7 @@ func TestIsSeparator(t *testing.T) {
 // TestIsOperator 测试字符是否为运算符
 func TestIsOperator(t *testing.T) {
 	tests := []struct {
-		input    byte
+		input    rune
 		expected bool
 	}{
 		{'=', true},
@@ -829,7 +1167,7 @@ func TestSkipNotEndSeparator(t *testing.T) {
 	tests := []struct {
 		input           string
 		expectedLiteral string
-		expectedChar    byte // 跳过后当前字符
+		expectedChar    rune // 跳过后当前字符
 	}{
 		{"abc", "", END},
 		{"x+123", "", END},
@@ -860,7 +1198,7 @@ func TestSkipNotEndSeparatorAndOperator(t *testing.T) {
 	tests := []struct {
 		input           string
 		expectedLiteral string
-		expectedChar    byte // 跳过后当前字符
+		expectedChar    rune // 跳过后当前字符
 	}{
 		{"abc", "", END},
 		{"x123", "", END},
@@ -887,3 +1225,156 @@ func TestSkipNotEndSeparatorAndOperator(t *testing.T) {
 		}
 	}
 }
+
+// TestTokenPosition 测试 token 的开始位置和结束位置
+func TestTokenPosition(t *testing.T) {
+	input := "let x = 10;\n  x >= \"ab\";\n// 注释\nfoo"
+	tests := []struct {
+		expectedType token.TokenType
+		expectedPos  token.Position
+		expectedEnd  token.Position
+	}{
+		{token.LET, token.Position{Filename: "main.holiya", Offset: 0, Line: 1, Column: 1}, token.Position{Filename: "main.holiya", Offset: 3, Line: 1, Column: 4}},
+		{token.IDENTIFIER, token.Position{Filename: "main.holiya", Offset: 4, Line: 1, Column: 5}, token.Position{Filename: "main.holiya", Offset: 5, Line: 1, Column: 6}},
+		{token.ASSIGN, token.Position{Filename: "main.holiya", Offset: 6, Line: 1, Column: 7}, token.Position{Filename: "main.holiya", Offset: 7, Line: 1, Column: 8}},
+		{token.INT, token.Position{Filename: "main.holiya", Offset: 8, Line: 1, Column: 9}, token.Position{Filename: "main.holiya", Offset: 10, Line: 1, Column: 11}},
+		{token.SEMICOLON, token.Position{Filename: "main.holiya", Offset: 10, Line: 1, Column: 11}, token.Position{Filename: "main.holiya", Offset: 11, Line: 1, Column: 12}},
+		{token.IDENTIFIER, token.Position{Filename: "main.holiya", Offset: 14, Line: 2, Column: 3}, token.Position{Filename: "main.holiya", Offset: 15, Line: 2, Column: 4}},
+		{token.GTE, token.Position{Filename: "main.holiya", Offset: 16, Line: 2, Column: 5}, token.Position{Filename: "main.holiya", Offset: 18, Line: 2, Column: 7}},
+		{token.STRING, token.Position{Filename: "main.holiya", Offset: 19, Line: 2, Column: 8}, token.Position{Filename: "main.holiya", Offset: 23, Line: 2, Column: 12}},
+		{token.SEMICOLON, token.Position{Filename: "main.holiya", Offset: 23, Line: 2, Column: 12}, token.Position{Filename: "main.holiya", Offset: 24, Line: 2, Column: 13}},
+		{token.IDENTIFIER, token.Position{Filename: "main.holiya", Offset: 35, Line: 4, Column: 1}, token.Position{Filename: "main.holiya", Offset: 38, Line: 4, Column: 4}},
+		{token.EOF, token.Position{Filename: "main.holiya", Offset: 38, Line: 4, Column: 4}, token.Position{Filename: "main.holiya", Offset: 38, Line: 4, Column: 4}},
+	}
+
+	l := NewWithFilename("main.holiya", input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected=%q, got=%q", i, tt.expectedType, tok.Type)
+		}
+		if tok.Pos != tt.expectedPos {
+			t.Errorf("tests[%d] - token pos wrong. expected=%+v, got=%+v", i, tt.expectedPos, tok.Pos)
+		}
+		if tok.End != tt.expectedEnd {
+			t.Errorf("tests[%d] - token end wrong. expected=%+v, got=%+v", i, tt.expectedEnd, tok.End)
+		}
+	}
+}
+
+// TestUnicodeSource 测试 Unicode 标识符，偏移量按字节计算，列号按字符计算
+func TestUnicodeSource(t *testing.T) {
+	input := "let 总数 = \"é\";\nπ2 + 总数１"
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedPos     token.Position
+		expectedEnd     token.Position
+	}{
+		{token.LET, "let", token.Position{Offset: 0, Line: 1, Column: 1}, token.Position{Offset: 3, Line: 1, Column: 4}},
+		{token.IDENTIFIER, "总数", token.Position{Offset: 4, Line: 1, Column: 5}, token.Position{Offset: 10, Line: 1, Column: 7}},
+		{token.ASSIGN, "=", token.Position{Offset: 11, Line: 1, Column: 8}, token.Position{Offset: 12, Line: 1, Column: 9}},
+		{token.STRING, "é", token.Position{Offset: 13, Line: 1, Column: 10}, token.Position{Offset: 17, Line: 1, Column: 13}},
+		{token.SEMICOLON, ";", token.Position{Offset: 17, Line: 1, Column: 13}, token.Position{Offset: 18, Line: 1, Column: 14}},
+		{token.IDENTIFIER, "π2", token.Position{Offset: 19, Line: 2, Column: 1}, token.Position{Offset: 22, Line: 2, Column: 3}},
+		{token.PLUS, "+", token.Position{Offset: 23, Line: 2, Column: 4}, token.Position{Offset: 24, Line: 2, Column: 5}},
+		{token.IDENTIFIER, "总数１", token.Position{Offset: 25, Line: 2, Column: 6}, token.Position{Offset: 34, Line: 2, Column: 9}},
+		{token.EOF, "", token.Position{Offset: 34, Line: 2, Column: 9}, token.Position{Offset: 34, Line: 2, Column: 9}},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token wrong. expected=%s %q, got=%s %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+		if tok.Pos != tt.expectedPos {
+			t.Errorf("tests[%d] - token pos wrong. expected=%+v, got=%+v", i, tt.expectedPos, tok.Pos)
+		}
+		if tok.End != tt.expectedEnd {
+			t.Errorf("tests[%d] - token end wrong. expected=%+v, got=%+v", i, tt.expectedEnd, tok.End)
+		}
+	}
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected errors: %+v", l.Errors())
+	}
+}
+
+// TestInvalidUTF8 测试不合法的 UTF-8 字节，在代码中是非法 token，在字符串中原样保留，都会报告词法错误
+func TestInvalidUTF8(t *testing.T) {
+	input := "x \xff \"a\xfeb\" 中"
+	expected := []token.Token{
+		{Type: token.IDENTIFIER, Literal: "x"},
+		{Type: token.ILLEGAL, Literal: "\xff"},
+		{Type: token.STRING, Literal: "a\xfeb"},
+		{Type: token.IDENTIFIER, Literal: "中"},
+		{Type: token.EOF, Literal: ""},
+	}
+
+	l := New(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Type != tt.Type || tok.Literal != tt.Literal {
+			t.Fatalf("tests[%d] - token wrong. expected=%s %q, got=%s %q", i, tt.Type, tt.Literal, tok.Type, tok.Literal)
+		}
+	}
+
+	expectedErrors := []Error{
+		{INVALID_UTF8, token.Position{Offset: 2, Line: 1, Column: 3}, token.Position{Offset: 3, Line: 1, Column: 4}, "invalid UTF-8 encoding: byte 0xff"},
+		{INVALID_UTF8, token.Position{Offset: 6, Line: 1, Column: 7}, token.Position{Offset: 7, Line: 1, Column: 8}, "invalid UTF-8 encoding: byte 0xfe"},
+	}
+	if !reflect.DeepEqual(l.Errors(), expectedErrors) {
+		t.Errorf("wrong errors.\nexpected=%+v\ngot=%+v", expectedErrors, l.Errors())
+	}
+}
+
+// TestDocComment 测试 /// 文档注释附加在之后的第一个 token 上
+func TestDocComment(t *testing.T) {
+	input := "/// 第一行\r\n///第二行\n// 普通注释\nlet x = 1;\n//// 分隔线\n/* 块注释 */ fn() {}"
+
+	l := New(input)
+	tok := l.NextToken()
+	if tok.Type != token.LET {
+		t.Fatalf("expected LET, got %s", tok.Type)
+	}
+	if tok.Doc == nil || len(tok.Doc.List) != 2 {
+		t.Fatalf("expected 2 doc comments, got %+v", tok.Doc)
+	}
+	if tok.Doc.List[0].Text != "/// 第一行" || tok.Doc.List[1].Text != "///第二行" {
+		t.Errorf("wrong doc comment text: %q, %q", tok.Doc.List[0].Text, tok.Doc.List[1].Text)
+	}
+	if pos := tok.Doc.List[1].Pos; pos.Line != 2 || pos.Column != 1 || pos.Offset != 15 {
+		t.Errorf("wrong doc comment position: %+v", pos)
+	}
+	if tok.Doc.Text() != "第一行\n第二行" {
+		t.Errorf("tok.Doc.Text() = %q", tok.Doc.Text())
+	}
+
+	// 文档注释只附加在一个 token 上，//// 和块注释不是文档注释
+	for tok = l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		if tok.Doc != nil {
+			t.Errorf("unexpected doc comment on %s: %+v", tok.Type, tok.Doc)
+		}
+	}
+}
+
+// TestUnterminatedBlockComment 测试没有闭合的块注释
+func TestUnterminatedBlockComment(t *testing.T) {
+	l := New("x /* a /* b */\ny")
+	if tok := l.NextToken(); tok.Type != token.IDENTIFIER {
+		t.Fatalf("expected IDENTIFIER, got %s", tok.Type)
+	}
+	if tok := l.NextToken(); tok.Type != token.EOF {
+		t.Fatalf("expected EOF, got %s", tok.Type)
+	}
+
+	expected := []Error{{
+		UNTERMINATED_COMMENT,
+		token.Position{Offset: 2, Line: 1, Column: 3},
+		token.Position{Offset: 16, Line: 2, Column: 2},
+		"block comment not terminated",
+	}}
+	if !reflect.DeepEqual(l.Errors(), expected) {
+		t.Errorf("wrong errors.\nexpected=%+v\ngot=%+v", expected, l.Errors())
+	}
+}
@@ -1,29 +1,44 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"holiya/engine"
 	"holiya/file"
+	"holiya/repl"
 	"os"
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		// 没有参数时，输出帮助信息
-		printHelp()
-		return
-	}
+	engineName := flag.String("engine", engine.EVAL, "execution engine: eval or vm")
+	flag.Usage = printHelp
+	flag.Parse()
 
-	// 执行 go run main.go filename.holiya 或 ./holiya filename.holiya
-	err := file.ProcessFile(os.Args[1], os.Stdout)
+	e, err := engine.New(*engineName)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
-		os.Exit(1)
+		os.Exit(2)
+	}
+
+	if flag.NArg() < 1 {
+		// 没有参数时，启动 repl
+		fmt.Println("Welcome to Holiya! Press Ctrl+D to exit.")
+		os.Exit(repl.Start(os.Stdin, os.Stdout, e))
 	}
+
+	// 执行 go run main.go filename.holiya 或 ./holiya filename.holiya
+	// 出错或调用 exit 函数时以对应的退出码退出
+	os.Exit(file.ProcessFile(flag.Arg(0), os.Stdout, os.Stderr, e))
 }
 
 // printHelp 输出帮助信息
 func printHelp() {
 	fmt.Println("Usage:")
+	fmt.Println("  ./holiya                     Start the REPL")
 	fmt.Println("  ./holiya filename.holiya     Process the specified file")
 	fmt.Println("  go run main.go filename.holiya     Process the specified file")
+	fmt.Println()
+	fmt.Println("Options:")
+	fmt.Println("  -engine=eval                 Run with the tree-walking evaluator (default)")
+	fmt.Println("  -engine=vm                   Compile to bytecode and run on the virtual machine")
 }
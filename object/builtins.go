@@ -0,0 +1,386 @@
+package object
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Builtins 所有的内置函数，求值器和虚拟机共用
+// 编译器和虚拟机用下标引用内置函数，所以新的内置函数只能添加在末尾
+var Builtins = []struct {
+	Name    string
+	Builtin *Builtin
+}{
+	// len 函数：返回数组、元组或字符串的长度
+	{"len", &Builtin{
+		Fn: func(ctx *Context, args ...Object) Object {
+			// 检查参数数量是否正确（必须是1个）
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			// 根据参数类型执行不同操作
+			switch arg := args[0].(type) {
+			case *Array:
+				// 如果是数组，返回其元素个数
+				return &Integer{Value: int64(len(arg.Elements))}
+			case *Tuple:
+				// 如果是元组，返回其元素个数
+				return &Integer{Value: int64(len(arg.Elements))}
+			case *String:
+				// 如果是字符串，返回其字符个数，而不是字节数
+				return &Integer{Value: int64(arg.Len())}
+			default:
+				// 不支持的类型报错
+				return newError("argument to `len` not supported, got %s", arg.Type())
+			}
+		}},
+	},
+
+	// puts 函数：打印所有参数并返回 NULL
+	{"puts", &Builtin{
+		Fn: func(ctx *Context, args ...Object) Object {
+			// 遍历所有参数，把它们的字符串表示逐行写到标准输出
+			for _, arg := range args {
+				if result := write(ctx.Stdout, "stdout", arg.Inspect()+"\n"); result != NULL {
+					return result
+				}
+			}
+			// 返回 nil，避免删除多余的 null 字符串
+			return nil
+		},
+	}},
+
+	// first 函数：返回数组或元组的第一个元素
+	{"first", &Builtin{
+		Fn: func(ctx *Context, args ...Object) Object {
+			// 检查参数数量是否正确（必须是1个）
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			// 检查参数是否为数组或元组类型
+			elements, ok := elementsOf(args[0])
+			if !ok {
+				return newError("argument to `first` must be ARRAY or TUPLE, got %s", args[0].Type())
+			}
+			// 如果数组不为空，返回第一个元素
+			if len(elements) > 0 {
+				return elements[0]
+			}
+			// 空数组返回 NULL
+			return NULL
+		},
+	}},
+
+	// last 函数：返回数组或元组的最后一个元素
+	{"last", &Builtin{
+		Fn: func(ctx *Context, args ...Object) Object {
+			// 检查参数数量是否正确（必须是1个）
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			// 检查参数是否为数组或元组类型
+			elements, ok := elementsOf(args[0])
+			if !ok {
+				return newError("argument to `last` must be ARRAY or TUPLE, got %s", args[0].Type())
+			}
+			// 如果数组不为空，返回最后一个元素
+			if len(elements) > 0 {
+				return elements[len(elements)-1]
+			}
+			// 空数组返回 NULL
+			return NULL
+		},
+	}},
+
+	// rest 函数：返回除第一个元素外的其余元素组成的新数组，参数是元组时返回新元组
+	{"rest", &Builtin{
+		Fn: func(ctx *Context, args ...Object) Object {
+			// 检查参数数量是否正确（必须是1个）
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			// 检查参数是否为数组或元组类型
+			elements, ok := elementsOf(args[0])
+			if !ok {
+				return newError("argument to `rest` must be ARRAY or TUPLE, got %s", args[0].Type())
+			}
+			// 如果数组不为空，创建新数组包含除第一个元素外的所有元素
+			if len(elements) > 0 {
+				newElements := make([]Object, len(elements)-1)
+				copy(newElements, elements[1:])
+				return withElements(args[0], newElements)
+			}
+			// 空数组返回 NULL
+			return NULL
+		},
+	}},
+
+	// push 函数：向数组末尾添加一个元素并返回新数组
+	{"push", &Builtin{
+		Fn: func(ctx *Context, args ...Object) Object {
+			// 检查参数数量是否正确（必须是2个）
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			// 检查第一个参数是否为数组类型
+			if args[0].Type() != ARRAY_OBJ {
+				return newError("argument to `push` must be ARRAY, got %s", args[0].Type())
+			}
+			// 获取原数组对象
+			arr := args[0].(*Array)
+			// 计算原数组长度
+			length := len(arr.Elements)
+			// 创建新数组，容量比原数组多1
+			newElements := make([]Object, length+1)
+			// 复制原数组元素到新数组
+			copy(newElements, arr.Elements)
+			// 将第二个参数添加到新数组末尾
+			newElements[length] = args[1]
+			// 返回新数组
+			return &Array{Elements: newElements}
+		},
+	}},
+
+	// slice 函数：返回数组、元组或字符串从 start 到 end（不包含）的部分，end 默认是长度
+	// 结果和参数的类型相同，字符串按字符计算下标，和 len、下标运算一致
+	{"slice", &Builtin{
+		Fn: func(ctx *Context, args ...Object) Object {
+			// 检查参数数量是否正确（2个或3个）
+			if len(args) != 2 && len(args) != 3 {
+				return newError("wrong number of arguments. got=%d, want=2..3", len(args))
+			}
+			elements, ok := elementsOf(args[0])
+			length := len(elements)
+			if str, isString := args[0].(*String); isString {
+				length = str.Len()
+			} else if !ok {
+				return newError("argument to `slice` must be ARRAY, TUPLE or STRING, got %s", args[0].Type())
+			}
+			// 检查下标是否为整数并且在范围内
+			bounds := []int64{0, int64(length)}
+			for i, arg := range args[1:] {
+				index, ok := arg.(*Integer)
+				if !ok {
+					return newError("slice index must be INTEGER, got %s", arg.Type())
+				}
+				bounds[i] = index.Value
+			}
+			start, end := bounds[0], bounds[1]
+			if start < 0 || end < start || end > int64(length) {
+				return newError("slice bounds out of range [%d:%d] with length %d", start, end, length)
+			}
+			// 返回新的数组、元组或字符串，不和原来的数组共享元素
+			if str, ok := args[0].(*String); ok {
+				return &String{Value: str.Substring(int(start), int(end))}
+			}
+			newElements := make([]Object, end-start)
+			copy(newElements, elements[start:end])
+			return withElements(args[0], newElements)
+		},
+	}},
+
+	// contains 函数：判断数组或元组是否包含某个元素、字符串是否包含某个子串、哈希表是否包含某个键
+	// 数组的元素和哈希表的键都按值比较，和 == 相同
+	{"contains", &Builtin{
+		Fn: func(ctx *Context, args ...Object) Object {
+			if len(args) != 2 {
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
+			}
+			if elements, ok := elementsOf(args[0]); ok {
+				for _, element := range elements {
+					if Equal(element, args[1]) {
+						return TRUE
+					}
+				}
+				return FALSE
+			}
+			switch arg := args[0].(type) {
+			case *String:
+				substr, ok := args[1].(*String)
+				if !ok {
+					return newError("second argument to `contains` must be STRING, got %s", args[1].Type())
+				}
+				return nativeBoolToBoolean(strings.Contains(arg.Value, substr.Value))
+			case *Hashmap:
+				key, ok := AsHashable(args[1])
+				if !ok {
+					return newError("unusable as hash key: %s", args[1].Type())
+				}
+				_, ok = arg.Get(key)
+				return nativeBoolToBoolean(ok)
+			default:
+				return newError("argument to `contains` must be ARRAY, TUPLE, STRING or HASH, got %s", args[0].Type())
+			}
+		},
+	}},
+
+	// sort 函数：返回排好序的新数组，不修改原来的数组，参数是元组时返回新元组
+	// 元素必须能互相比较大小，比如都是数字、都是字符串或者都是数组，相等的元素保持原来的顺序
+	{"sort", &Builtin{
+		Fn: func(ctx *Context, args ...Object) Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+			original, ok := elementsOf(args[0])
+			if !ok {
+				return newError("argument to `sort` must be ARRAY or TUPLE, got %s", args[0].Type())
+			}
+			elements := make([]Object, len(original))
+			copy(elements, original)
+			var err *Error
+			sort.SliceStable(elements, func(i, j int) bool {
+				result, ok := Compare(elements[i], elements[j])
+				if !ok && err == nil {
+					err = newError("`sort` cannot compare %s with %s", elements[i].Type(), elements[j].Type())
+				}
+				return result < 0
+			})
+			if err != nil {
+				return err
+			}
+			return withElements(args[0], elements)
+		},
+	}},
+
+	// print 函数：把所有参数的字符串表示用空格连接后写到标准输出，不换行
+	{"print", &Builtin{
+		Fn: func(ctx *Context, args ...Object) Object {
+			return write(ctx.Stdout, "stdout", joinArgs(args))
+		},
+	}},
+
+	// println 函数：和 print 相同，最后再写一个换行符
+	{"println", &Builtin{
+		Fn: func(ctx *Context, args ...Object) Object {
+			return write(ctx.Stdout, "stdout", joinArgs(args)+"\n")
+		},
+	}},
+
+	// eprint 函数：和 print 相同，但是写到标准错误
+	{"eprint", &Builtin{
+		Fn: func(ctx *Context, args ...Object) Object {
+			return write(ctx.Stderr, "stderr", joinArgs(args))
+		},
+	}},
+
+	// eprintln 函数：和 println 相同，但是写到标准错误
+	{"eprintln", &Builtin{
+		Fn: func(ctx *Context, args ...Object) Object {
+			return write(ctx.Stderr, "stderr", joinArgs(args)+"\n")
+		},
+	}},
+
+	// format 函数：按照格式字符串格式化参数，返回格式化后的字符串，如 format("%d-%s", 1, "a")
+	{"format", &Builtin{
+		Fn: func(ctx *Context, args ...Object) Object {
+			result, err := formatArgs("format", args)
+			if err != nil {
+				return err
+			}
+			return &String{Value: result}
+		},
+	}},
+
+	// printf 函数：和 format 相同，但是把结果写到标准输出
+	{"printf", &Builtin{
+		Fn: func(ctx *Context, args ...Object) Object {
+			result, err := formatArgs("printf", args)
+			if err != nil {
+				return err
+			}
+			return write(ctx.Stdout, "stdout", result)
+		},
+	}},
+
+	// exit 函数：以退出码 code 结束程序，没有参数时退出码为 0
+	{"exit", &Builtin{
+		Fn: func(ctx *Context, args ...Object) Object {
+			if len(args) > 1 {
+				return newError("wrong number of arguments. got=%d, want=0..1", len(args))
+			}
+			code := int64(0)
+			if len(args) == 1 {
+				arg, ok := args[0].(*Integer)
+				if !ok {
+					return newError("argument to `exit` must be INTEGER, got %s", args[0].Type())
+				}
+				code = arg.Value
+			}
+			if code < 0 || code > 255 {
+				return newError("exit code out of range: %d", code)
+			}
+			return &Error{Message: fmt.Sprintf("exit status %d", code), Exit: true, Code: int(code)}
+		},
+	}},
+}
+
+// 给内置函数设置名字，调用栈中使用这个名字
+func init() {
+	for _, def := range Builtins {
+		def.Builtin.Name = def.Name
+	}
+}
+
+// GetBuiltinByName 根据名字查找内置函数，找不到时返回 nil
+func GetBuiltinByName(name string) *Builtin {
+	for _, def := range Builtins {
+		if def.Name == name {
+			return def.Builtin
+		}
+	}
+	return nil
+}
+
+// 把参数的字符串表示用空格连接起来
+func joinArgs(args []Object) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = arg.Inspect()
+	}
+	return strings.Join(parts, " ")
+}
+
+// 检查 format 和 printf 的参数，第一个参数是格式字符串，用后面的参数格式化
+func formatArgs(name string, args []Object) (string, *Error) {
+	if len(args) < 1 {
+		return "", newError("wrong number of arguments. got=%d, want=>=1", len(args))
+	}
+	format, ok := args[0].(*String)
+	if !ok {
+		return "", newError("first argument to `%s` must be STRING, got %s", name, args[0].Type())
+	}
+	return formatString(name, format.Value, args[1:])
+}
+
+// 把 Go 的布尔值转换为 TRUE 或 FALSE
+func nativeBoolToBoolean(value bool) *Boolean {
+	if value {
+		return TRUE
+	}
+	return FALSE
+}
+
+// 创建一个新的 Error 对象，包含格式化的错误信息
+func newError(format string, a ...interface{}) *Error {
+	return &Error{Message: fmt.Sprintf(format, a...)}
+}
+
+// elementsOf 返回数组或元组的元素，只读取元素的内置函数对数组和元组的处理相同
+func elementsOf(obj Object) ([]Object, bool) {
+	switch obj := obj.(type) {
+	case *Array:
+		return obj.Elements, true
+	case *Tuple:
+		return obj.Elements, true
+	}
+	return nil, false
+}
+
+// withElements 用新的元素创建和 obj 类型相同的数组或元组
+func withElements(obj Object, elements []Object) Object {
+	if _, ok := obj.(*Tuple); ok {
+		return &Tuple{Elements: elements}
+	}
+	return &Array{Elements: elements}
+}
@@ -0,0 +1,264 @@
+package object
+
+import (
+	"cmp"
+	"math"
+	"strings"
+)
+
+// Equaler 可以按值比较是否相等的对象
+// 没有实现该接口的对象（函数、内置函数等）只和自己相等
+type Equaler interface {
+	Object
+	// Equals 判断和另一个对象是否相等
+	Equals(other Object) bool
+}
+
+// Ordered 有大小顺序的对象，可以用 <、>、<=、>= 比较，也可以用 sort 排序
+type Ordered interface {
+	Object
+	// Compare 和另一个对象比较，小于、等于、大于分别返回 -1、0、1
+	// 两个对象不能比较大小时第二个返回值为 false
+	Compare(other Object) (int, bool)
+}
+
+// Equal 判断两个对象是否相等，== 和 != 运算、哈希表的键和内置函数都使用它
+// 数字按数值比较，字符串、布尔值按值比较，数组、元组和哈希表逐个比较元素
+func Equal(a, b Object) bool {
+	return equal(a, b, visited{})
+}
+
+// Compare 比较两个对象的大小，两个对象不能比较大小时第二个返回值为 false
+func Compare(a, b Object) (int, bool) {
+	return compare(a, b, visited{})
+}
+
+// visited 记录已经开始比较的一对复合对象
+// 数组和哈希表可以包含自己，比较时再次遇到同一对对象就认为它们相等，
+// 所以循环引用的对象也能比较完，不会无限递归；其他元素不相等时结果仍然是不相等
+type visited map[[2]Object]bool
+
+// enter 记录开始比较 a 和 b，已经在比较时返回 false
+func (v visited) enter(a, b Object) bool {
+	key := [2]Object{a, b}
+	if v[key] {
+		return false
+	}
+	v[key] = true
+	return true
+}
+
+// equal 判断两个对象是否相等，复合对象的元素用同一个 v 比较
+func equal(a, b Object, v visited) bool {
+	switch a := a.(type) {
+	case *Array:
+		other, ok := b.(*Array)
+		if !ok {
+			return false
+		}
+		return a == other || !v.enter(a, other) || elementsEqual(a.Elements, other.Elements, v)
+	case *Tuple:
+		other, ok := b.(*Tuple)
+		if !ok {
+			return false
+		}
+		return a == other || !v.enter(a, other) || elementsEqual(a.Elements, other.Elements, v)
+	case *Hashmap:
+		other, ok := b.(*Hashmap)
+		if !ok || len(a.Pairs) != len(other.Pairs) {
+			return false
+		}
+		return a == other || !v.enter(a, other) || pairsEqual(a, other, v)
+	case Equaler:
+		return a.Equals(b)
+	}
+	return a == b
+}
+
+// compare 比较两个对象的大小，复合对象的元素用同一个 v 比较
+func compare(a, b Object, v visited) (int, bool) {
+	switch a := a.(type) {
+	case *Array:
+		other, ok := b.(*Array)
+		if !ok {
+			return 0, false
+		}
+		if a == other || !v.enter(a, other) {
+			return 0, true
+		}
+		return compareElements(a.Elements, other.Elements, v)
+	case *Tuple:
+		other, ok := b.(*Tuple)
+		if !ok {
+			return 0, false
+		}
+		if a == other || !v.enter(a, other) {
+			return 0, true
+		}
+		return compareElements(a.Elements, other.Elements, v)
+	case Ordered:
+		return a.Compare(b)
+	}
+	return 0, false
+}
+
+// Equals 判断整数是否和另一个数字相等
+// 整数和浮点数按准确的数值比较，不会因为转换成浮点数损失精度而相等
+func (i *Integer) Equals(other Object) bool {
+	switch other := other.(type) {
+	case *Integer:
+		return i.Value == other.Value
+	case *Float:
+		value, ok := floatToInt(other.Value)
+		return ok && value == i.Value
+	}
+	return false
+}
+
+// Compare 和另一个数字比较大小，整数和浮点数按准确的数值比较，结果和 Equals 一致
+func (i *Integer) Compare(other Object) (int, bool) {
+	switch other := other.(type) {
+	case *Integer:
+		return cmp.Compare(i.Value, other.Value), true
+	case *Float:
+		return compareIntFloat(i.Value, other.Value), true
+	}
+	return 0, false
+}
+
+// Equals 判断浮点数是否和另一个数字相等，NaN 和任何数字都不相等
+func (f *Float) Equals(other Object) bool {
+	switch other := other.(type) {
+	case *Integer:
+		return other.Equals(f)
+	case *Float:
+		return f.Value == other.Value
+	}
+	return false
+}
+
+// Compare 和另一个数字比较大小
+// 为了让排序的结果确定，NaN 比所有的数字都小，并且和自己相等
+func (f *Float) Compare(other Object) (int, bool) {
+	switch other := other.(type) {
+	case *Integer:
+		return -compareIntFloat(other.Value, f.Value), true
+	case *Float:
+		return cmp.Compare(f.Value, other.Value), true
+	}
+	return 0, false
+}
+
+// compareIntFloat 按准确的数值比较整数和浮点数，不把整数转换为浮点数，
+// 否则绝对值大于 2^53 的整数会损失精度，和相邻的浮点数比较时得到相等
+func compareIntFloat(i int64, f float64) int {
+	switch {
+	case math.IsNaN(f):
+		// 和 Float.Compare 相同，NaN 比所有的数字都小
+		return 1
+	case f >= math.MaxInt64:
+		// float64(math.MaxInt64) 是 2^63，大于所有的整数
+		return -1
+	case f < math.MinInt64:
+		return 1
+	}
+
+	// 浮点数在整数的范围内，先比较整数部分，相同时再看小数部分的符号
+	t := math.Trunc(f)
+	if c := cmp.Compare(i, int64(t)); c != 0 {
+		return c
+	}
+	return cmp.Compare(t, f)
+}
+
+// Equals 判断布尔值是否和另一个布尔值相等
+func (b *Boolean) Equals(other Object) bool {
+	otherBool, ok := other.(*Boolean)
+	return ok && b.Value == otherBool.Value
+}
+
+// Equals 判断是否是 null
+func (n *Null) Equals(other Object) bool {
+	_, ok := other.(*Null)
+	return ok
+}
+
+// Equals 判断字符串是否和另一个字符串相等
+func (s *String) Equals(other Object) bool {
+	otherString, ok := other.(*String)
+	return ok && s.Value == otherString.Value
+}
+
+// Compare 按字典序和另一个字符串比较大小，也就是逐个比较字符的 Unicode 码点
+func (s *String) Compare(other Object) (int, bool) {
+	otherString, ok := other.(*String)
+	if !ok {
+		return 0, false
+	}
+	return strings.Compare(s.Value, otherString.Value), true
+}
+
+// Equals 判断数组是否和另一个数组长度相同并且每个元素都相等
+func (a *Array) Equals(other Object) bool {
+	return Equal(a, other)
+}
+
+// Compare 按字典序和另一个数组比较大小
+func (a *Array) Compare(other Object) (int, bool) {
+	return Compare(a, other)
+}
+
+// Equals 判断元组是否和另一个元组长度相同并且每个元素都相等，元组和数组不相等
+func (t *Tuple) Equals(other Object) bool {
+	return Equal(t, other)
+}
+
+// Compare 按字典序和另一个元组比较大小
+func (t *Tuple) Compare(other Object) (int, bool) {
+	return Compare(t, other)
+}
+
+// elementsEqual 判断两组元素是否个数相同并且每个元素都相等
+func elementsEqual(a, b []Object, v visited) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, element := range a {
+		if !equal(element, b[i], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareElements 按字典序比较两组元素
+// 从第一个元素开始比较，第一个不相等的元素决定大小，所有元素都相等时较短的一组较小
+// 遇到不能比较大小的元素时两组元素也不能比较大小
+func compareElements(a, b []Object, v visited) (int, bool) {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		result, ok := compare(a[i], b[i], v)
+		if !ok {
+			return 0, false
+		}
+		if result != 0 {
+			return result, true
+		}
+	}
+	return cmp.Compare(len(a), len(b)), true
+}
+
+// Equals 判断哈希表是否和另一个哈希表有相同的键，并且每个键对应的值都相等，和键的顺序无关
+func (h *Hashmap) Equals(other Object) bool {
+	return Equal(h, other)
+}
+
+// pairsEqual 判断两个键值对个数相同的哈希表是否每个键对应的值都相等
+func pairsEqual(a, b *Hashmap, v visited) bool {
+	for _, pair := range a.Pairs {
+		otherPair, ok := b.Get(pair.Key.(Hashable))
+		if !ok || !equal(pair.Value, otherPair.Value, v) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package object
+
+import (
+	"math"
+	"testing"
+)
+
+// 测试 Equal 按值比较对象
+func TestEqual(t *testing.T) {
+	nan := &Float{Value: math.NaN()}
+	self := &Array{}
+	self.Elements = []Object{self}
+	fn := &Builtin{}
+
+	hash1, hash2 := &Hashmap{}, &Hashmap{}
+	hash1.Set(&String{Value: "a"}, &Integer{Value: 1})
+	hash1.Set(&Integer{Value: 2}, &Array{Elements: []Object{TRUE}})
+	hash2.Set(&Float{Value: 2.0}, &Array{Elements: []Object{TRUE}})
+	hash2.Set(&String{Value: "a"}, &Float{Value: 1.0})
+
+	tests := []struct {
+		a, b     Object
+		expected bool
+	}{
+		{&Integer{Value: 1}, &Integer{Value: 1}, true},
+		{&Integer{Value: 1}, &Float{Value: 1.0}, true},
+		{&Float{Value: 1.0}, &Integer{Value: 1}, true},
+		{&Integer{Value: 1<<53 + 1}, &Float{Value: 1 << 53}, false},
+		{nan, nan, false},
+		{&String{Value: "a"}, &String{Value: "a"}, true},
+		{&String{Value: "1"}, &Integer{Value: 1}, false},
+		{TRUE, &Boolean{Value: true}, true},
+		{NULL, &Null{}, true},
+		{NULL, FALSE, false},
+		{&Array{Elements: []Object{&Integer{Value: 1}}}, &Array{Elements: []Object{&Float{Value: 1}}}, true},
+		{&Array{Elements: []Object{&Integer{Value: 1}}}, &Array{}, false},
+		{self, self, true},
+		{hash1, hash2, true},
+		{hash1, &Hashmap{}, false},
+		{&Tuple{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}, &Tuple{Elements: []Object{&Float{Value: 1}, &String{Value: "a"}}}, true},
+		{&Tuple{Elements: []Object{&Integer{Value: 1}}}, &Array{Elements: []Object{&Integer{Value: 1}}}, false},
+		{fn, fn, true},
+		{fn, &Builtin{}, false},
+	}
+
+	for _, tt := range tests {
+		if Equal(tt.a, tt.b) != tt.expected {
+			t.Errorf("Equal(%s, %s) = %t, want %t", tt.a.Inspect(), tt.b.Inspect(), !tt.expected, tt.expected)
+		}
+	}
+}
+
+// 测试 Compare 比较对象的大小
+func TestCompare(t *testing.T) {
+	array := func(elements ...Object) *Array { return &Array{Elements: elements} }
+	one, two := &Integer{Value: 1}, &Integer{Value: 2}
+
+	tests := []struct {
+		a, b       Object
+		expected   int
+		comparable bool
+	}{
+		{one, two, -1, true},
+		{two, &Float{Value: 1.5}, 1, true},
+		{&Float{Value: math.NaN()}, one, -1, true},
+		{one, &Float{Value: math.NaN()}, 1, true},
+		// 整数和浮点数按准确的数值比较，和 Equals 的结果一致
+		{&Integer{Value: 1<<53 + 1}, &Float{Value: 1 << 53}, 1, true},
+		{&Float{Value: 1 << 53}, &Integer{Value: 1<<53 + 1}, -1, true},
+		{&Integer{Value: 1 << 53}, &Float{Value: 1 << 53}, 0, true},
+		{&Integer{Value: math.MaxInt64}, &Float{Value: math.MaxInt64}, -1, true},
+		{&Integer{Value: math.MinInt64}, &Float{Value: math.MinInt64}, 0, true},
+		{&Integer{Value: math.MinInt64}, &Float{Value: math.Inf(-1)}, 1, true},
+		{&Integer{Value: math.MaxInt64}, &Float{Value: math.Inf(1)}, -1, true},
+		{&Integer{Value: -2}, &Float{Value: -1.5}, -1, true},
+		{&Integer{Value: -1}, &Float{Value: -1.5}, 1, true},
+		{&Float{Value: 2.5}, two, 1, true},
+		{&String{Value: "abc"}, &String{Value: "abd"}, -1, true},
+		{&String{Value: "b"}, &String{Value: "abc"}, 1, true},
+		{&String{Value: "a"}, one, 0, false},
+		{array(one, two), array(one, two), 0, true},
+		{array(one), array(one, two), -1, true},
+		{array(two), array(one, two), 1, true},
+		{array(one), array(TRUE), 0, false},
+		{&Tuple{Elements: []Object{one}}, &Tuple{Elements: []Object{two}}, -1, true},
+		{&Tuple{Elements: []Object{one}}, array(one), 0, false},
+		{TRUE, FALSE, 0, false},
+		{&Hashmap{}, &Hashmap{}, 0, false},
+	}
+
+	for _, tt := range tests {
+		result, ok := Compare(tt.a, tt.b)
+		if ok != tt.comparable || result != tt.expected {
+			t.Errorf("Compare(%s, %s) = %d, %t, want %d, %t", tt.a.Inspect(), tt.b.Inspect(), result, ok, tt.expected, tt.comparable)
+		}
+	}
+}
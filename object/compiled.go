@@ -0,0 +1,102 @@
+package object
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"holiya/code"
+	"holiya/token"
+)
+
+// CompiledFunction 编译后的函数，由编译器生成，保存在常量池中
+// 虚拟机执行 OpClosure 指令时用它创建闭包
+type CompiledFunction struct {
+	// 函数体的指令
+	Instructions code.Instructions
+	// 局部变量的数量，包括参数
+	NumLocals int
+	// 参数的数量，不包括剩余参数
+	NumParameters int
+	// 必需参数的数量，之后的参数都有默认值
+	NumRequired int
+	// 是否有剩余参数，剩余参数保存在第 NumParameters 个局部变量中
+	HasRest bool
+	// 函数的名字，匿名函数为空
+	Name string
+	// 创建闭包时需要捕获的变量
+	FreeVariables []FreeVariable
+	// 局部变量的名字，用于错误信息
+	LocalNames []string
+	// 指令对应的源码位置，按指令的偏移量排序
+	Positions []SourcePosition
+}
+
+// FreeVariable 闭包捕获的变量的来源
+type FreeVariable struct {
+	// 为 true 时捕获外层函数的局部变量，Index 是局部变量的下标；
+	// 否则捕获外层函数捕获的变量，Index 是外层函数捕获变量的下标
+	IsLocal bool
+	Index   int
+	// 变量的名字，用于错误信息
+	Name string
+}
+
+// SourcePosition 从偏移量 Offset 开始的指令对应的源码位置
+type SourcePosition struct {
+	Offset int
+	Pos    token.Position
+}
+
+// 返回编译后的函数类型
+func (cf *CompiledFunction) Type() ObjectType {
+	return COMPILED_FUNCTION_OBJ
+}
+
+// 返回编译后的函数的字符串表示
+func (cf *CompiledFunction) Inspect() string {
+	return fmt.Sprintf("CompiledFunction[%p]", cf)
+}
+
+// PositionAt 返回偏移量为 offset 的指令对应的源码位置
+func (cf *CompiledFunction) PositionAt(offset int) token.Position {
+	i := sort.Search(len(cf.Positions), func(i int) bool {
+		return cf.Positions[i].Offset > offset
+	})
+	if i == 0 {
+		return token.Position{}
+	}
+	return cf.Positions[i-1].Pos
+}
+
+// Upvalue 闭包捕获的变量
+// 变量所在的函数还在执行时，Location 指向虚拟机栈中的局部变量，
+// 函数返回后，变量的值复制到 Closed 中，Location 改为指向 Closed
+type Upvalue struct {
+	Location *Object
+	Closed   Object
+}
+
+// Closure 闭包，编译后的函数和它捕获的变量
+// 对用户来说闭包就是函数，所以类型和求值器中的函数相同
+type Closure struct {
+	Fn   *CompiledFunction
+	Free []*Upvalue
+}
+
+// 返回闭包类型
+func (c *Closure) Type() ObjectType {
+	return FUNCTION_OBJ
+}
+
+// 返回闭包的字符串表示，编译后的函数没有源码，所以只输出参数
+func (c *Closure) Inspect() string {
+	params := []string{}
+	for i := 0; i < c.Fn.NumParameters; i++ {
+		params = append(params, c.Fn.LocalNames[i])
+	}
+	if c.Fn.HasRest {
+		params = append(params, "..."+c.Fn.LocalNames[c.Fn.NumParameters])
+	}
+	return "fn(" + strings.Join(params, ", ") + ") { ... }"
+}
@@ -0,0 +1,60 @@
+package object
+
+import (
+	"io"
+	"os"
+)
+
+// Context 内置函数执行时的上下文，puts、print 等内置函数的输入输出都通过它进行
+// 嵌入 Holiya 的程序可以用它重定向脚本的输出
+type Context struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	Stdin  io.Reader
+
+	// 求值器正在执行的函数调用，最外层的调用在前面
+	calls []Frame
+}
+
+// MaxCallDepth 函数调用的最大深度，超过时两个引擎都报告 stack overflow 错误
+const MaxCallDepth = 10000
+
+// DefaultContext 返回使用进程的标准输出、标准错误和标准输入的上下文
+func DefaultContext() *Context {
+	return &Context{Stdout: os.Stdout, Stderr: os.Stderr, Stdin: os.Stdin}
+}
+
+// PushCall 调用函数时把调用记录到调用栈中
+func (c *Context) PushCall(frame Frame) {
+	c.calls = append(c.calls, frame)
+}
+
+// PopCall 函数返回时从调用栈中删除最内层的调用
+func (c *Context) PopCall() {
+	c.calls = c.calls[:len(c.calls)-1]
+}
+
+// Depth 返回正在执行的函数调用的数量
+func (c *Context) Depth() int {
+	return len(c.calls)
+}
+
+// Trace 返回当前调用栈的快照，最内层的调用在前面
+func (c *Context) Trace() []Frame {
+	if len(c.calls) == 0 {
+		return nil
+	}
+	trace := make([]Frame, len(c.calls))
+	for i, frame := range c.calls {
+		trace[len(c.calls)-1-i] = frame
+	}
+	return trace
+}
+
+// write 把 s 写到 w，写入失败时返回错误，成功时返回 NULL
+func write(w io.Writer, name string, s string) Object {
+	if _, err := io.WriteString(w, s); err != nil {
+		return newError("cannot write to %s: %s", name, err)
+	}
+	return NULL
+}
@@ -6,6 +6,8 @@ package object
 type Environment struct {
 	store map[string]Object
 	outer *Environment
+	// 内置函数执行时的上下文，只有最外层的环境需要设置
+	ctx *Context
 }
 
 // 创建一个环境
@@ -40,3 +42,38 @@ func (e *Environment) Set(name string, value Object) Object {
 	e.store[name] = value
 	return value
 }
+
+// 给已经声明的变量赋值
+// 先在自己作用域内查找变量，找不到的话再到外部环境查找，找到后修改变量所在的环境
+// 所以闭包可以修改外部函数的变量。变量没有声明过时返回 false
+func (e *Environment) Assign(name string, value Object) (Object, bool) {
+	if _, ok := e.store[name]; ok {
+		e.store[name] = value
+		return value, true
+	}
+	if e.outer != nil {
+		return e.outer.Assign(name, value)
+	}
+	return nil, false
+}
+
+// 设置内置函数执行时的上下文
+func (e *Environment) SetContext(ctx *Context) {
+	e.ctx = ctx
+}
+
+// 获取内置函数执行时的上下文
+// 内层环境使用最外层环境的上下文，都没有设置时给最外层环境设置使用进程的标准输出、标准错误和标准输入的上下文，
+// 这样同一个程序中的调用栈保存在同一个上下文中
+func (e *Environment) Context() *Context {
+	env := e
+	for ; env.outer != nil; env = env.outer {
+		if env.ctx != nil {
+			return env.ctx
+		}
+	}
+	if env.ctx == nil {
+		env.ctx = DefaultContext()
+	}
+	return env.ctx
+}
@@ -161,3 +161,41 @@ func TestEnvironmentSet(t *testing.T) {
 		t.Errorf("Expected value 'world', got '%s'", retrievedStr.Value)
 	}
 }
+
+// 测试 Assign 方法
+func TestEnvironmentAssign(t *testing.T) {
+	outer := NewEnvironment()
+	outer.Set("x", &Integer{Value: 1})
+	inner := NewEnclosedEnvironment(outer)
+	inner.Set("y", &Integer{Value: 2})
+
+	// 修改外部环境的变量
+	if _, ok := inner.Assign("x", &Integer{Value: 10}); !ok {
+		t.Fatal("Assign() should return true for variable declared in outer environment")
+	}
+	if _, ok := inner.store["x"]; ok {
+		t.Error("Assign() should not create variable in inner environment")
+	}
+	obj, _ := outer.Get("x")
+	if obj.(*Integer).Value != 10 {
+		t.Errorf("Expected outer x to be 10, got %d", obj.(*Integer).Value)
+	}
+
+	// 修改自己作用域内的变量
+	if _, ok := inner.Assign("y", &Integer{Value: 20}); !ok {
+		t.Fatal("Assign() should return true for variable declared in inner environment")
+	}
+	obj, _ = inner.Get("y")
+	if obj.(*Integer).Value != 20 {
+		t.Errorf("Expected inner y to be 20, got %d", obj.(*Integer).Value)
+	}
+
+	// 修改没有声明的变量
+	obj, ok := inner.Assign("z", &Integer{Value: 30})
+	if ok || obj != nil {
+		t.Error("Assign() should return nil, false for undeclared variable")
+	}
+	if _, ok := inner.Get("z"); ok {
+		t.Error("Assign() should not declare undeclared variable")
+	}
+}
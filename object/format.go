@@ -0,0 +1,101 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// formatString 按照格式字符串 format 格式化参数，name 是调用它的内置函数的名字，用于错误信息
+// 支持的动词和 Go 的 fmt 包相同，可以带标志、宽度和精度，如 %-5d、%.2f：
+//   - %v、%s 任何值的字符串表示，%q 加上双引号的字符串表示
+//   - %d、%b、%o、%c 整数，%x、%X 整数或字符串
+//   - %f、%F、%e、%E、%g、%G 整数或浮点数
+//   - %t 布尔值，%% 百分号本身
+func formatString(name string, format string, args []Object) (string, *Error) {
+	var out strings.Builder
+	argIndex := 0
+	for i := 0; i < len(format); {
+		if format[i] != '%' {
+			out.WriteByte(format[i])
+			i++
+			continue
+		}
+
+		// 标志、宽度和精度原样交给 fmt 处理
+		start := i
+		i++
+		for i < len(format) && strings.IndexByte("+-# 0", format[i]) >= 0 {
+			i++
+		}
+		for i < len(format) && (isDigit(format[i]) || format[i] == '.') {
+			i++
+		}
+		if i >= len(format) {
+			return "", newError("`%s` format ends with an incomplete verb %q", name, format[start:])
+		}
+		verb, size := utf8.DecodeRuneInString(format[i:])
+		i += size
+		spec := format[start:i]
+		if verb == '%' {
+			out.WriteByte('%')
+			continue
+		}
+
+		if argIndex >= len(args) {
+			return "", newError("`%s` missing argument for %s", name, spec)
+		}
+		value, err := formatArg(name, spec, verb, args[argIndex])
+		if err != nil {
+			return "", err
+		}
+		argIndex++
+		out.WriteString(fmt.Sprintf(spec, value))
+	}
+
+	if argIndex < len(args) {
+		return "", newError("`%s` got too many arguments for the format. got=%d, want=%d", name, len(args), argIndex)
+	}
+	return out.String(), nil
+}
+
+// formatArg 检查参数的类型是否适用于动词，返回交给 fmt 格式化的 Go 值
+func formatArg(name string, spec string, verb rune, arg Object) (interface{}, *Error) {
+	switch verb {
+	case 'v', 's', 'q':
+		if str, ok := arg.(*String); ok {
+			return str.Value, nil
+		}
+		return arg.Inspect(), nil
+	case 'd', 'b', 'o', 'c':
+		if integer, ok := arg.(*Integer); ok {
+			return integer.Value, nil
+		}
+	case 'x', 'X':
+		switch arg := arg.(type) {
+		case *Integer:
+			return arg.Value, nil
+		case *String:
+			return arg.Value, nil
+		}
+	case 'f', 'F', 'e', 'E', 'g', 'G':
+		switch arg := arg.(type) {
+		case *Integer:
+			return float64(arg.Value), nil
+		case *Float:
+			return arg.Value, nil
+		}
+	case 't':
+		if boolean, ok := arg.(*Boolean); ok {
+			return boolean.Value, nil
+		}
+	default:
+		return nil, newError("`%s` unknown verb %s", name, spec)
+	}
+	return nil, newError("`%s` cannot format %s with %s", name, arg.Type(), spec)
+}
+
+// isDigit 判断字节是否是十进制数字
+func isDigit(ch byte) bool {
+	return '0' <= ch && ch <= '9'
+}
@@ -0,0 +1,53 @@
+package object
+
+import "testing"
+
+// 测试 format 和 printf 使用的格式化
+func TestFormatString(t *testing.T) {
+	tests := []struct {
+		format   string
+		args     []Object
+		expected string
+	}{
+		{"plain", nil, "plain"},
+		{"%d%%", []Object{&Integer{Value: 50}}, "50%"},
+		{"%s=%v", []Object{&String{Value: "a"}, &Array{Elements: []Object{&Integer{Value: 1}}}}, "a=[1]"},
+		{"%q", []Object{&String{Value: "你好"}}, `"你好"`},
+		{"%5d|%-5d|%05d", []Object{&Integer{Value: 1}, &Integer{Value: 2}, &Integer{Value: 3}}, "    1|2    |00003"},
+		{"%.2f %g", []Object{&Float{Value: 3.14159}, &Integer{Value: 2}}, "3.14 2"},
+		{"%x %X %b %o", []Object{&Integer{Value: 255}, &String{Value: "hi"}, &Integer{Value: 5}, &Integer{Value: 8}}, "ff 6869 101 10"},
+		{"%c%t", []Object{&Integer{Value: '好'}, TRUE}, "好true"},
+		{"%s", []Object{NULL}, "null"},
+	}
+
+	for _, tt := range tests {
+		result, err := formatString("format", tt.format, tt.args)
+		if err != nil {
+			t.Errorf("formatString(%q) returned error: %s", tt.format, err.Message)
+			continue
+		}
+		if result != tt.expected {
+			t.Errorf("formatString(%q) = %q, want %q", tt.format, result, tt.expected)
+		}
+	}
+
+	errorTests := []struct {
+		format   string
+		args     []Object
+		expected string
+	}{
+		{"%d", nil, "`format` missing argument for %d"},
+		{"%d", []Object{&String{Value: "a"}}, "`format` cannot format STRING with %d"},
+		{"%.1f", []Object{TRUE}, "`format` cannot format BOOLEAN with %.1f"},
+		{"%z", []Object{&Integer{Value: 1}}, "`format` unknown verb %z"},
+		{"%5", nil, "`format` format ends with an incomplete verb \"%5\""},
+		{"", []Object{&Integer{Value: 1}}, "`format` got too many arguments for the format. got=1, want=0"},
+	}
+
+	for _, tt := range errorTests {
+		_, err := formatString("format", tt.format, tt.args)
+		if err == nil || err.Message != tt.expected {
+			t.Errorf("formatString(%q) returned %v, want %q", tt.format, err, tt.expected)
+		}
+	}
+}
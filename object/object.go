@@ -2,10 +2,14 @@ package object
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"hash/fnv"
 	"holiya/ast"
+	"holiya/token"
+	"math"
 	"strings"
+	"unicode/utf8"
 )
 
 const (
@@ -25,13 +29,21 @@ const (
 
 	// 返回值
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
+	// break 信号
+	BREAK_OBJ = "BREAK"
+	// continue 信号
+	CONTINUE_OBJ = "CONTINUE"
 	// 函数
 	FUNCTION_OBJ = "FUNCTION"
 	// 内置函数
 	BUILTIN_OBJ = "BUILTIN"
+	// 编译后的函数，只在常量池中出现
+	COMPILED_FUNCTION_OBJ = "COMPILED_FUNCTION"
 
 	// 数组
 	ARRAY_OBJ = "ARRAY"
+	// 元组
+	TUPLE_OBJ = "TUPLE"
 	// 哈希表
 	HASH_OBJ = "HASH"
 )
@@ -45,10 +57,11 @@ type Object interface {
 	Inspect() string
 }
 
-// 内置函数类型
-type BuiltinFunction func(args ...Object) Object
+// 内置函数类型，ctx 是执行时的上下文，内置函数的输入输出都通过它进行
+type BuiltinFunction func(ctx *Context, args ...Object) Object
 
 // 哈希键结构体
+// 相等的键一定有相同的哈希键，不相等的键也可能有相同的哈希键，由 Hashmap 比较键本身区分
 type HashKey struct {
 	// 类型
 	Type ObjectType
@@ -57,11 +70,50 @@ type HashKey struct {
 }
 
 // 哈希接口，实现该接口的对象都可以作为哈希键
+// 元组只有在所有元素都可以作为哈希键时才能作为哈希键，所以要用 AsHashable 判断对象能否作为哈希键
 type Hashable interface {
+	Object
 	// 获取哈希键
 	GetHashKey() HashKey
 }
 
+// AsHashable 判断对象能否作为哈希键，可以时返回对应的 Hashable
+func AsHashable(obj Object) (Hashable, bool) {
+	if tuple, ok := obj.(*Tuple); ok {
+		for _, element := range tuple.Elements {
+			if _, ok := AsHashable(element); !ok {
+				return nil, false
+			}
+		}
+		return tuple, true
+	}
+	key, ok := obj.(Hashable)
+	return key, ok
+}
+
+// KeysEqual 判断两个哈希表的键是否是同一个键，和 Equal 相同，只是所有的 NaN 都是同一个键
+// 否则 NaN 作为键存入后就再也查不到了，元组中的 NaN 也是如此
+func KeysEqual(a, b Hashable) bool {
+	if x, ok := a.(*Tuple); ok {
+		y, ok := b.(*Tuple)
+		if !ok || len(x.Elements) != len(y.Elements) {
+			return false
+		}
+		for i := range x.Elements {
+			if !KeysEqual(x.Elements[i].(Hashable), y.Elements[i].(Hashable)) {
+				return false
+			}
+		}
+		return true
+	}
+	if Equal(a, b) {
+		return true
+	}
+	x, ok1 := a.(*Float)
+	y, ok2 := b.(*Float)
+	return ok1 && ok2 && math.IsNaN(x.Value) && math.IsNaN(y.Value)
+}
+
 // 整数
 type Integer struct {
 	Value int64
@@ -99,11 +151,28 @@ func (f *Float) Inspect() string {
 	return fmt.Sprintf("%f", f.Value)
 }
 
-// 返回浮点数的哈希键对象
+// 返回浮点数的哈希键对象，按浮点数的二进制表示计算
+// 值为整数的浮点数和对应的整数是同一个键，使用整数的哈希键，-0.0 也因此和 0 是同一个键
+// 所有的 NaN 使用同一个哈希键
 func (f *Float) GetHashKey() HashKey {
+	if value, ok := floatToInt(f.Value); ok {
+		return (&Integer{Value: value}).GetHashKey()
+	}
+	bits := math.Float64bits(f.Value)
+	if math.IsNaN(f.Value) {
+		bits = math.Float64bits(math.NaN())
+	}
 	return HashKey{
 		Type:  f.Type(),
-		Value: uint64(f.Value)}
+		Value: bits}
+}
+
+// floatToInt 在浮点数的值是 int64 范围内的整数时返回对应的整数
+func floatToInt(f float64) (int64, bool) {
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(f), true
 }
 
 // 布尔
@@ -137,6 +206,17 @@ func (b *Boolean) GetHashKey() HashKey {
 	}
 }
 
+// null、true 和 false 都是唯一的实例，求值器和虚拟机共用这些实例，
+// 所以可以直接比较指针判断是否相等
+var (
+	// null 实例
+	NULL = &Null{}
+	// true 实例
+	TRUE = &Boolean{Value: true}
+	// false 实例
+	FALSE = &Boolean{Value: false}
+)
+
 // null，使用空结构体，使用了
 type Null struct{}
 
@@ -165,9 +245,44 @@ func (rv *ReturnValue) Inspect() string {
 	return rv.Value.Inspect()
 }
 
+// break 信号，执行 break 语句时产生，一直向外传递到所在的循环
+type Break struct{}
+
+// 返回 break 信号的类型
+func (b *Break) Type() ObjectType {
+	return BREAK_OBJ
+}
+
+// 返回 break 信号的字符串表示
+func (b *Break) Inspect() string {
+	return "break"
+}
+
+// continue 信号，执行 continue 语句时产生，一直向外传递到所在的循环
+type Continue struct{}
+
+// 返回 continue 信号的类型
+func (c *Continue) Type() ObjectType {
+	return CONTINUE_OBJ
+}
+
+// 返回 continue 信号的字符串表示
+func (c *Continue) Inspect() string {
+	return "continue"
+}
+
 // 错误
+// 调用 exit 函数时也返回错误，这样程序的执行会像出错时一样中止，这时 Exit 为 true，Code 是退出码
 type Error struct {
 	Message string
+	// 出错的位置，由求值器在返回错误时设置
+	Pos token.Position
+	// 出错时的调用栈，最内层的调用在前面，在顶层代码中出错时为空
+	Trace []Frame
+	// 是否是调用 exit 函数产生的
+	Exit bool
+	// 退出码
+	Code int
 }
 
 // 返回错误类型
@@ -176,16 +291,26 @@ func (e *Error) Type() ObjectType {
 }
 
 // 返回错误的字符串表示
+// 位置有效时，错误信息以位置开头，如 ERROR: main.holiya:12:7: identifier not found: x
 func (e *Error) Inspect() string {
+	if e.Pos.IsValid() {
+		return "ERROR: " + e.Pos.String() + ": " + e.Message
+	}
 	return "ERROR: " + e.Message
 }
 
 // 函数
 type Function struct {
 	Parameters []*ast.Identifier
-	Body       *ast.BlockStatement
+	// 参数的默认值，和 Parameters 一一对应，没有默认值的参数对应 nil
+	Defaults []ast.Expression
+	// 剩余参数，可以为 nil
+	Rest *ast.Identifier
+	Body *ast.BlockStatement
 	// 函数的运行环境
 	Env *Environment
+	// 函数的名字，匿名函数为空
+	Name string
 }
 
 // 返回函数类型
@@ -197,14 +322,9 @@ func (f *Function) Type() ObjectType {
 func (f *Function) Inspect() string {
 	var out bytes.Buffer
 
-	params := []string{}
-	for _, p := range f.Parameters {
-		params = append(params, p.String())
-	}
-
 	out.WriteString("fn")
 	out.WriteString("(")
-	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(ast.ParametersString(f.Parameters, f.Defaults, f.Rest))
 	out.WriteString(") {\n")
 	out.WriteString(f.Body.String())
 	out.WriteString("\n}\n")
@@ -213,8 +333,73 @@ func (f *Function) Inspect() string {
 }
 
 // 字符串
+// 长度、下标、切片和遍历都按字符（Unicode 码点）计算，不合法的 UTF-8 字节每个算作一个字符
 type String struct {
 	Value string
+	// 每个字符在 Value 中的开始位置，第一次按字符访问时计算并缓存
+	// 只包含 ASCII 字符的字符串为 nil，字符下标就是字节下标
+	offsets []int
+	// offsets 是否已经计算
+	indexed bool
+}
+
+// index 计算每个字符的开始位置
+func (s *String) index() {
+	if s.indexed {
+		return
+	}
+	s.indexed = true
+	for i := 0; i < len(s.Value); i++ {
+		if s.Value[i] >= utf8.RuneSelf {
+			s.offsets = make([]int, 0, len(s.Value))
+			for offset := range s.Value {
+				s.offsets = append(s.offsets, offset)
+			}
+			return
+		}
+	}
+}
+
+// offset 返回第 i 个字符的开始位置，i 等于字符数时返回字符串的字节长度
+func (s *String) offset(i int) int {
+	switch {
+	case s.offsets == nil:
+		return i
+	case i == len(s.offsets):
+		return len(s.Value)
+	default:
+		return s.offsets[i]
+	}
+}
+
+// Len 返回字符串的字符数
+func (s *String) Len() int {
+	s.index()
+	if s.offsets == nil {
+		return len(s.Value)
+	}
+	return len(s.offsets)
+}
+
+// CharAt 返回第 i 个字符，i 必须在 [0, Len()) 范围内
+func (s *String) CharAt(i int) string {
+	return s.Substring(i, i+1)
+}
+
+// Substring 返回第 start 个字符到第 end 个字符（不包含）之间的子串
+// 必须满足 0 <= start <= end <= Len()
+func (s *String) Substring(start, end int) string {
+	s.index()
+	return s.Value[s.offset(start):s.offset(end)]
+}
+
+// Chars 返回由每个字符组成的字符串对象，用于遍历字符串
+func (s *String) Chars() []Object {
+	chars := make([]Object, s.Len())
+	for i := range chars {
+		chars[i] = &String{Value: s.CharAt(i)}
+	}
+	return chars
 }
 
 // 返回字符串类型
@@ -236,7 +421,9 @@ func (s *String) GetHashKey() HashKey {
 
 // 内置函数
 type Builtin struct {
-	Fn BuiltinFunction
+	// 名字，用于调用栈，没有名字时为空
+	Name string
+	Fn   BuiltinFunction
 }
 
 // 返回内置函数类型
@@ -275,15 +462,111 @@ func (a *Array) Inspect() string {
 	return out.String()
 }
 
+// 元组，创建后不能修改
+// 所有元素都可以作为哈希键时，元组也可以作为哈希键，哈希键由元素的哈希键计算
+type Tuple struct {
+	Elements []Object
+}
+
+// 返回元组类型
+func (t *Tuple) Type() ObjectType {
+	return TUPLE_OBJ
+}
+
+// 返回元组的字符串表示，如 #(1, a)
+func (t *Tuple) Inspect() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, element := range t.Elements {
+		elements = append(elements, element.Inspect())
+	}
+
+	out.WriteString("#(")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// 返回元组的哈希键对象，依次用每个元素的哈希键计算，相等的元组有相同的哈希键
+// 只能在 AsHashable 判断元组可以作为哈希键之后调用
+func (t *Tuple) GetHashKey() HashKey {
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, element := range t.Elements {
+		key := element.(Hashable).GetHashKey()
+		h.Write([]byte(key.Type))
+		binary.LittleEndian.PutUint64(buf[:], key.Value)
+		h.Write(buf[:])
+	}
+	return HashKey{Type: t.Type(), Value: h.Sum64()}
+}
+
+// Unpack 返回解构赋值时依次赋给 n 个变量的值，被解构的对象必须是元素个数为 n 的元组或数组
+func Unpack(obj Object, n int) ([]Object, *Error) {
+	var elements []Object
+	switch obj := obj.(type) {
+	case *Tuple:
+		elements = obj.Elements
+	case *Array:
+		elements = obj.Elements
+	default:
+		return nil, newError("cannot destructure %s, want TUPLE or ARRAY", obj.Type())
+	}
+	if len(elements) != n {
+		return nil, newError("cannot destructure %s of length %d into %d variables", obj.Type(), len(elements), n)
+	}
+	return elements, nil
+}
+
 // 哈希键值对
 type HashPair struct {
 	Key   Object
 	Value Object
 }
 
-// 哈希表
+// 哈希表，键值对按键第一次插入的顺序排列，遍历和输出的顺序是确定的
+// 通过键查找和修改都是 O(1) 的，修改键值对要使用 Set，不能直接修改 Pairs
+// 哈希键相同的键放在同一个桶里，查找时用 KeysEqual 比较键本身，哈希冲突不会把不同的键当成同一个
 type Hashmap struct {
-	Pairs map[HashKey]HashPair
+	// 键值对，按键第一次插入的顺序排列
+	Pairs []HashPair
+	// 每个哈希键对应的桶，保存键值对在 Pairs 中的下标
+	index map[HashKey][]int
+}
+
+// find 返回键在 Pairs 中的下标，不存在时返回 -1
+func (h *Hashmap) find(key Hashable) int {
+	for _, i := range h.index[key.GetHashKey()] {
+		if KeysEqual(h.Pairs[i].Key.(Hashable), key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Get 根据键查找键值对
+func (h *Hashmap) Get(key Hashable) (HashPair, bool) {
+	i := h.find(key)
+	if i < 0 {
+		return HashPair{}, false
+	}
+	return h.Pairs[i], true
+}
+
+// Set 设置键对应的值，键已经存在时只替换值，键和位置都不变
+func (h *Hashmap) Set(key Hashable, value Object) {
+	if i := h.find(key); i >= 0 {
+		h.Pairs[i].Value = value
+		return
+	}
+	if h.index == nil {
+		h.index = make(map[HashKey][]int)
+	}
+	hashKey := key.GetHashKey()
+	h.index[hashKey] = append(h.index[hashKey], len(h.Pairs))
+	h.Pairs = append(h.Pairs, HashPair{Key: key, Value: value})
 }
 
 // 返回哈希表类型
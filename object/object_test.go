@@ -4,6 +4,7 @@ package object
 import (
 	"holiya/ast"
 	"holiya/token"
+	"math"
 	"testing"
 )
 
@@ -108,6 +109,54 @@ func TestFloatGetHashKey(t *testing.T) {
 	if key1.Value == key3.Value {
 		t.Errorf("Float with different value have same hash keys")
 	}
+
+	// 小数部分不同的浮点数不能因为截断成整数而得到相同的哈希键
+	if (&Float{Value: 1.1}).GetHashKey() == (&Float{Value: 1.9}).GetHashKey() {
+		t.Errorf("1.1 and 1.9 have same hash keys")
+	}
+	// 值为整数的浮点数和对应的整数是同一个键，-0.0 和 0 也是同一个键
+	if (&Float{Value: 3.0}).GetHashKey() != (&Integer{Value: 3}).GetHashKey() {
+		t.Errorf("3.0 and 3 have different hash keys")
+	}
+	if (&Float{Value: math.Copysign(0, -1)}).GetHashKey() != (&Integer{Value: 0}).GetHashKey() {
+		t.Errorf("-0.0 and 0 have different hash keys")
+	}
+	// 所有的 NaN 都是同一个键
+	nan1 := &Float{Value: math.NaN()}
+	nan2 := &Float{Value: math.Float64frombits(math.Float64bits(math.NaN()) | 1)}
+	if nan1.GetHashKey() != nan2.GetHashKey() {
+		t.Errorf("NaN values have different hash keys")
+	}
+	if (&Float{Value: 1e300}).GetHashKey().Type != FLOAT_OBJ {
+		t.Errorf("1e300 should not be hashed as an integer")
+	}
+}
+
+// 测试哈希表的键的比较
+func TestKeysEqual(t *testing.T) {
+	tests := []struct {
+		a, b     Hashable
+		expected bool
+	}{
+		{&Integer{Value: 1}, &Integer{Value: 1}, true},
+		{&Integer{Value: 1}, &Float{Value: 1.0}, true},
+		{&Float{Value: 1.0}, &Integer{Value: 1}, true},
+		{&Integer{Value: 1}, &Float{Value: 1.5}, false},
+		{&Float{Value: 0}, &Float{Value: math.Copysign(0, -1)}, true},
+		{&Float{Value: math.NaN()}, &Float{Value: math.NaN()}, true},
+		{&Float{Value: math.NaN()}, &Float{Value: 1}, false},
+		{&Integer{Value: math.MaxInt64}, &Float{Value: math.MaxInt64}, false},
+		{&String{Value: "1"}, &Integer{Value: 1}, false},
+		{&String{Value: "a"}, &String{Value: "a"}, true},
+		{TRUE, &Boolean{Value: true}, true},
+		{TRUE, &Integer{Value: 1}, false},
+	}
+
+	for _, tt := range tests {
+		if KeysEqual(tt.a, tt.b) != tt.expected {
+			t.Errorf("KeysEqual(%s, %s) = %t, want %t", tt.a.Inspect(), tt.b.Inspect(), !tt.expected, tt.expected)
+		}
+	}
 }
 
 // 测试 Boolean 对象的 Type 方法
@@ -345,11 +394,67 @@ func TestArrayInspect(t *testing.T) {
 	}
 }
 
+// 测试 Tuple 对象的 Inspect 方法
+func TestTupleInspect(t *testing.T) {
+	tuple := &Tuple{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}
+	if tuple.Inspect() != "#(1, a)" {
+		t.Errorf("Tuple.Inspect() = %s, want #(1, a)", tuple.Inspect())
+	}
+	if (&Tuple{}).Inspect() != "#()" {
+		t.Errorf("Tuple.Inspect() = %s, want #()", (&Tuple{}).Inspect())
+	}
+}
+
+// 测试元组的哈希键由元素计算，以及只有所有元素都能作为哈希键时元组才能作为哈希键
+func TestTupleGetHashKey(t *testing.T) {
+	tuple := func(elements ...Object) *Tuple { return &Tuple{Elements: elements} }
+	one, a := &Integer{Value: 1}, &String{Value: "a"}
+
+	if tuple(one, a).GetHashKey() != tuple(&Float{Value: 1}, &String{Value: "a"}).GetHashKey() {
+		t.Errorf("equal tuples have different hash keys")
+	}
+	if tuple(one, a).GetHashKey() == tuple(a, one).GetHashKey() {
+		t.Errorf("#(1, a) and #(a, 1) have same hash keys")
+	}
+	if tuple(one).GetHashKey() == one.GetHashKey() {
+		t.Errorf("#(1) and 1 have same hash keys")
+	}
+
+	tests := []struct {
+		obj      Object
+		expected bool
+	}{
+		{tuple(one, a), true},
+		{tuple(tuple(one), TRUE), true},
+		{tuple(one, &Array{}), false},
+		{tuple(tuple(&Hashmap{})), false},
+		{&Array{}, false},
+		{one, true},
+	}
+	for _, tt := range tests {
+		if _, ok := AsHashable(tt.obj); ok != tt.expected {
+			t.Errorf("AsHashable(%s) = %t, want %t", tt.obj.Inspect(), ok, tt.expected)
+		}
+	}
+}
+
+// 测试解构时取出元组或数组的元素
+func TestUnpack(t *testing.T) {
+	elements, err := Unpack(&Tuple{Elements: []Object{TRUE, FALSE}}, 2)
+	if err != nil || len(elements) != 2 || elements[0] != TRUE {
+		t.Errorf("Unpack(#(true, false), 2) = %v, %v", elements, err)
+	}
+	if _, err := Unpack(&Array{Elements: []Object{TRUE}}, 2); err == nil || err.Message != "cannot destructure ARRAY of length 1 into 2 variables" {
+		t.Errorf("Unpack([true], 2) returned error %v", err)
+	}
+	if _, err := Unpack(TRUE, 1); err == nil || err.Message != "cannot destructure BOOLEAN, want TUPLE or ARRAY" {
+		t.Errorf("Unpack(true, 1) returned error %v", err)
+	}
+}
+
 // 测试 Hashmap 对象的 Type 方法
 func TestHashmapType(t *testing.T) {
-	hash := &Hashmap{
-		Pairs: map[HashKey]HashPair{},
-	}
+	hash := &Hashmap{}
 	if hash.Type() != HASH_OBJ {
 		t.Errorf("Hashmap.Type() = %s, want %s", hash.Type(), HASH_OBJ)
 	}
@@ -357,26 +462,114 @@ func TestHashmapType(t *testing.T) {
 
 // 测试 Hashmap 对象的 Inspect 方法
 func TestHashmapInspect(t *testing.T) {
-	s := String{Value: "name"}
-	hash := &Hashmap{
-		Pairs: map[HashKey]HashPair{
-			s.GetHashKey(): {
-				Key:   &String{Value: "name"},
-				Value: &String{Value: "Jimmy"},
-			},
-		},
+	hash := &Hashmap{}
+	for _, name := range []string{"name", "age", "city"} {
+		key := &String{Value: name}
+		hash.Set(key, &String{Value: name + "!"})
 	}
 
+	// 键值对按插入的顺序输出
 	result := hash.Inspect()
-	if len(result) == 0 {
-		t.Errorf("Hashmap.Inspect() returned empty string")
-	}
-	if len(result) < 2 || result[0] != '{' || result[len(result)-1] != '}' {
-		t.Errorf("Hashmap.Inspect() format is incorrect: %s", result)
+	if result != "{name: name!, age: age!, city: city!}" {
+		t.Errorf("Hashmap.Inspect() = %s", result)
 	}
 
-	emptyHash := &Hashmap{Pairs: map[HashKey]HashPair{}}
+	emptyHash := &Hashmap{}
 	if emptyHash.Inspect() != "{}" {
 		t.Errorf("Hashmap.Inspect() = %s, want {}", emptyHash.Inspect())
 	}
 }
+
+// 测试 Hashmap 的查找和修改，修改已有的键时位置不变
+func TestHashmapGetSet(t *testing.T) {
+	hash := &Hashmap{}
+	one, two := &Integer{Value: 1}, &Integer{Value: 2}
+	hash.Set(two, &String{Value: "b"})
+	hash.Set(one, &String{Value: "a"})
+	hash.Set(&Float{Value: 2.0}, &String{Value: "c"})
+
+	if len(hash.Pairs) != 2 {
+		t.Fatalf("len(hash.Pairs) = %d, want 2", len(hash.Pairs))
+	}
+	// 替换值时保留原来的键
+	if hash.Inspect() != "{2: c, 1: a}" {
+		t.Errorf("hash.Inspect() = %s, want {2: c, 1: a}", hash.Inspect())
+	}
+	pair, ok := hash.Get(one)
+	if !ok || pair.Value.Inspect() != "a" {
+		t.Errorf("hash.Get(1) = %v, %t", pair, ok)
+	}
+	if _, ok := hash.Get(&Integer{Value: 3}); ok {
+		t.Errorf("hash.Get(3) found a pair")
+	}
+}
+
+// collidingKey 的哈希键都相同，用于测试哈希冲突
+type collidingKey struct {
+	name string
+}
+
+func (c *collidingKey) Type() ObjectType    { return "COLLIDING" }
+func (c *collidingKey) Inspect() string     { return c.name }
+func (c *collidingKey) GetHashKey() HashKey { return HashKey{Type: "COLLIDING", Value: 42} }
+
+// 测试哈希键相同的不同键不会互相覆盖
+func TestHashmapCollision(t *testing.T) {
+	hash := &Hashmap{}
+	a, b := &collidingKey{name: "a"}, &collidingKey{name: "b"}
+	hash.Set(a, &Integer{Value: 1})
+	hash.Set(b, &Integer{Value: 2})
+
+	if hash.Inspect() != "{a: 1, b: 2}" {
+		t.Fatalf("hash.Inspect() = %s, want {a: 1, b: 2}", hash.Inspect())
+	}
+	for key, expected := range map[*collidingKey]string{a: "1", b: "2"} {
+		pair, ok := hash.Get(key)
+		if !ok || pair.Value.Inspect() != expected {
+			t.Errorf("hash.Get(%s) = %v, %t, want %s", key.name, pair, ok, expected)
+		}
+	}
+	if _, ok := hash.Get(&collidingKey{name: "c"}); ok {
+		t.Errorf("hash.Get(c) found a pair")
+	}
+}
+
+// 测试 String 对象按字符计算长度、下标和子串，缓存的字符位置在多次访问之间保持一致
+func TestStringChars(t *testing.T) {
+	tests := []struct {
+		value         string
+		expectedLen   int
+		expectedChars []string
+	}{
+		{"", 0, []string{}},
+		{"abc", 3, []string{"a", "b", "c"}},
+		{"你好", 2, []string{"你", "好"}},
+		{"a😀é", 3, []string{"a", "😀", "é"}},
+		{"a\xffb", 3, []string{"a", "\xff", "b"}},
+	}
+
+	for _, tt := range tests {
+		str := &String{Value: tt.value}
+		if str.Len() != tt.expectedLen {
+			t.Errorf("String{%q}.Len() = %d, want %d", tt.value, str.Len(), tt.expectedLen)
+			continue
+		}
+		for i, expected := range tt.expectedChars {
+			if str.CharAt(i) != expected {
+				t.Errorf("String{%q}.CharAt(%d) = %q, want %q", tt.value, i, str.CharAt(i), expected)
+			}
+		}
+		chars := str.Chars()
+		if len(chars) != len(tt.expectedChars) {
+			t.Errorf("len(String{%q}.Chars()) = %d, want %d", tt.value, len(chars), len(tt.expectedChars))
+		}
+		if str.Substring(0, str.Len()) != tt.value {
+			t.Errorf("String{%q}.Substring(0, Len()) = %q", tt.value, str.Substring(0, str.Len()))
+		}
+	}
+
+	str := &String{Value: "你好世界"}
+	if str.Substring(1, 3) != "好世" {
+		t.Errorf("Substring(1, 3) = %q, want %q", str.Substring(1, 3), "好世")
+	}
+}
@@ -0,0 +1,51 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+
+	"holiya/token"
+)
+
+// Frame 调用栈中的一帧，出错时的调用栈保存在 Error.Trace 中
+type Frame struct {
+	// 被调用的函数的名字，匿名函数为空
+	Function string
+	// 调用函数的位置，从 Go 代码中调用时无效
+	Pos token.Position
+}
+
+// String 返回帧的字符串表示，如 `add` called at main.holiya:3:5
+func (f Frame) String() string {
+	name := "anonymous function"
+	if f.Function != "" {
+		name = "`" + f.Function + "`"
+	}
+	if f.Pos.IsValid() {
+		return name + " called at " + f.Pos.String()
+	}
+	return name
+}
+
+// FormatTrace 把调用栈格式化为多行的字符串，最内层的调用在前面，调用栈为空时返回空字符串
+// 递归调用时连续相同的帧只显示一次，后面注明重复的次数
+func FormatTrace(trace []Frame) string {
+	if len(trace) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	out.WriteString("traceback (most recent call first):\n")
+	for i := 0; i < len(trace); {
+		out.WriteString("  " + trace[i].String() + "\n")
+		j := i + 1
+		for j < len(trace) && trace[j] == trace[i] {
+			j++
+		}
+		if repeated := j - i - 1; repeated > 0 {
+			out.WriteString(fmt.Sprintf("  ... repeated %d more times\n", repeated))
+		}
+		i = j
+	}
+	return out.String()
+}
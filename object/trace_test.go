@@ -0,0 +1,42 @@
+package object
+
+import (
+	"testing"
+
+	"holiya/token"
+)
+
+// 测试调用栈的格式化，连续相同的帧只显示一次
+func TestFormatTrace(t *testing.T) {
+	pos := func(line, column int) token.Position {
+		return token.Position{Filename: "main.holiya", Line: line, Column: column}
+	}
+	recursive := Frame{Function: "loop", Pos: pos(2, 3)}
+
+	tests := []struct {
+		trace    []Frame
+		expected string
+	}{
+		{nil, ""},
+		{
+			[]Frame{{Function: "add", Pos: pos(3, 5)}, {Pos: pos(7, 1)}, {Function: "main"}},
+			"traceback (most recent call first):\n" +
+				"  `add` called at main.holiya:3:5\n" +
+				"  anonymous function called at main.holiya:7:1\n" +
+				"  `main`\n",
+		},
+		{
+			[]Frame{recursive, recursive, recursive, {Function: "loop", Pos: pos(5, 1)}},
+			"traceback (most recent call first):\n" +
+				"  `loop` called at main.holiya:2:3\n" +
+				"  ... repeated 2 more times\n" +
+				"  `loop` called at main.holiya:5:1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		if result := FormatTrace(tt.trace); result != tt.expected {
+			t.Errorf("FormatTrace(%v) = %q, want %q", tt.trace, result, tt.expected)
+		}
+	}
+}
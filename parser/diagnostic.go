@@ -0,0 +1,188 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+
+	"holiya/token"
+)
+
+// Severity 诊断信息的严重程度
+type Severity int
+
+const (
+	// 错误，程序不能执行
+	ERROR Severity = iota
+	// 警告，程序可以执行，但可能有问题
+	WARNING
+	// 提示
+	NOTE
+)
+
+// String 返回严重程度的字符串表示
+func (s Severity) String() string {
+	switch s {
+	case ERROR:
+		return "error"
+	case WARNING:
+		return "warning"
+	case NOTE:
+		return "note"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// MarshalText 序列化为 JSON 等格式时使用字符串表示
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Code 错误码，每一类错误有固定的错误码，方便工具根据错误码处理
+type Code string
+
+const (
+	// 下一个 token 不是期望的 token
+	UNEXPECTED_TOKEN Code = "E0001"
+	// 没有 token 对应的前缀表达式解析函数，也就是这里不能开始一个表达式
+	NO_PREFIX_PARSE_FN Code = "E0002"
+	// 整数字面量不合法，如下划线的位置不对或者数字不属于进制
+	INVALID_INTEGER Code = "E0003"
+	// 浮点数字面量不合法
+	INVALID_FLOAT Code = "E0004"
+	// 哈希字面量的键值对之后不是 , 或 }
+	INVALID_HASH_PAIR Code = "E0005"
+	// 赋值运算符左边不是标识符或索引表达式
+	INVALID_ASSIGN_TARGET Code = "E0006"
+	// break 或 continue 不在循环中
+	OUTSIDE_LOOP Code = "E0007"
+	// 函数的参数列表不合法，如剩余参数不是最后一个参数
+	INVALID_PARAMETER Code = "E0008"
+	// 字符串没有闭合，由词法分析器报告
+	UNTERMINATED_STRING Code = "E0009"
+	// 字符串中的转义序列不合法，由词法分析器报告
+	INVALID_ESCAPE Code = "E0010"
+	// 源码不是合法的 UTF-8 编码，由词法分析器报告
+	INVALID_UTF8 Code = "E0011"
+	// 数字字面量超出了取值范围，如整数大于 int64 的最大值
+	NUMBER_OUT_OF_RANGE Code = "E0012"
+	// 块注释没有闭合，由词法分析器报告
+	UNTERMINATED_COMMENT Code = "E0013"
+	// 字符串插值 ${} 中没有表达式
+	EMPTY_INTERPOLATION Code = "E0014"
+)
+
+// FixIt 修复建议，把 Pos 到 End 之间的内容替换成 NewText
+// Pos 和 End 相同时表示在 Pos 处插入 NewText
+type FixIt struct {
+	// 修复建议的说明
+	Message string `json:"message"`
+	// 替换的开始位置
+	Pos token.Position `json:"pos"`
+	// 替换的结束位置
+	End token.Position `json:"end"`
+	// 替换后的内容
+	NewText string `json:"newText"`
+}
+
+// Diagnostic 解析时产生的诊断信息
+type Diagnostic struct {
+	// 严重程度
+	Severity Severity `json:"severity"`
+	// 错误码
+	Code Code `json:"code"`
+	// 出错的开始位置
+	Pos token.Position `json:"pos"`
+	// 出错的结束位置
+	End token.Position `json:"end"`
+	// 错误信息
+	Message string `json:"message"`
+	// 期望的 token 类型，可以为空
+	Expected []token.TokenType `json:"expected,omitempty"`
+	// 实际遇到的 token 类型，可以为空
+	Found token.TokenType `json:"found,omitempty"`
+	// 修复建议，可以为空
+	FixIts []FixIt `json:"fixIts,omitempty"`
+}
+
+// Error 实现 error 接口
+// 位置有效时，错误信息以位置开头，如 main.holiya:12:7: expected ...
+func (d *Diagnostic) Error() string {
+	if d.Pos.IsValid() {
+		return d.Pos.String() + ": " + d.Message
+	}
+	return d.Message
+}
+
+// ErrorList 诊断信息列表
+type ErrorList []*Diagnostic
+
+// Add 添加一条诊断信息
+func (l *ErrorList) Add(d *Diagnostic) {
+	*l = append(*l, d)
+}
+
+// Len 实现 sort.Interface 接口
+func (l ErrorList) Len() int {
+	return len(l)
+}
+
+// Swap 实现 sort.Interface 接口
+func (l ErrorList) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+}
+
+// Less 实现 sort.Interface 接口
+// 按文件名、行、列排序，位置相同时按错误信息排序
+func (l ErrorList) Less(i, j int) bool {
+	e, f := l[i].Pos, l[j].Pos
+	if e.Filename != f.Filename {
+		return e.Filename < f.Filename
+	}
+	if e.Line != f.Line {
+		return e.Line < f.Line
+	}
+	if e.Column != f.Column {
+		return e.Column < f.Column
+	}
+	return l[i].Message < l[j].Message
+}
+
+// Sort 按位置对诊断信息排序
+func (l ErrorList) Sort() {
+	sort.Stable(l)
+}
+
+// RemoveMultiples 排序并去重，同一个位置只保留第一条诊断信息
+func (l *ErrorList) RemoveMultiples() {
+	l.Sort()
+	var last token.Position
+	i := 0
+	for _, d := range *l {
+		if i == 0 || d.Pos != last {
+			last = d.Pos
+			(*l)[i] = d
+			i++
+		}
+	}
+	*l = (*l)[:i]
+}
+
+// Error 实现 error 接口，返回第一条错误信息和剩余错误的数量
+func (l ErrorList) Error() string {
+	switch len(l) {
+	case 0:
+		return "no errors"
+	case 1:
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", l[0].Error(), len(l)-1)
+}
+
+// Err 没有诊断信息时返回 nil，否则返回诊断信息列表本身
+func (l ErrorList) Err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
@@ -0,0 +1,208 @@
+package parser
+
+import (
+	"encoding/json"
+	"holiya/lexer"
+	"holiya/token"
+	"strings"
+	"testing"
+)
+
+// 测试 ErrorList 的排序和去重
+func TestErrorListRemoveMultiples(t *testing.T) {
+	list := ErrorList{
+		{Pos: token.Position{Line: 3, Column: 1}, Message: "c"},
+		{Pos: token.Position{Line: 1, Column: 5}, Message: "b"},
+		{Pos: token.Position{Line: 1, Column: 2}, Message: "a"},
+		{Pos: token.Position{Line: 3, Column: 1}, Message: "d"},
+	}
+	list.RemoveMultiples()
+
+	expected := []string{"1:2: a", "1:5: b", "3:1: c"}
+	if len(list) != len(expected) {
+		t.Fatalf("len(list) = %d, want %d", len(list), len(expected))
+	}
+	for i, msg := range expected {
+		if list[i].Error() != msg {
+			t.Errorf("list[%d] = %q, want %q", i, list[i].Error(), msg)
+		}
+	}
+}
+
+// 测试 ErrorList 实现的 error 接口
+func TestErrorListError(t *testing.T) {
+	var list ErrorList
+	if list.Err() != nil {
+		t.Errorf("empty list Err() = %v, want nil", list.Err())
+	}
+
+	list.Add(&Diagnostic{Pos: token.Position{Filename: "main.holiya", Line: 1, Column: 1}, Message: "first"})
+	if list.Error() != "main.holiya:1:1: first" {
+		t.Errorf("list.Error() = %q", list.Error())
+	}
+
+	list.Add(&Diagnostic{Message: "second"})
+	list.Add(&Diagnostic{Message: "third"})
+	if list.Error() != "main.holiya:1:1: first (and 2 more errors)" {
+		t.Errorf("list.Error() = %q", list.Error())
+	}
+	if list.Err() == nil {
+		t.Error("list.Err() = nil, want error")
+	}
+}
+
+// 测试解析器产生的诊断信息的错误码、期望的 token 和修复建议
+func TestParserDiagnostics(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedCode     Code
+		expectedExpected []token.TokenType
+		expectedFound    token.TokenType
+		expectedFixIt    string
+	}{
+		{"add(1, 2;", UNEXPECTED_TOKEN, []token.TokenType{token.RPAREN}, token.SEMICOLON, ")"},
+		{"let 5 = 1;", UNEXPECTED_TOKEN, []token.TokenType{token.IDENTIFIER}, token.INT, ""},
+		{"1 + ;", NO_PREFIX_PARSE_FN, nil, token.SEMICOLON, ""},
+		{"{1: 2 3}", INVALID_HASH_PAIR, []token.TokenType{token.COMMA, token.RBRACE}, token.INT, ""},
+		{"let s = \"abc;\nlet t = 1;", UNTERMINATED_STRING, nil, token.ILLEGAL, ""},
+		{"let s = `abc;", UNTERMINATED_STRING, nil, token.ILLEGAL, ""},
+		{`let s = "a\qb";`, INVALID_ESCAPE, nil, token.STRING, ""},
+		{"9223372036854775808", NUMBER_OUT_OF_RANGE, nil, "", ""},
+		{"1e400", NUMBER_OUT_OF_RANGE, nil, "", ""},
+		{"0b12", INVALID_INTEGER, nil, "", ""},
+		{"1e+", INVALID_FLOAT, nil, token.ILLEGAL, ""},
+		{"12abc", INVALID_INTEGER, nil, token.ILLEGAL, ""},
+		{"let x = 1; /* ", UNTERMINATED_COMMENT, nil, "", ""},
+		{`"a ${}"`, EMPTY_INTERPOLATION, nil, "", ""},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		parser.ParseProgram()
+		if len(parser.Errors()) == 0 {
+			t.Errorf("expected errors for input %q, got none", tt.input)
+			continue
+		}
+		diagnostic := parser.Errors()[0]
+		if diagnostic.Code != tt.expectedCode {
+			t.Errorf("diagnostic.Code = %s, want %s for input %q", diagnostic.Code, tt.expectedCode, tt.input)
+		}
+		if strings.Join(tokenTypes(diagnostic.Expected), ",") != strings.Join(tokenTypes(tt.expectedExpected), ",") {
+			t.Errorf("diagnostic.Expected = %v, want %v for input %q", diagnostic.Expected, tt.expectedExpected, tt.input)
+		}
+		if diagnostic.Found != tt.expectedFound {
+			t.Errorf("diagnostic.Found = %s, want %s for input %q", diagnostic.Found, tt.expectedFound, tt.input)
+		}
+		if tt.expectedFixIt == "" {
+			if len(diagnostic.FixIts) != 0 {
+				t.Errorf("unexpected fix-its %v for input %q", diagnostic.FixIts, tt.input)
+			}
+			continue
+		}
+		if len(diagnostic.FixIts) != 1 || diagnostic.FixIts[0].NewText != tt.expectedFixIt {
+			t.Errorf("diagnostic.FixIts = %v, want %q for input %q", diagnostic.FixIts, tt.expectedFixIt, tt.input)
+		}
+	}
+}
+
+// 测试词法错误只报告一次，不会再产生解析错误，后面的语句可以继续解析
+func TestLexerErrorsInParser(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedErrors     []string
+		expectedStatements int
+	}{
+		{"let s = \"abc;\nlet t = 1;", []string{"1:9: string literal not terminated"}, 1},
+		{`let s = "a\qb"; let t = "\x4";`, []string{
+			"1:11: unknown escape sequence \\q",
+			"1:26: invalid escape sequence \\x: expected two hex digits",
+		}, 2},
+		{"let s = `a\nb", []string{"1:9: raw string literal not terminated"}, 0},
+		{"let t = 1;\nlet s = \"a ${", []string{"2:9: string literal not terminated"}, 1},
+		{"let 总数 = 1;\nlet x = \xff;\nlet y = \"\xfe\";", []string{
+			"2:9: invalid UTF-8 encoding: byte 0xff",
+			"3:10: invalid UTF-8 encoding: byte 0xfe",
+		}, 2},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		errors := []string{}
+		for _, diagnostic := range p.Errors() {
+			errors = append(errors, diagnostic.Error())
+		}
+		if strings.Join(errors, "\n") != strings.Join(tt.expectedErrors, "\n") {
+			t.Errorf("wrong errors for %q.\ngot=%q\nwant=%q", tt.input, errors, tt.expectedErrors)
+		}
+		if len(program.Statements) != tt.expectedStatements {
+			t.Errorf("wrong number of statements for %q. got=%d, want=%d", tt.input, len(program.Statements), tt.expectedStatements)
+		}
+	}
+}
+
+// 测试数字字面量的错误信息
+func TestNumberLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 9223372036854775808;", "1:9: integer literal 9223372036854775808 overflows int64, max is 9223372036854775807"},
+		{"0xFFFFFFFFFFFFFFFFF + 1", "1:1: integer literal 0xFFFFFFFFFFFFFFFFF overflows int64, max is 9223372036854775807"},
+		{"1e309 * 2", "1:1: float literal 1e309 is out of range for float64"},
+		{"1__0", "1:1: invalid integer literal 1__0"},
+		{"x + 0o8", "1:5: invalid integer literal 0o8"},
+		{"1.5_", "1:1: invalid float literal 1.5_"},
+		// 格式不合法的数字整体报告一次错误
+		{"1.", "1:1: invalid float literal 1."},
+		{"12abc", "1:1: invalid integer literal 12abc"},
+		{"0xG", "1:1: invalid integer literal 0xG"},
+		{"1e", "1:1: invalid float literal 1e"},
+		{"1e+", "1:1: invalid float literal 1e+"},
+		{".5", "1:1: invalid float literal .5"},
+		{"let x = [1, 2.x, 3];", "1:13: invalid float literal 2.x"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Errorf("expected 1 error for %q, got %d: %v", tt.input, len(errors), errors)
+			continue
+		}
+		if errors[0].Error() != tt.expected {
+			t.Errorf("wrong error for %q. got=%q, want=%q", tt.input, errors[0].Error(), tt.expected)
+		}
+	}
+}
+
+// 测试诊断信息序列化为 JSON
+func TestDiagnosticJSON(t *testing.T) {
+	diagnostic := &Diagnostic{
+		Severity: ERROR,
+		Code:     UNEXPECTED_TOKEN,
+		Pos:      token.Position{Line: 1, Column: 2, Offset: 1},
+		End:      token.Position{Line: 1, Column: 3, Offset: 2},
+		Message:  "message",
+		Found:    token.SEMICOLON,
+	}
+	data, err := json.Marshal(diagnostic)
+	if err != nil {
+		t.Fatalf("json.Marshal returned error: %v", err)
+	}
+	for _, expected := range []string{`"severity":"error"`, `"code":"E0001"`, `"found":";"`, `"message":"message"`} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("json %s does not contain %s", data, expected)
+		}
+	}
+}
+
+// tokenTypes 把 token 类型转换为字符串，方便比较
+func tokenTypes(types []token.TokenType) []string {
+	result := []string{}
+	for _, t := range types {
+		result = append(result, string(t))
+	}
+	return result
+}
@@ -1,10 +1,12 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"holiya/ast"
 	"holiya/lexer"
 	"holiya/token"
+	"math"
 	"strconv"
 )
 
@@ -13,16 +15,24 @@ const (
 	_ int = iota
 	// LOWEST 最低的优先级
 	LOWEST
-	// EQUALS =，!=
+	// ASSIGN =，+=，-=，*=，/=，%=
+	ASSIGN
+	// LOGICAL_OR ||
+	LOGICAL_OR
+	// LOGICAL_AND &&
+	LOGICAL_AND
+	// EQUALS ==，!=
 	EQUALS
+	// LESSGREATER <，>，<=，>=
+	LESSGREATER
 	// SUM +，-
 	SUM
-	// LESSGREATER <，>
-	LESSGREATER
 	// PRODUCT *，/，%
 	PRODUCT
 	// PREFIX !，-
 	PREFIX
+	// POWER **，比前缀运算符高，-2 ** 2 等于 -(2 ** 2)
+	POWER
 	// CALL (，函数调用
 	CALL
 	// INDEX [，索引
@@ -31,16 +41,35 @@ const (
 
 // 定义所有的token类型对应的整数值
 var precedences = map[token.TokenType]int{
-	token.EQ:       EQUALS,
-	token.NEQ:      EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.DIV:      PRODUCT,
-	token.MUL:      PRODUCT,
-	token.LPAREN:   CALL,
-	token.LBRACKET: INDEX,
+	token.ASSIGN:       ASSIGN,
+	token.PLUS_ASSIGN:  ASSIGN,
+	token.MINUS_ASSIGN: ASSIGN,
+	token.MUL_ASSIGN:   ASSIGN,
+	token.DIV_ASSIGN:   ASSIGN,
+	token.MOD_ASSIGN:   ASSIGN,
+	token.OR:           LOGICAL_OR,
+	token.AND:          LOGICAL_AND,
+	token.EQ:           EQUALS,
+	token.NEQ:          EQUALS,
+	token.LT:           LESSGREATER,
+	token.GT:           LESSGREATER,
+	token.LTE:          LESSGREATER,
+	token.GTE:          LESSGREATER,
+	token.PLUS:         SUM,
+	token.MINUS:        SUM,
+	token.MUL:          PRODUCT,
+	token.DIV:          PRODUCT,
+	token.MOD:          PRODUCT,
+	token.POW:          POWER,
+	token.LPAREN:       CALL,
+	token.LBRACKET:     INDEX,
+}
+
+// 右结合的中缀运算符，不在这里的中缀运算符都是左结合的
+// 如 2 ** 3 ** 2 等于 2 ** (3 ** 2)，而 8 - 4 - 2 等于 (8 - 4) - 2
+// 赋值运算符由 parseAssignExpression 单独处理，也是右结合的
+var rightAssociative = map[token.TokenType]bool{
+	token.POW: true,
 }
 
 type (
@@ -54,8 +83,16 @@ type (
 type Parser struct {
 	// Lexer指针
 	l *lexer.Lexer
-	// 错误信息
-	errors []string
+	// 诊断信息
+	errors ErrorList
+	// 还没有恢复的错误数量，出错的语句恢复到语句边界后清零
+	unrecovered int
+	// 当前 token 之前没有闭合的 { 的数量
+	depth int
+	// 当前所在的循环的层数，函数体中重新从 0 开始，用于检查 break 和 continue 的位置
+	loopDepth int
+	// 已经转换为诊断信息的词法错误的数量
+	lexerErrors int
 
 	// 当前指针指向的token
 	currToken token.Token
@@ -72,7 +109,7 @@ type Parser struct {
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
 		l:      l,
-		errors: []string{},
+		errors: ErrorList{},
 	}
 
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
@@ -84,6 +121,8 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	// 注册字符串前缀表达式的解析函数
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	// 注册插值字符串前缀表达式的解析函数
+	p.registerPrefix(token.INTERP_START, p.parseInterpolatedString)
 	// 注册!的前缀表达式的解析函数
 	p.registerPrefix(token.BAND, p.parsePrefixExpression)
 	// 注册-的前缀表达式的解析函数
@@ -102,6 +141,8 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	// 注册{的前缀表达式的解析函数
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
+	// 注册#(的前缀表达式的解析函数
+	p.registerPrefix(token.TUPLE_START, p.parseTupleLiteral)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	// 注册+的中缀表达式的解析函数
@@ -112,8 +153,10 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.MUL, p.parseInfixExpression)
 	// 注册/的中缀表达式的解析函数
 	p.registerInfix(token.DIV, p.parseInfixExpression)
-	// 注册/的中缀表达式的解析函数
+	// 注册%的中缀表达式的解析函数
 	p.registerInfix(token.MOD, p.parseInfixExpression)
+	// 注册**的中缀表达式的解析函数
+	p.registerInfix(token.POW, p.parseInfixExpression)
 	// 注册<的中缀表达式的解析函数
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	// 注册>的中缀表达式的解析函数
@@ -126,6 +169,17 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	// 注册!=的中缀表达式的解析函数
 	p.registerInfix(token.NEQ, p.parseInfixExpression)
+	// 注册赋值运算符的中缀表达式的解析函数
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PLUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MINUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MUL_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.DIV_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MOD_ASSIGN, p.parseAssignExpression)
+	// 注册&&的中缀表达式的解析函数
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	// 注册||的中缀表达式的解析函数
+	p.registerInfix(token.OR, p.parseInfixExpression)
 
 	// 注册(的中缀表达式的解析函数
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
@@ -141,20 +195,82 @@ func New(l *lexer.Lexer) *Parser {
 }
 
 // ParseProgram 解析Program
+// 有语法错误时，跳过出错的语句继续解析，返回的 Program 只包含没有错误的语句
 func (p *Parser) ParseProgram() *ast.Program {
 	program := &ast.Program{}
 	program.Statements = []ast.Statement{}
 
 	for !p.currTokenIs(token.EOF) {
-		stmt := p.parseStatement()
+		stmt, _ := p.parseStatementWithRecovery()
 		if stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
 		p.nextToken()
 	}
+	// 同一个位置只报告一次错误
+	p.errors.RemoveMultiples()
 	return program
 }
 
+// 解析语句，语句有错误时跳到语句的边界并返回 nil
+// 这样出错的语句不会出现在语法树中，后面的语句也不会因为前面的错误产生一连串的错误
+// 第二个返回值表示出错的语句停在了所在块的 } 上，也就是块已经结束了
+func (p *Parser) parseStatementWithRecovery() (ast.Statement, bool) {
+	startDepth := p.depth
+	statement := p.parseStatement()
+	if p.unrecovered > 0 {
+		// 嵌套的块中的错误已经在块中恢复了，这里只处理本语句的错误
+		p.unrecovered = 0
+		return nil, p.synchronize(startDepth)
+	}
+	return statement, false
+}
+
+// 出错后跳过 token，直到语句的边界
+// 语句的边界是：当前 token 是 ;，下一个 token 是 }、let、return、fn 或循环的关键字，
+// 或者跳过了一个完整的 {} 块。跳过时会跳过嵌套的 {} 块中的所有 token
+// startDepth 是语句开始时没有闭合的 { 的数量，用于区分语句中的 } 和所在块的 }
+// 结束时当前 token 是出错语句的最后一个 token，调用方调用 nextToken 后就来到下一个语句的开头
+// 如果当前 token 是所在块的 }，说明出错的语句被块的结尾截断了，这时停在 } 上并返回 true
+func (p *Parser) synchronize(startDepth int) bool {
+	for !p.currTokenIs(token.EOF) {
+		// 包括当前 token 在内没有闭合的 { 的数量
+		depth := p.depth
+		switch p.currToken.Type {
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			if p.depth <= startDepth {
+				return true
+			}
+			depth--
+			// 跳过了一个完整的块，块后面是 else 或 ; 时，它们也属于出错的语句
+			if depth == startDepth && !p.peekTokenIs(token.ELSE) && !p.peekTokenIs(token.SEMICOLON) {
+				return false
+			}
+		case token.SEMICOLON:
+			if depth == startDepth {
+				return false
+			}
+		}
+		if depth == startDepth && isStatementBoundary(p.peekToken.Type) {
+			return false
+		}
+		p.nextToken()
+	}
+	return false
+}
+
+// 判断 token 是不是语句的边界，出错后解析器从这些 token 开始继续解析
+func isStatementBoundary(t token.TokenType) bool {
+	switch t {
+	case token.RBRACE, token.LET, token.RETURN, token.FUNCTION, token.EOF,
+		token.WHILE, token.FOR, token.BREAK, token.CONTINUE:
+		return true
+	}
+	return false
+}
+
 // 注册前缀表达式
 // 注册identifier，int，float，string，!，-，true，false，(，if，fn，[，{
 func (p *Parser) registerPrefix(tokenType token.TokenType, fn prefixParseFn) {
@@ -170,9 +286,14 @@ func (p *Parser) parseIdentifier() ast.Expression {
 // 注意：解析整数时，使用int64存储整数值
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	value, err := strconv.ParseInt(p.currToken.Literal, 0, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		msg := fmt.Sprintf("integer literal %s overflows int64, max is %d", p.currToken.Literal, math.MaxInt64)
+		p.appendError(NUMBER_OUT_OF_RANGE, p.currToken, msg)
+		return nil
+	}
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %s as integer", p.currToken.Literal)
-		p.appendError(msg)
+		msg := fmt.Sprintf("invalid integer literal %s", p.currToken.Literal)
+		p.appendError(INVALID_INTEGER, p.currToken, msg)
 		return nil
 	}
 
@@ -186,9 +307,14 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 // 注意：解析浮点数时，使用float64存储浮点数数值
 func (p *Parser) parseFloatLiteral() ast.Expression {
 	value, err := strconv.ParseFloat(p.currToken.Literal, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		msg := fmt.Sprintf("float literal %s is out of range for float64", p.currToken.Literal)
+		p.appendError(NUMBER_OUT_OF_RANGE, p.currToken, msg)
+		return nil
+	}
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %s as float", p.currToken.Literal)
-		p.appendError(msg)
+		msg := fmt.Sprintf("invalid float literal %s", p.currToken.Literal)
+		p.appendError(INVALID_FLOAT, p.currToken, msg)
 		return nil
 	}
 
@@ -203,6 +329,58 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.currToken, Value: p.currToken.Literal}
 }
 
+// 解析插值字符串，如 "a ${b} c"
+// 当前 token 是 INTERP_START，之后是插值表达式和 INTERP_MIDDLE 交替出现，最后是 INTERP_END
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	expression := &ast.InterpolatedString{Token: p.currToken}
+	hasEmpty := false
+	for {
+		// 字符串片段，空的片段不需要求值
+		if p.currToken.Literal != "" {
+			expression.Parts = append(expression.Parts, &ast.StringLiteral{Token: p.currToken, Value: p.currToken.Literal})
+		}
+		if p.currTokenIs(token.INTERP_END) {
+			if hasEmpty {
+				return nil
+			}
+			expression.EndToken = p.currToken
+			return expression
+		}
+		// ${ 之后直接是 }，报告空的插值，位置从 ${ 开始到 } 结束，
+		// 然后继续解析字符串剩下的部分，这样字符串之后的 token 不会产生多余的错误
+		if p.peekTokenIs(token.INTERP_MIDDLE) || p.peekTokenIs(token.INTERP_END) {
+			diagnostic := p.appendError(EMPTY_INTERPOLATION, p.currToken, "empty interpolation")
+			diagnostic.Pos = p.currToken.End
+			diagnostic.Pos.Column -= 2
+			diagnostic.Pos.Offset -= 2
+			diagnostic.End = p.peekToken.Pos
+			diagnostic.End.Column++
+			diagnostic.End.Offset++
+			hasEmpty = true
+			p.nextToken()
+			continue
+		}
+		// 跳过字符串片段，解析插值表达式
+		// 插值表达式中有错误时不再继续解析，避免再报告缺少 } 的错误
+		p.nextToken()
+		unrecovered := p.unrecovered
+		part := p.parseExpression(LOWEST)
+		if part == nil || p.unrecovered > unrecovered {
+			return nil
+		}
+		expression.Parts = append(expression.Parts, part)
+		// 插值表达式之后只能是 } 和字符串剩下的部分
+		if !p.peekTokenIs(token.INTERP_MIDDLE) && !p.peekTokenIs(token.INTERP_END) {
+			msg := fmt.Sprintf("expected } to close string interpolation, got %s instead", p.peekToken.Type)
+			diagnostic := p.appendError(UNEXPECTED_TOKEN, p.peekToken, msg)
+			diagnostic.Expected = []token.TokenType{token.INTERP_MIDDLE, token.INTERP_END}
+			diagnostic.Found = p.peekToken.Type
+			return nil
+		}
+		p.nextToken()
+	}
+}
+
 // 解析前缀表达式
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	// 当前token是前缀运算符 '-' 和 '!'
@@ -255,7 +433,8 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 // 没有前缀表达式解析函数错误
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
 	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	diagnostic := p.appendError(NO_PREFIX_PARSE_FN, p.currToken, msg)
+	diagnostic.Found = t
 }
 
 // 获取当前token的优先级，在precedences中匹配不到的话就是最低优先级
@@ -294,8 +473,11 @@ func (p *Parser) parseGroupedExpression() ast.Expression {
 	// 跳过(
 	p.nextToken()
 
-	// 解析括号中的表达式
+	// 解析括号中的表达式，表达式有错误时已经记录了错误，直接返回nil
 	expression := p.parseExpression(LOWEST)
+	if expression == nil {
+		return nil
+	}
 
 	// 如果是以)结尾，则跳过，否则记录错误，并返回nil
 	if !p.expectPeek(token.RPAREN) {
@@ -333,7 +515,7 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	expression.Consequence = p.parseBlockStatement()
 	// 验证当前token是不是}
 	if !p.currTokenIs(token.RBRACE) {
-		p.appendError(fmt.Sprintf("expected }, but got %s", p.currToken.Type))
+		p.missingRbraceError()
 		return nil
 	}
 
@@ -349,7 +531,7 @@ func (p *Parser) parseIfExpression() ast.Expression {
 		expression.Alternative = p.parseBlockStatement()
 		// 验证当前token是不是}
 		if !p.currTokenIs(token.RBRACE) {
-			p.appendError(fmt.Sprintf("expected }, but got %s", p.currToken.Type))
+			p.missingRbraceError()
 			return nil
 		}
 	}
@@ -357,6 +539,14 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return expression
 }
 
+// 块语句没有以}结尾时，记录错误
+func (p *Parser) missingRbraceError() {
+	msg := fmt.Sprintf("expected }, but got %s", p.currToken.Type)
+	diagnostic := p.appendError(UNEXPECTED_TOKEN, p.currToken, msg)
+	diagnostic.Expected = []token.TokenType{token.RBRACE}
+	diagnostic.Found = p.currToken.Type
+}
+
 // 解析块语句，块语句就是在if-else和函数体中的语句
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	// 创建块语句
@@ -371,15 +561,21 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	// 如果当前token不是}或是EOF，则继续解析语句
 	for !p.currTokenIs(token.RBRACE) && !p.currTokenIs(token.EOF) {
 		// 解析语句，在holiya中，语句只有let语句，return语句和表达式语句
-		statement := p.parseStatement()
-		// statement可能会返回空
+		statement, blockEnd := p.parseStatementWithRecovery()
+		// 语句有错误时会返回空
 		if statement != nil {
 			// 添加语句
 			block.Statements = append(block.Statements, statement)
 		}
+		// 出错的语句停在了块的 } 上，块已经结束
+		if blockEnd {
+			break
+		}
 		// 跳过每个语句的结尾，来到下一个语句的开头
 		p.nextToken()
 	}
+	// 记录 }
+	block.Rbrace = p.currToken
 	return block
 }
 
@@ -387,11 +583,37 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.currToken.Type {
 	case token.LET:
+		// 解析解构的let语句，如 let #(x, y) = point;
+		if p.peekTokenIs(token.TUPLE_START) {
+			if statement := p.parseLetTupleStatement(); statement != nil {
+				return statement
+			}
+			return nil
+		}
 		// 解析let语句
-		return p.parseLetStatement()
+		// 注意：不能直接返回 *ast.LetStatement 类型的 nil，否则得到的接口值不等于 nil
+		if statement := p.parseLetStatement(); statement != nil {
+			return statement
+		}
+		return nil
 	case token.RETURN:
 		// 解析return语句
-		return p.parseReturnStatement()
+		if statement := p.parseReturnStatement(); statement != nil {
+			return statement
+		}
+		return nil
+	case token.WHILE:
+		// 解析while语句
+		if statement := p.parseWhileStatement(); statement != nil {
+			return statement
+		}
+		return nil
+	case token.FOR:
+		// 解析for语句，可能是C风格的for语句，也可能是for-in语句
+		return p.parseForStatement()
+	case token.BREAK, token.CONTINUE:
+		// 解析break和continue语句
+		return p.parseLoopControlStatement()
 	default:
 		// 解析表达式语句
 		return p.parseExpressionStatement()
@@ -400,8 +622,8 @@ func (p *Parser) parseStatement() ast.Statement {
 
 // 解析let语句
 func (p *Parser) parseLetStatement() *ast.LetStatement {
-	// 当前token是let
-	statement := &ast.LetStatement{Token: p.currToken}
+	// 当前token是let，let 之前的文档注释属于这条语句
+	statement := &ast.LetStatement{Token: p.currToken, Doc: p.currToken.Doc}
 
 	// 如果下一个token不是标识符，则记录错误并返回nil
 	if !p.expectPeek(token.IDENTIFIER) {
@@ -422,8 +644,52 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	// 解析表达式，并保存到statement中
 	statement.Value = p.parseExpression(LOWEST)
 
+	// 函数字面量直接赋给变量时，变量名就是函数的名字，用于错误信息
+	if fn, ok := statement.Value.(*ast.FunctionLiteral); ok {
+		fn.Name = statement.Name.Value
+	}
+
 	// 如果下一个token是;，则跳过
-	if p.peekTokenIs(token.SEMICOLON) {
+	// 表达式有错误时停在出错的位置，由 synchronize 跳到语句边界
+	if p.unrecovered == 0 && p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return statement
+}
+
+// 解析解构的let语句，如 let #(x, y) = point;
+func (p *Parser) parseLetTupleStatement() *ast.LetTupleStatement {
+	// 当前token是let
+	statement := &ast.LetTupleStatement{Token: p.currToken}
+
+	// 跳到#(
+	p.nextToken()
+
+	// 至少要有一个变量名，变量名之间用,分隔
+	for {
+		if !p.expectPeek(token.IDENTIFIER) {
+			return nil
+		}
+		statement.Names = append(statement.Names, &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal})
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RPAREN) || !p.expectPeek(token.ASSIGN) {
+		return nil
+	}
+
+	// 跳过=
+	p.nextToken()
+
+	// 解析表达式，并保存到statement中
+	statement.Value = p.parseExpression(LOWEST)
+
+	// 如果下一个token是;，则跳过
+	if p.unrecovered == 0 && p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
@@ -441,6 +707,11 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	// 解析表达式
 	statement.ReturnValue = p.parseExpression(LOWEST)
 
+	// 表达式有错误时，不再报告缺少;的错误
+	if p.unrecovered > 0 {
+		return nil
+	}
+
 	// 如果下一个token不是;，则记录错误并返回nil
 	if !p.expectPeek(token.SEMICOLON) {
 		return nil
@@ -449,6 +720,177 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	return statement
 }
 
+// 解析while语句，如 while (x < 10) { x += 1; }
+func (p *Parser) parseWhileStatement() *ast.WhileStatement {
+	// 当前token是while
+	statement := &ast.WhileStatement{Token: p.currToken}
+
+	// 验证下一个token是不是(
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	// 跳过(
+	p.nextToken()
+	// 解析循环条件
+	statement.Condition = p.parseExpression(LOWEST)
+	// 验证下一个token是不是)
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	statement.Body = p.parseLoopBody()
+	if statement.Body == nil {
+		return nil
+	}
+
+	return statement
+}
+
+// 解析for语句
+// ( 之后是标识符和 in 时是 for-in 语句，如 for (x in array) { ... }，
+// 否则是C风格的for语句，如 for (let i = 0; i < 10; i += 1) { ... }
+func (p *Parser) parseForStatement() ast.Statement {
+	// 当前token是for
+	forToken := p.currToken
+
+	// 验证下一个token是不是(
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	// 跳过(
+	p.nextToken()
+
+	if p.currTokenIs(token.IDENTIFIER) && p.peekTokenIs(token.IN) {
+		if statement := p.parseForInStatement(forToken); statement != nil {
+			return statement
+		}
+		return nil
+	}
+
+	statement := &ast.ForStatement{Token: forToken}
+
+	// 解析初始化语句，解析完成后当前token是;
+	if !p.currTokenIs(token.SEMICOLON) {
+		if p.currTokenIs(token.LET) {
+			// let 语句会跳过后面的;
+			init := p.parseLetStatement()
+			if init == nil {
+				return nil
+			}
+			statement.Init = init
+			if !p.currTokenIs(token.SEMICOLON) {
+				p.peekError(token.SEMICOLON)
+				return nil
+			}
+		} else {
+			init := &ast.ExpressionStatement{Token: p.currToken}
+			init.Expression = p.parseExpression(LOWEST)
+			if init.Expression == nil || !p.expectPeek(token.SEMICOLON) {
+				return nil
+			}
+			statement.Init = init
+		}
+	}
+
+	// 跳过;，解析循环条件，解析完成后当前token是;
+	p.nextToken()
+	if !p.currTokenIs(token.SEMICOLON) {
+		statement.Condition = p.parseExpression(LOWEST)
+		if statement.Condition == nil || !p.expectPeek(token.SEMICOLON) {
+			return nil
+		}
+	}
+
+	// 跳过;，解析每次循环后执行的表达式，解析完成后当前token是)
+	p.nextToken()
+	if !p.currTokenIs(token.RPAREN) {
+		statement.Post = p.parseExpression(LOWEST)
+		if statement.Post == nil || !p.expectPeek(token.RPAREN) {
+			return nil
+		}
+	}
+
+	statement.Body = p.parseLoopBody()
+	if statement.Body == nil {
+		return nil
+	}
+
+	return statement
+}
+
+// 解析for-in语句，当前token是循环变量
+func (p *Parser) parseForInStatement(forToken token.Token) *ast.ForInStatement {
+	statement := &ast.ForInStatement{Token: forToken}
+	statement.Variable = &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+
+	// 跳过循环变量和in
+	p.nextToken()
+	p.nextToken()
+
+	// 解析被遍历的表达式
+	statement.Iterable = p.parseExpression(LOWEST)
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	statement.Body = p.parseLoopBody()
+	if statement.Body == nil {
+		return nil
+	}
+
+	return statement
+}
+
+// 解析循环体，当前token是循环体之前的)
+// 循环体之后的;可以省略
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	// 验证下一个token是不是{
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	p.loopDepth++
+	body := p.parseBlockStatement()
+	p.loopDepth--
+
+	// 验证当前token是不是}
+	if !p.currTokenIs(token.RBRACE) {
+		p.missingRbraceError()
+		return nil
+	}
+
+	// 如果下一个token是;，则跳过
+	if p.unrecovered == 0 && p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return body
+}
+
+// 解析break和continue语句，语句之后的;可以省略
+// break和continue只能出现在循环中
+func (p *Parser) parseLoopControlStatement() ast.Statement {
+	var statement ast.Statement
+	if p.currTokenIs(token.BREAK) {
+		statement = &ast.BreakStatement{Token: p.currToken}
+	} else {
+		statement = &ast.ContinueStatement{Token: p.currToken}
+	}
+
+	if p.loopDepth == 0 {
+		msg := fmt.Sprintf("%s statement outside loop", p.currToken.Literal)
+		p.appendError(OUTSIDE_LOOP, p.currToken, msg)
+		return nil
+	}
+
+	// 如果下一个token是;，则跳过
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return statement
+}
+
 // 表达式语句，类似foobar;
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	// 创建表达式语句
@@ -458,7 +900,8 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	statement.Expression = p.parseExpression(LOWEST)
 
 	// 如果下一个token;，则跳过
-	if p.peekTokenIs(token.SEMICOLON) {
+	// 表达式有错误时停在出错的位置，由 synchronize 跳到语句边界
+	if p.unrecovered == 0 && p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
@@ -467,69 +910,108 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 
 // 解析函数
 func (p *Parser) parseFunctionLiteral() ast.Expression {
-	// 创建函数表达式
-	fnExpression := &ast.FunctionLiteral{Token: p.currToken}
+	// 创建函数表达式，fn 之前的文档注释属于这个函数
+	fnExpression := &ast.FunctionLiteral{Token: p.currToken, Doc: p.currToken.Doc}
 
 	// 如果下一个token不是(，则记录错误并返回nil
 	if !p.expectPeek(token.LPAREN) {
 		return nil
 	}
 
-	// 解析函数的参数列表
-	parameters := p.parseFunctionParameters()
-
-	// 如果参数列表为nil，则返回nil
-	if parameters == nil {
+	// 解析函数的参数列表，参数有错误时返回nil
+	if !p.parseFunctionParameters(fnExpression) {
 		return nil
 	}
 
-	fnExpression.Parameters = parameters
-
 	// 如果下一个token不是{，则记录错误并返回nil
 	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
 
-	// 解析函数的函数体
+	// 解析函数的函数体，函数体中的 break 和 continue 不能跳出函数外的循环
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
 	fnExpression.Body = p.parseBlockStatement()
+	p.loopDepth = loopDepth
+	// 验证当前token是不是}
+	if !p.currTokenIs(token.RBRACE) {
+		p.missingRbraceError()
+		return nil
+	}
 
 	return fnExpression
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
+// 解析函数的参数列表，结果保存到 fn 中，参数有错误时返回 false
+// 参数的形式有三种：
+//   - 普通参数，如 a
+//   - 有默认值的参数，如 b = 2，之后的普通参数也必须有默认值
+//   - 剩余参数，如 ...rest，只能是最后一个参数
+func (p *Parser) parseFunctionParameters(fn *ast.FunctionLiteral) bool {
 	// 创建一个空数组，用于存储参数列表
-	identifiers := []*ast.Identifier{}
+	fn.Parameters = []*ast.Identifier{}
+	fn.Defaults = []ast.Expression{}
 
 	// 如果没有参数，则返回空数组
 	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
-		return identifiers
+		return true
 	}
 
-	// 跳过(
-	p.nextToken()
+	for {
+		// 跳过(或,
+		p.nextToken()
 
-	// 第一个参数
-	identifier := &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
-	identifiers = append(identifiers, identifier)
+		// 剩余参数之后只能是)
+		if p.currTokenIs(token.ELLIPSIS) {
+			if !p.expectPeek(token.IDENTIFIER) {
+				return false
+			}
+			fn.Rest = &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
+			if p.peekTokenIs(token.COMMA) {
+				p.appendError(INVALID_PARAMETER, p.peekToken, "rest parameter must be the last parameter")
+				return false
+			}
+			break
+		}
 
-	// 如果下一个token是,，则继续解析下一个参数
-	for p.peekTokenIs(token.COMMA) {
-		// 跳过当前参数所在的token
-		p.nextToken()
-		// 跳过,
-		p.nextToken()
-		// 添加参数
+		if !p.currTokenIs(token.IDENTIFIER) {
+			msg := fmt.Sprintf("expected parameter name, got %s instead", p.currToken.Type)
+			diagnostic := p.appendError(UNEXPECTED_TOKEN, p.currToken, msg)
+			diagnostic.Expected = []token.TokenType{token.IDENTIFIER}
+			diagnostic.Found = p.currToken.Type
+			return false
+		}
 		identifier := &ast.Identifier{Token: p.currToken, Value: p.currToken.Literal}
-		identifiers = append(identifiers, identifier)
-	}
 
-	// 如果下一个token不是)，则记录错误并返回nil
-	if !p.expectPeek(token.RPAREN) {
-		return nil
+		// 解析参数的默认值
+		var defaultValue ast.Expression
+		if p.peekTokenIs(token.ASSIGN) {
+			// 跳过参数名和=
+			p.nextToken()
+			p.nextToken()
+			defaultValue = p.parseExpression(LOWEST)
+			if defaultValue == nil {
+				return false
+			}
+		} else if len(fn.Defaults) > 0 && fn.Defaults[len(fn.Defaults)-1] != nil {
+			msg := fmt.Sprintf("parameter %s without default value follows parameter with default value", identifier.Value)
+			p.appendError(INVALID_PARAMETER, identifier.Token, msg)
+			return false
+		}
+		fn.Parameters = append(fn.Parameters, identifier)
+		fn.Defaults = append(fn.Defaults, defaultValue)
+
+		// 如果下一个token是,，则继续解析下一个参数
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		// 跳过当前参数的最后一个token，当前token是,
+		p.nextToken()
 	}
 
-	return identifiers
+	// 如果下一个token不是)，则记录错误并返回false
+	return p.expectPeek(token.RPAREN)
 }
 
 // 解析数组
@@ -543,11 +1025,29 @@ func (p *Parser) parseArrayLiteral() ast.Expression {
 		return nil
 	}
 	arrayExpression.Elements = list
+	// parseExpressionList 结束时当前 token 是 ]
+	arrayExpression.Rbracket = p.currToken
 
 	return arrayExpression
 }
 
-// 解析表达式列表，有数组和调用表达式的实参列表
+// 解析元组，如 #(1, "a")
+func (p *Parser) parseTupleLiteral() ast.Expression {
+	// 创建元组表达式
+	tupleExpression := &ast.TupleLiteral{Token: p.currToken}
+
+	list := p.parseExpressionList(token.RPAREN)
+	if list == nil {
+		return nil
+	}
+	tupleExpression.Elements = list
+	// parseExpressionList 结束时当前 token 是 )
+	tupleExpression.Rparen = p.currToken
+
+	return tupleExpression
+}
+
+// 解析表达式列表，有数组、元组和调用表达式的实参列表
 func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	// 创建一个空数组，用于存储表达式列表
 	list := []ast.Expression{}
@@ -561,8 +1061,11 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	// 跳过[或(
 	p.nextToken()
 
-	// 第一个表达式
+	// 第一个表达式，表达式有错误时已经记录了错误，直接返回nil
 	expression := p.parseExpression(LOWEST)
+	if expression == nil {
+		return nil
+	}
 	list = append(list, expression)
 
 	// 如果下一个token是,，则继续解析下一个表达式
@@ -571,8 +1074,11 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 		p.nextToken()
 		// 调到下一个表达式所在的token
 		p.nextToken()
-		// 解析表达式
+		// 解析表达式，有错误时返回nil
 		expression := p.parseExpression(LOWEST)
+		if expression == nil {
+			return nil
+		}
 		// 添加表达式
 		list = append(list, expression)
 	}
@@ -590,28 +1096,36 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 	// 创建哈希表达式
 	hashExpression := &ast.HashLiteral{Token: p.currToken}
 
-	// 创建一个空map，用于存储键值对
-	hashExpression.Pairs = make(map[ast.Expression]ast.Expression)
+	// 创建一个空切片，按源码中的顺序存储键值对
+	hashExpression.Pairs = []ast.HashPair{}
 
 	// 如果下一个token不是}，继续解析
 	for !p.peekTokenIs(token.RBRACE) {
 		// 跳过第一个token { 或,
 		p.nextToken()
-		// 解析键
+		// 解析键，有错误时返回nil
 		key := p.parseExpression(LOWEST)
+		if key == nil {
+			return nil
+		}
 		// 如果下一个token不是:，则记录错误并返回nil
 		if !p.expectPeek(token.COLON) {
 			return nil
 		}
 		// 跳过:
 		p.nextToken()
-		// 解析值
+		// 解析值，有错误时返回nil
 		value := p.parseExpression(LOWEST)
+		if value == nil {
+			return nil
+		}
 		// 添加键值对
-		hashExpression.Pairs[key] = value
+		hashExpression.Pairs = append(hashExpression.Pairs, ast.HashPair{Key: key, Value: value})
 		// 如果下一个token不是},，则记录错误并返回nil
 		if !p.peekTokenIs(token.RBRACE) && !p.peekTokenIs(token.COMMA) {
-			p.appendError("Expected comma or right brace after hash pair")
+			diagnostic := p.appendError(INVALID_HASH_PAIR, p.peekToken, "Expected comma or right brace after hash pair")
+			diagnostic.Expected = []token.TokenType{token.COMMA, token.RBRACE}
+			diagnostic.Found = p.peekToken.Type
 			return nil
 		}
 		// 跳过,
@@ -621,6 +1135,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 	}
 	// 跳过}
 	p.nextToken()
+	hashExpression.Rbrace = p.currToken
 
 	return hashExpression
 }
@@ -642,10 +1157,18 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 
 	// 获取当前token的优先级
 	precedence := p.currPrecedence()
+	// 右结合的运算符用低一级的优先级解析右边的表达式，
+	// 这样右边遇到同样的运算符时会继续往右解析
+	if rightAssociative[p.currToken.Type] {
+		precedence--
+	}
 	// 跳过中缀运算符
 	p.nextToken()
-	// 解析中缀运算符右边的表达式
+	// 解析中缀运算符右边的表达式，右边有错误时返回nil
 	expression.Right = p.parseExpression(precedence)
+	if expression.Right == nil {
+		return nil
+	}
 
 	return expression
 }
@@ -661,6 +1184,8 @@ func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 		return nil
 	}
 	callExpression.Arguments = arguments
+	// parseExpressionList 结束时当前 token 是 )
+	callExpression.Rparen = p.currToken
 
 	return callExpression
 }
@@ -684,19 +1209,125 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
+	indexExpression.Rbracket = p.currToken
 
 	return indexExpression
 }
 
+// 解析赋值表达式，如 x = 5，x += 1，array[0] = 1
+// 参数是赋值运算符左边的表达式，只能是标识符或者索引表达式
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	expression := &ast.AssignExpression{
+		Token:    p.currToken,
+		Target:   target,
+		Operator: p.currToken.Literal,
+	}
+
+	switch target.(type) {
+	case *ast.Identifier, *ast.IndexExpression:
+	case nil:
+		// 左边的表达式解析出错，错误已经记录过了
+		return nil
+	default:
+		msg := fmt.Sprintf("cannot assign to %s", target.String())
+		diagnostic := p.appendError(INVALID_ASSIGN_TARGET, p.currToken, msg)
+		diagnostic.Pos = target.Pos()
+		diagnostic.End = target.End()
+		return nil
+	}
+
+	// 跳过赋值运算符
+	p.nextToken()
+	// 赋值是右结合的，a = b = 1 等于 a = (b = 1)
+	value := p.parseExpression(ASSIGN - 1)
+	if value == nil {
+		return nil
+	}
+	expression.Value = value
+
+	return expression
+}
+
 // 获取下一个token，currToken指向下一个token，peekToken指向下两个token
 func (p *Parser) nextToken() {
+	// 越过 { 和 } 时更新没有闭合的 { 的数量
+	switch p.currToken.Type {
+	case token.LBRACE:
+		p.depth++
+	case token.RBRACE:
+		if p.depth > 0 {
+			p.depth--
+		}
+	}
 	p.currToken = p.peekToken
 	p.peekToken = p.l.NextToken()
+	p.addLexerErrors()
+}
+
+// 把词法分析器新产生的错误转换为诊断信息
+func (p *Parser) addLexerErrors() {
+	lexerErrors := p.l.Errors()
+	for ; p.lexerErrors < len(lexerErrors); p.lexerErrors++ {
+		err := lexerErrors[p.lexerErrors]
+		// 没有闭合的字符串和格式不合法的数字是非法的 token，转义序列不合法的字符串仍然是字符串，
+		// 不合法的 UTF-8 字节可能出现在字符串、注释或代码中，注释不是 token，都不记录 token 类型
+		var code Code
+		var found token.TokenType
+		switch err.Kind {
+		case lexer.UNTERMINATED_STRING:
+			code, found = UNTERMINATED_STRING, token.ILLEGAL
+		case lexer.INVALID_ESCAPE:
+			code, found = INVALID_ESCAPE, token.STRING
+		case lexer.INVALID_UTF8:
+			code = INVALID_UTF8
+		case lexer.UNTERMINATED_COMMENT:
+			code = UNTERMINATED_COMMENT
+		case lexer.INVALID_INTEGER:
+			code, found = INVALID_INTEGER, token.ILLEGAL
+		case lexer.INVALID_FLOAT:
+			code, found = INVALID_FLOAT, token.ILLEGAL
+		}
+		p.errors.Add(&Diagnostic{
+			Severity: ERROR,
+			Code:     code,
+			Pos:      err.Pos,
+			End:      err.End,
+			Message:  err.Message,
+			Found:    found,
+		})
+	}
+}
+
+// 判断词法分析器是否已经在 pos 处报告过错误
+func (p *Parser) hasLexerErrorAt(pos token.Position) bool {
+	for _, diagnostic := range p.errors {
+		if diagnostic.Pos != pos {
+			continue
+		}
+		switch diagnostic.Code {
+		case UNTERMINATED_STRING, INVALID_ESCAPE, INVALID_INTEGER, INVALID_FLOAT:
+			return true
+		}
+	}
+	return false
 }
 
-// 添加错误信息到错误列表
-func (p *Parser) appendError(errorMessage string) {
-	p.errors = append(p.errors, errorMessage)
+// 添加错误级别的诊断信息到错误列表，诊断信息的位置是 tok 的位置
+// 返回添加的诊断信息，调用方可以继续补充期望的 token 和修复建议
+// tok 是词法分析器已经报告过错误的非法 token 时，不再重复报告
+func (p *Parser) appendError(code Code, tok token.Token, errorMessage string) *Diagnostic {
+	diagnostic := &Diagnostic{
+		Severity: ERROR,
+		Code:     code,
+		Pos:      tok.Pos,
+		End:      tok.End,
+		Message:  errorMessage,
+	}
+	if tok.Type != token.ILLEGAL || !p.hasLexerErrorAt(tok.Pos) {
+		p.errors.Add(diagnostic)
+	}
+	p.unrecovered++
+	return diagnostic
 }
 
 // 判断下一个token与给出的token的类型是否相等，相等的话，跳过当前token
@@ -714,10 +1345,32 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 // 注意：词法解析应该要尽量得搜集更多的错误
 func (p *Parser) peekError(t token.TokenType) {
 	msg := fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type)
-	p.appendError(msg)
+	diagnostic := p.appendError(UNEXPECTED_TOKEN, p.peekToken, msg)
+	diagnostic.Expected = []token.TokenType{t}
+	diagnostic.Found = p.peekToken.Type
+	// 缺少的是分隔符时，建议在下一个 token 之前插入该分隔符
+	if isPunctuation(t) {
+		diagnostic.FixIts = append(diagnostic.FixIts, FixIt{
+			Message: fmt.Sprintf("insert %q", string(t)),
+			Pos:     p.peekToken.Pos,
+			End:     p.peekToken.Pos,
+			NewText: string(t),
+		})
+	}
 }
 
-// Errors 辅助函数 返回token解析的错误
-func (p *Parser) Errors() []string {
+// Errors 辅助函数 返回token解析的诊断信息
+func (p *Parser) Errors() ErrorList {
 	return p.errors
 }
+
+// isPunctuation 判断 token 类型是不是分隔符或赋值符号
+// 这些 token 的类型和字面量相同，可以直接作为修复建议插入
+func isPunctuation(t token.TokenType) bool {
+	switch t {
+	case token.ASSIGN, token.LPAREN, token.RPAREN, token.LBRACE, token.RBRACE,
+		token.LBRACKET, token.RBRACKET, token.COMMA, token.SEMICOLON, token.COLON:
+		return true
+	}
+	return false
+}
@@ -5,6 +5,7 @@ import (
 	"holiya/ast"
 	"holiya/lexer"
 	"holiya/token"
+	"strings"
 	"testing"
 )
 
@@ -99,6 +100,45 @@ func TestParseIntegerLiteral(t *testing.T) {
 			expectedString: "",
 			expectError:    true,
 		},
+		{
+			tokenLiteral:   "0xFF",
+			expectedValue:  255,
+			expectedString: "0xFF",
+		},
+		{
+			tokenLiteral:   "0o17",
+			expectedValue:  15,
+			expectedString: "0o17",
+		},
+		{
+			tokenLiteral:   "0b1010",
+			expectedValue:  10,
+			expectedString: "0b1010",
+		},
+		{
+			tokenLiteral:   "1_000_000",
+			expectedValue:  1000000,
+			expectedString: "1_000_000",
+		},
+		{
+			// 下划线只能出现在数字之间
+			tokenLiteral: "1__000",
+			expectError:  true,
+		},
+		{
+			tokenLiteral: "1_",
+			expectError:  true,
+		},
+		{
+			// 二进制中不能有 2
+			tokenLiteral: "0b102",
+			expectError:  true,
+		},
+		{
+			// 超过 int64 最大值
+			tokenLiteral: "9223372036854775808",
+			expectError:  true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -108,7 +148,7 @@ func TestParseIntegerLiteral(t *testing.T) {
 		}
 		parser := &Parser{
 			currToken: tok,
-			errors:    []string{},
+			errors:    ErrorList{},
 		}
 		result := parser.parseIntegerLiteral()
 		if tt.expectError {
@@ -199,6 +239,26 @@ func TestParseFloatLiteral(t *testing.T) {
 			expectedString: "",
 			expectError:    true,
 		},
+		{
+			tokenLiteral:   "1.5e-3",
+			expectedValue:  0.0015,
+			expectedString: "1.5e-3",
+		},
+		{
+			tokenLiteral:   "2E10",
+			expectedValue:  2e10,
+			expectedString: "2E10",
+		},
+		{
+			tokenLiteral:   "1_000.000_1",
+			expectedValue:  1000.0001,
+			expectedString: "1_000.000_1",
+		},
+		{
+			// 超过 float64 的范围
+			tokenLiteral: "1e400",
+			expectError:  true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -208,7 +268,7 @@ func TestParseFloatLiteral(t *testing.T) {
 		}
 		parser := &Parser{
 			currToken: tok,
-			errors:    []string{},
+			errors:    ErrorList{},
 		}
 		result := parser.parseFloatLiteral()
 		if tt.expectError {
@@ -294,6 +354,119 @@ func TestParseStringLiteral(t *testing.T) {
 	}
 }
 
+// 测试解析插值字符串
+func TestParseInterpolatedString(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedParts int
+		expected      string
+	}{
+		{`"hello ${name}!"`, 3, `"hello ${name}!"`},
+		{`"${a}${b}"`, 2, `"${a}${b}"`},
+		{`"sum: ${1 + 2 * 3}, len: ${len(items)}"`, 4, `"sum: ${(1 + (2 * 3))}, len: ${len(items)}"`},
+		{`"${ "x${y}" }"`, 1, `"${"x${y}"}"`},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		program := parser.ParseProgram()
+		if len(parser.Errors()) != 0 {
+			t.Fatalf("unexpected errors for input %q: %v", tt.input, parser.Errors())
+		}
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		str, ok := stmt.Expression.(*ast.InterpolatedString)
+		if !ok {
+			t.Fatalf("expression is not *ast.InterpolatedString. got=%T", stmt.Expression)
+		}
+		if len(str.Parts) != tt.expectedParts {
+			t.Errorf("len(str.Parts) = %d, want %d for input %q", len(str.Parts), tt.expectedParts, tt.input)
+		}
+		if str.String() != tt.expected {
+			t.Errorf("str.String() = %q, want %q", str.String(), tt.expected)
+		}
+		if str.Pos().Offset != 0 || str.End().Offset != len(tt.input) {
+			t.Errorf("wrong position for %q. got=%d..%d, want=0..%d", tt.input, str.Pos().Offset, str.End().Offset, len(tt.input))
+		}
+	}
+}
+
+// 测试插值字符串的错误
+func TestParseInterpolatedStringErrors(t *testing.T) {
+	tests := []struct {
+		input          string
+		expected       string
+		expectedErrors int
+	}{
+		{`"a ${b c}"`, "1:8: expected } to close string interpolation, got IDENTIFIER instead", 1},
+		{`"a ${}"`, "1:4: empty interpolation", 1},
+		{`"a ${ } b"`, "1:4: empty interpolation", 1},
+		{`"${1}${}"`, "1:6: empty interpolation", 1},
+		{`puts("${}", 2)`, "1:7: empty interpolation", 1},
+		{`"${}${}"`, "1:2: empty interpolation", 2},
+		{"\"a ${b} c\n", "1:7: string literal not terminated", 1},
+		{`let s = "a ${`, "1:9: string literal not terminated", 1},
+		{`"a ${b} c ${d + `, "1:1: string literal not terminated", 1},
+		{`"${1 + }"`, "1:8: no prefix parse function for INTERP_END found", 1},
+		{`"${-}"`, "1:5: no prefix parse function for INTERP_END found", 1},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		parser.ParseProgram()
+		errors := parser.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected errors for input %q, got none", tt.input)
+		}
+		if errors[0].Error() != tt.expected {
+			t.Errorf("errors[0].Error() = %q, want %q for input %q", errors[0].Error(), tt.expected, tt.input)
+		}
+		if len(errors) != tt.expectedErrors {
+			t.Errorf("got %d errors, want %d for input %q: %v", len(errors), tt.expectedErrors, tt.input, errors)
+		}
+	}
+}
+
+// 测试文档注释附加到 let 语句和函数字面量上
+func TestParseDocComments(t *testing.T) {
+	input := `/// 两个数的和
+/// 参数可以是整数或浮点数
+let add = fn(a, b) { a + b };
+let apply = /// 回调函数
+fn(f) { f() };
+/* 块注释 */ let x = 1;`
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("unexpected errors: %v", p.Errors())
+	}
+	if len(program.Statements) != 3 {
+		t.Fatalf("len(program.Statements) = %d, want 3", len(program.Statements))
+	}
+
+	add := program.Statements[0].(*ast.LetStatement)
+	if add.Doc.Text() != "两个数的和\n参数可以是整数或浮点数" {
+		t.Errorf("add.Doc.Text() = %q", add.Doc.Text())
+	}
+	expected := "/// 两个数的和\n/// 参数可以是整数或浮点数\nlet add = fn(a, b)(a + b);"
+	if add.String() != expected {
+		t.Errorf("add.String() = %q, want %q", add.String(), expected)
+	}
+
+	apply := program.Statements[1].(*ast.LetStatement)
+	if apply.Doc != nil {
+		t.Errorf("apply.Doc = %+v, want nil", apply.Doc)
+	}
+	fn := apply.Value.(*ast.FunctionLiteral)
+	if fn.Doc.Text() != "回调函数" {
+		t.Errorf("fn.Doc.Text() = %q", fn.Doc.Text())
+	}
+
+	if x := program.Statements[2].(*ast.LetStatement); x.Doc != nil {
+		t.Errorf("x.Doc = %+v, want nil", x.Doc)
+	}
+}
+
 // 测试 parsePrefixExpression 函数
 func TestParsePrefixExpression(t *testing.T) {
 	tests := []struct {
@@ -343,7 +516,7 @@ func TestParsePrefixExpression(t *testing.T) {
 			t.Fatalf("parsePrefixExpression() returned wrong type. Expected *ast.PrefixExpression, got %T", result)
 		}
 
-		if prefixExpression.Token != tt.token {
+		if prefixExpression.Token.Type != tt.token.Type || prefixExpression.Token.Literal != tt.token.Literal {
 			t.Errorf("prefixExpression.Token = %v, want %v", prefixExpression.Token, tt.token)
 		}
 
@@ -410,7 +583,7 @@ func TestParseExpression(t *testing.T) {
 			t.Fatalf("parsePrefixExpression() returned wrong type. Expected *ast.PrefixExpression, got %T", result)
 		}
 
-		if prefixExpression.Token != tt.token {
+		if prefixExpression.Token.Type != tt.token.Type || prefixExpression.Token.Literal != tt.token.Literal {
 			t.Errorf("prefixExpression.Token = %v, want %v", prefixExpression.Token, tt.token)
 		}
 
@@ -435,7 +608,7 @@ func TestNoPrefixParseFnError(t *testing.T) {
 	if len(parser.errors) != 1 {
 		t.Errorf("parser.errors = %v, want 1", parser.errors)
 	}
-	if parser.errors[0] != "no prefix parse function for ! found" {
+	if parser.errors[0].Error() != "no prefix parse function for ! found" {
 		t.Errorf("parser.errors[0] = %v, want 'no prefix parse function for BAND found'", parser.errors[0])
 	}
 }
@@ -455,15 +628,23 @@ func TestCurrPrecedence(t *testing.T) {
 		input    string
 		expected int
 	}{
-		{"=", LOWEST},
+		{"=", ASSIGN},
+		{"+=", ASSIGN},
+		{"%=", ASSIGN},
 		{"==", EQUALS},
 		{"!=", EQUALS},
 		{"+", SUM},
 		{"-", SUM},
 		{"*", PRODUCT},
 		{"/", PRODUCT},
+		{"%", PRODUCT},
+		{"**", POWER},
 		{"<", LESSGREATER},
 		{">", LESSGREATER},
+		{"<=", LESSGREATER},
+		{">=", LESSGREATER},
+		{"&&", LOGICAL_AND},
+		{"||", LOGICAL_OR},
 		{"(", CALL},
 		{"[", INDEX},
 	}
@@ -1262,8 +1443,8 @@ func TestPeekError(t *testing.T) {
 	if len(parser.errors) == 0 {
 		t.Error("expected 1 error, but got 0")
 	}
-	if parser.errors[0] != "expected next token to be FALSE, got TRUE instead" {
-		t.Errorf("parser.errors[0] = %v, want 'expected next token to be FALSE, got TRUE instead'", parser.errors[0])
+	if parser.errors[0].Error() != "1:2: expected next token to be FALSE, got TRUE instead" {
+		t.Errorf("parser.errors[0] = %v, want '1:2: expected next token to be FALSE, got TRUE instead'", parser.errors[0])
 	}
 }
 
@@ -1275,8 +1456,18 @@ func TestErrors(t *testing.T) {
 	if len(parser.Errors()) == 0 {
 		t.Error("expected 1 error, but got 0")
 	}
-	if parser.Errors()[0] != "expected next token to be FALSE, got TRUE instead" {
-		t.Errorf("parser.errors[0] = %v, want 'expected next token to be FALSE, got TRUE instead'", parser.errors[0])
+	diagnostic := parser.Errors()[0]
+	if diagnostic.Error() != "1:2: expected next token to be FALSE, got TRUE instead" {
+		t.Errorf("parser.errors[0] = %v, want '1:2: expected next token to be FALSE, got TRUE instead'", parser.errors[0])
+	}
+	if diagnostic.Severity != ERROR || diagnostic.Code != UNEXPECTED_TOKEN {
+		t.Errorf("diagnostic severity=%s, code=%s, want error and %s", diagnostic.Severity, diagnostic.Code, UNEXPECTED_TOKEN)
+	}
+	if len(diagnostic.Expected) != 1 || diagnostic.Expected[0] != token.FALSE || diagnostic.Found != token.TRUE {
+		t.Errorf("diagnostic expected=%v, found=%s, want [FALSE] and TRUE", diagnostic.Expected, diagnostic.Found)
+	}
+	if diagnostic.Pos.String() != "1:2" || diagnostic.End.String() != "1:6" {
+		t.Errorf("diagnostic span=%s-%s, want 1:2-1:6", diagnostic.Pos, diagnostic.End)
 	}
 }
 
@@ -1970,3 +2161,454 @@ func TestParseProgram(t *testing.T) {
 		}
 	}
 }
+
+// 测试节点的开始位置和结束位置
+func TestNodePosition(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedPos string
+		expectedEnd string
+	}{
+		{"x;", "1:1", "1:2"},
+		{"let x = 5 * 10;", "1:1", "1:15"},
+		{"return a;", "1:1", "1:9"},
+		{"  -a + b;", "1:3", "1:9"},
+		{"add(1, 2);", "1:1", "1:10"},
+		{"arr[1 + 2];", "1:1", "1:11"},
+		{"[1, 2, 3];", "1:1", "1:10"},
+		{"{\"a\": 1};", "1:1", "1:9"},
+		{"fn(x) {\n  x;\n};", "1:1", "3:2"},
+		{"if (x) {\n  1\n} else {\n  2\n}", "1:1", "5:2"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		parser := New(l)
+		program := parser.ParseProgram()
+		if len(parser.errors) != 0 {
+			t.Fatalf("unexpected errors for input %q: %v", tt.input, parser.errors)
+		}
+		statement := program.Statements[0]
+		if statement.Pos().String() != tt.expectedPos {
+			t.Errorf("statement.Pos() = %s, want %s for input %q", statement.Pos(), tt.expectedPos, tt.input)
+		}
+		if statement.End().String() != tt.expectedEnd {
+			t.Errorf("statement.End() = %s, want %s for input %q", statement.End(), tt.expectedEnd, tt.input)
+		}
+	}
+}
+
+// 测试逻辑运算符的解析和优先级
+func TestParseLogicalExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a && b", "(a && b)"},
+		{"a || b", "(a || b)"},
+		{"a || b && c", "(a || (b && c))"},
+		{"a && b || c", "((a && b) || c)"},
+		{"a && b && c", "((a && b) && c)"},
+		{"a == b && c != d", "((a == b) && (c != d))"},
+		{"a < b || c > d", "((a < b) || (c > d))"},
+		{"!a && -b > c", "((!a) && ((-b) > c))"},
+		{"(a || b) && c", "((a || b) && c)"},
+		{"f(a && b, c || d)", "f((a && b), (c || d))"},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		program := parser.ParseProgram()
+		if len(parser.Errors()) != 0 {
+			t.Fatalf("unexpected errors for input %q: %v", tt.input, parser.Errors())
+		}
+		if program.String() != tt.expected {
+			t.Errorf("program.String() = %q, want %q", program.String(), tt.expected)
+		}
+	}
+}
+
+// 测试赋值表达式的解析
+func TestParseAssignExpression(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedOperator string
+		expectedString   string
+	}{
+		{"x = 5;", "=", "(x = 5)"},
+		{"x += 1;", "+=", "(x += 1)"},
+		{"x -= 1;", "-=", "(x -= 1)"},
+		{"x *= 2 + 3;", "*=", "(x *= (2 + 3))"},
+		{"x /= 2;", "/=", "(x /= 2)"},
+		{"x %= 2;", "%=", "(x %= 2)"},
+		{"a = b = c;", "=", "(a = (b = c))"},
+		{"x = y || z;", "=", "(x = (y || z))"},
+		{"arr[0] = 1;", "=", "((arr[0]) = 1)"},
+		{"h[\"a\"] += f(1);", "+=", "((h[a]) += f(1))"},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		program := parser.ParseProgram()
+		if len(parser.Errors()) != 0 {
+			t.Fatalf("unexpected errors for input %q: %v", tt.input, parser.Errors())
+		}
+		if len(program.Statements) != 1 {
+			t.Fatalf("len(program.Statements) = %d, want 1", len(program.Statements))
+		}
+		statement := program.Statements[0].(*ast.ExpressionStatement)
+		expression, ok := statement.Expression.(*ast.AssignExpression)
+		if !ok {
+			t.Fatalf("statement.Expression is not *ast.AssignExpression. got=%T", statement.Expression)
+		}
+		if expression.Operator != tt.expectedOperator {
+			t.Errorf("expression.Operator = %q, want %q", expression.Operator, tt.expectedOperator)
+		}
+		if expression.String() != tt.expectedString {
+			t.Errorf("expression.String() = %q, want %q", expression.String(), tt.expectedString)
+		}
+	}
+}
+
+// 测试赋值运算符左边不是标识符或索引表达式时的错误
+func TestParseInvalidAssignTarget(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 = 2;", "1:1: cannot assign to 1"},
+		{"a + b = 2;", "1:1: cannot assign to (a + b)"},
+		{"(a + b) = 2;", "1:2: cannot assign to (a + b)"},
+		{"f() += 1;", "1:1: cannot assign to f()"},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		parser.ParseProgram()
+		errors := parser.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("len(errors) = %d, want 1 for input %q: %v", len(errors), tt.input, errors)
+		}
+		if errors[0].Code != INVALID_ASSIGN_TARGET {
+			t.Errorf("errors[0].Code = %s, want %s", errors[0].Code, INVALID_ASSIGN_TARGET)
+		}
+		if errors[0].Error() != tt.expected {
+			t.Errorf("errors[0].Error() = %q, want %q", errors[0].Error(), tt.expected)
+		}
+	}
+}
+
+// 测试循环语句的解析
+func TestParseLoopStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"while (x < 10) { x += 1; }", "while(x < 10) (x += 1)"},
+		{"while (true) { break; };", "whiletrue break;"},
+		{"for (let i = 0; i < 10; i += 1) { continue; }", "for (let i = 0; (i < 10); (i += 1)) continue;"},
+		{"for (i = 0; i < 10; i += 1) { }", "for ((i = 0); (i < 10); (i += 1)) "},
+		{"for (;;) { break }", "for (; ; ) break;"},
+		{"for (; x;) { x = false; }", "for (; x; ) (x = false)"},
+		{"for (x in [1, 2]) { puts(x); }", "for (x in [1, 2]) puts(x)"},
+		{"for (k in {\"a\": 1}) { if (k) { break; } }", "for (k in {a:1}) ifk break;"},
+		{"while (a) { let f = fn() { return 1; }; continue; }", "whilea let f = fn()return 1;;continue;"},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		program := parser.ParseProgram()
+		if len(parser.Errors()) != 0 {
+			t.Fatalf("unexpected errors for input %q: %v", tt.input, parser.Errors())
+		}
+		if len(program.Statements) != 1 {
+			t.Fatalf("len(program.Statements) = %d, want 1 for input %q", len(program.Statements), tt.input)
+		}
+		if program.String() != tt.expected {
+			t.Errorf("program.String() = %q, want %q", program.String(), tt.expected)
+		}
+	}
+}
+
+// 测试循环语句的错误
+func TestParseLoopStatementErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"break;", "1:1: break statement outside loop"},
+		{"if (true) { continue; }", "1:13: continue statement outside loop"},
+		{"while (true) { let f = fn() { break; }; }", "1:31: break statement outside loop"},
+		{"while true { }", "1:7: expected next token to be (, got TRUE instead"},
+		{"for (let i = 0 i < 1; i += 1) { }", "1:16: expected next token to be ;, got IDENTIFIER instead"},
+		{"for (x in y { }", "1:13: expected next token to be ), got { instead"},
+		{"for (;;) x;", "1:10: expected next token to be {, got IDENTIFIER instead"},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		parser.ParseProgram()
+		errors := parser.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected errors for input %q, got none", tt.input)
+		}
+		if errors[0].Error() != tt.expected {
+			t.Errorf("errors[0].Error() = %q, want %q for input %q", errors[0].Error(), tt.expected, tt.input)
+		}
+	}
+}
+
+// 测试元组字面量和解构的 let 语句
+func TestParseTuple(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`#(1, "a")`, "#(1, a)"},
+		{"#()", "#()"},
+		{"#(1 + 2)", "#((1 + 2))"},
+		{"#(#(1, 2), [3])[0]", "(#(#(1, 2), [3])[0])"},
+		{"{#(0, 1): true}", "{#(0, 1):true}"},
+		{"let #(x, y) = point;", "let #(x, y) = point;"},
+		{"let #(a) = #(1)", "let #(a) = #(1);"},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		program := parser.ParseProgram()
+		if len(parser.Errors()) != 0 {
+			t.Fatalf("unexpected errors for input %q: %v", tt.input, parser.Errors())
+		}
+		if len(program.Statements) != 1 {
+			t.Fatalf("len(program.Statements) = %d, want 1 for input %q", len(program.Statements), tt.input)
+		}
+		if program.String() != tt.expected {
+			t.Errorf("program.String() = %q, want %q", program.String(), tt.expected)
+		}
+	}
+}
+
+// 测试元组和解构的 let 语句的错误
+func TestParseTupleErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"#(1, 2", "1:7: expected next token to be ), got EOF instead"},
+		{"let #() = x;", "1:7: expected next token to be IDENTIFIER, got ) instead"},
+		{"let #(x, 1) = y;", "1:10: expected next token to be IDENTIFIER, got INT instead"},
+		{"let #(x y) = z;", "1:9: expected next token to be ), got IDENTIFIER instead"},
+		{"let #(x) z;", "1:10: expected next token to be =, got IDENTIFIER instead"},
+		{"# (1)", "1:1: no prefix parse function for ILLEGAL found"},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		parser.ParseProgram()
+		errors := parser.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected errors for input %q, got none", tt.input)
+		}
+		if errors[0].Error() != tt.expected {
+			t.Errorf("errors[0].Error() = %q, want %q for input %q", errors[0].Error(), tt.expected, tt.input)
+		}
+	}
+}
+
+// 测试函数参数的默认值和剩余参数
+func TestParseFunctionParameters(t *testing.T) {
+	tests := []struct {
+		input            string
+		expectedString   string
+		expectedDefaults []string
+		expectedRest     string
+		expectedName     string
+	}{
+		{"fn() {}", "fn()", []string{}, "", ""},
+		{"fn(a, b) {}", "fn(a, b)", []string{"", ""}, "", ""},
+		{"fn(a, b = 2) {}", "fn(a, b = 2)", []string{"", "2"}, "", ""},
+		{"fn(a = 1, b = a + 1) {}", "fn(a = 1, b = (a + 1))", []string{"1", "(a + 1)"}, "", ""},
+		{"fn(...rest) {}", "fn(...rest)", []string{}, "rest", ""},
+		{"fn(a, b = [], ...rest) {}", "fn(a, b = [], ...rest)", []string{"", "[]"}, "rest", ""},
+		{"let add = fn(a, b) {};", "let add = fn(a, b);", []string{"", ""}, "", "add"},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		program := parser.ParseProgram()
+		if len(parser.Errors()) != 0 {
+			t.Fatalf("unexpected errors for input %q: %v", tt.input, parser.Errors())
+		}
+		if program.String() != tt.expectedString {
+			t.Errorf("program.String() = %q, want %q", program.String(), tt.expectedString)
+		}
+
+		var fn *ast.FunctionLiteral
+		switch statement := program.Statements[0].(type) {
+		case *ast.LetStatement:
+			fn = statement.Value.(*ast.FunctionLiteral)
+		case *ast.ExpressionStatement:
+			fn = statement.Expression.(*ast.FunctionLiteral)
+		}
+		defaults := []string{}
+		for _, d := range fn.Defaults {
+			if d == nil {
+				defaults = append(defaults, "")
+			} else {
+				defaults = append(defaults, d.String())
+			}
+		}
+		if strings.Join(defaults, ",") != strings.Join(tt.expectedDefaults, ",") || len(fn.Defaults) != len(fn.Parameters) {
+			t.Errorf("fn.Defaults = %v, want %v", defaults, tt.expectedDefaults)
+		}
+		rest := ""
+		if fn.Rest != nil {
+			rest = fn.Rest.Value
+		}
+		if rest != tt.expectedRest {
+			t.Errorf("fn.Rest = %q, want %q", rest, tt.expectedRest)
+		}
+		if fn.Name != tt.expectedName {
+			t.Errorf("fn.Name = %q, want %q", fn.Name, tt.expectedName)
+		}
+	}
+}
+
+// 测试函数参数列表的错误
+func TestParseFunctionParametersErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(a = 1, b) {}", "1:11: parameter b without default value follows parameter with default value"},
+		{"fn(...rest, a) {}", "1:11: rest parameter must be the last parameter"},
+		{"fn(...) {}", "1:7: expected next token to be IDENTIFIER, got ) instead"},
+		{"fn(1) {}", "1:4: expected parameter name, got INT instead"},
+		{"fn(a b) {}", "1:6: expected next token to be ), got IDENTIFIER instead"},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		parser.ParseProgram()
+		errors := parser.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("expected errors for input %q, got none", tt.input)
+		}
+		if errors[0].Error() != tt.expected {
+			t.Errorf("errors[0].Error() = %q, want %q for input %q", errors[0].Error(), tt.expected, tt.input)
+		}
+	}
+}
+
+// 测试出错后的恢复，每个错误只报告一次，没有错误的语句仍然会被解析
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedErrors     []string
+		expectedStatements []string
+	}{
+		{
+			input:              "let = 5; let y = 2;",
+			expectedErrors:     []string{"1:5: expected next token to be IDENTIFIER, got = instead"},
+			expectedStatements: []string{"let y = 2;"},
+		},
+		{
+			input:              "let x 5;\nlet y = 10;\ny;",
+			expectedErrors:     []string{"1:7: expected next token to be =, got INT instead"},
+			expectedStatements: []string{"let y = 10;", "y"},
+		},
+		{
+			input: "let a = ;\nlet b = 1 +;\nlet c = 3;",
+			expectedErrors: []string{
+				"1:9: no prefix parse function for ; found",
+				"2:12: no prefix parse function for ; found",
+			},
+			expectedStatements: []string{"let c = 3;"},
+		},
+		{
+			input:              "return 5\nlet x = 1;",
+			expectedErrors:     []string{"2:1: expected next token to be ;, got LET instead"},
+			expectedStatements: []string{"let x = 1;"},
+		},
+		{
+			input:              "let f = fn(x) { let = 1; x };\nf(1);",
+			expectedErrors:     []string{"1:21: expected next token to be IDENTIFIER, got = instead"},
+			expectedStatements: []string{"let f = fn(x)x;", "f(1)"},
+		},
+		{
+			input:              "let f = fn() { x + };\nlet y = 1;",
+			expectedErrors:     []string{"1:20: no prefix parse function for } found"},
+			expectedStatements: []string{"let f = fn();", "let y = 1;"},
+		},
+		{
+			input:              "let f = fn() { 1",
+			expectedErrors:     []string{"1:17: expected }, but got EOF"},
+			expectedStatements: []string{},
+		},
+		{
+			input:              "if (x { y; } else { z; }\nlet a = 1;",
+			expectedErrors:     []string{"1:7: expected next token to be ), got { instead"},
+			expectedStatements: []string{"let a = 1;"},
+		},
+		{
+			input:              "}\nlet a = 1;",
+			expectedErrors:     []string{"1:1: no prefix parse function for } found"},
+			expectedStatements: []string{"let a = 1;"},
+		},
+		{
+			input:              "let a = [1, 2;\nlet b = {\"k\" 1};\nlet c = 1;",
+			expectedErrors:     []string{"1:14: expected next token to be ], got ; instead", "2:14: expected next token to be :, got INT instead"},
+			expectedStatements: []string{"let c = 1;"},
+		},
+		{
+			input:              "[1, ]\nlet a = 1;",
+			expectedErrors:     []string{"1:5: no prefix parse function for ] found"},
+			expectedStatements: []string{"let a = 1;"},
+		},
+		{
+			input:              "{1: }\nlet a = 1;",
+			expectedErrors:     []string{"1:5: no prefix parse function for } found"},
+			expectedStatements: []string{"let a = 1;"},
+		},
+		{
+			input:              "#(1,)\nlet a = 1;",
+			expectedErrors:     []string{"1:5: no prefix parse function for ) found"},
+			expectedStatements: []string{"let a = 1;"},
+		},
+		{
+			input:              "(1 + ;\nlet a = 1;",
+			expectedErrors:     []string{"1:6: no prefix parse function for ; found"},
+			expectedStatements: []string{"let a = 1;"},
+		},
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		program := parser.ParseProgram()
+
+		if len(parser.Errors()) != len(tt.expectedErrors) {
+			t.Errorf("wrong number of errors for input %q. expected=%d, got=%d: %v",
+				tt.input, len(tt.expectedErrors), len(parser.Errors()), parser.Errors())
+			continue
+		}
+		for i, expected := range tt.expectedErrors {
+			if parser.Errors()[i].Error() != expected {
+				t.Errorf("errors[%d] = %q, want %q", i, parser.Errors()[i].Error(), expected)
+			}
+		}
+
+		if len(program.Statements) != len(tt.expectedStatements) {
+			t.Errorf("wrong number of statements for input %q. expected=%d, got=%d: %s",
+				tt.input, len(tt.expectedStatements), len(program.Statements), program.String())
+			continue
+		}
+		for i, expected := range tt.expectedStatements {
+			if program.Statements[i] == nil {
+				t.Fatalf("program.Statements[%d] is nil for input %q", i, tt.input)
+			}
+			if program.Statements[i].String() != expected {
+				t.Errorf("program.Statements[%d] = %q, want %q", i, program.Statements[i].String(), expected)
+			}
+		}
+	}
+}
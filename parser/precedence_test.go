@@ -0,0 +1,179 @@
+package parser
+
+import (
+	"holiya/lexer"
+	"strings"
+	"testing"
+)
+
+// 参考实现使用的运算符表，和 precedences 分开定义，用来检查 precedences 是否正确
+// 数字越大优先级越高，right 表示右结合
+var referenceOperators = map[string]struct {
+	precedence int
+	right      bool
+}{
+	"||": {1, false},
+	"&&": {2, false},
+	"==": {3, false},
+	"!=": {3, false},
+	"<":  {4, false},
+	">":  {4, false},
+	"<=": {4, false},
+	">=": {4, false},
+	"+":  {5, false},
+	"-":  {5, false},
+	"*":  {6, false},
+	"/":  {6, false},
+	"%":  {6, false},
+	"**": {7, true},
+}
+
+// 参考实现使用的运算符，按优先级从低到高排列
+var referenceOperatorList = []string{"||", "&&", "==", "!=", "<", ">", "<=", ">=", "+", "-", "*", "/", "%", "**"}
+
+// referenceParser 使用优先级爬升算法的参考实现
+// 只处理由标识符和二元运算符组成的表达式，输出格式和 ast.Program.String() 相同
+type referenceParser struct {
+	tokens   []string
+	position int
+}
+
+// parse 解析优先级不低于 minPrecedence 的表达式
+func (r *referenceParser) parse(minPrecedence int) string {
+	left := r.tokens[r.position]
+	r.position++
+	for r.position < len(r.tokens) {
+		operator := r.tokens[r.position]
+		info := referenceOperators[operator]
+		if info.precedence < minPrecedence {
+			break
+		}
+		r.position++
+		next := info.precedence + 1
+		if info.right {
+			next = info.precedence
+		}
+		right := r.parse(next)
+		left = "(" + left + " " + operator + " " + right + ")"
+	}
+	return left
+}
+
+// referenceString 返回参考实现解析表达式的结果
+func referenceString(input string) string {
+	r := &referenceParser{tokens: strings.Fields(input)}
+	return r.parse(1)
+}
+
+// 生成由 operands 和 operators 交替组成的表达式
+func buildExpression(operands []string, operators []string) string {
+	var out strings.Builder
+	for i, operand := range operands {
+		if i > 0 {
+			out.WriteString(" " + operators[i-1] + " ")
+		}
+		out.WriteString(operand)
+	}
+	return out.String()
+}
+
+// 测试运算符的优先级和结合性
+// 包含手写的用例和由参考实现生成的所有两个、三个运算符的组合
+func TestOperatorPrecedence(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a <= b + 1", "(a <= (b + 1))"},
+		{"a >= b - 1", "(a >= (b - 1))"},
+		{"a % b * c", "((a % b) * c)"},
+		{"a * b % c", "((a * b) % c)"},
+		{"a + b % c", "(a + (b % c))"},
+		{"a + b < c", "((a + b) < c)"},
+		{"a < b == c > d", "((a < b) == (c > d))"},
+		{"a == b < c", "(a == (b < c))"},
+		{"a != b >= c + d", "(a != (b >= (c + d)))"},
+		{"a - b - c", "((a - b) - c)"},
+		{"a / b / c", "((a / b) / c)"},
+		{"a ** b ** c", "(a ** (b ** c))"},
+		{"a ** b * c", "((a ** b) * c)"},
+		{"a * b ** c", "(a * (b ** c))"},
+		{"-a ** b", "(-(a ** b))"},
+		{"!a ** b", "(!(a ** b))"},
+		{"a ** -b", "(a ** (-b))"},
+		{"a ** -b ** c", "(a ** (-(b ** c)))"},
+		{"-a * b", "((-a) * b)"},
+		{"!a == b", "((!a) == b)"},
+		{"-a + -b", "((-a) + (-b))"},
+		{"!-a", "(!(-a))"},
+		{"(a + b) * c", "((a + b) * c)"},
+		{"(a ** b) ** c", "((a ** b) ** c)"},
+		{"a * (b + c) % d", "((a * (b + c)) % d)"},
+		{"f(a) ** 2", "(f(a) ** 2)"},
+		{"a[0] ** b[1]", "((a[0]) ** (b[1]))"},
+		{"-a[0]", "(-(a[0]))"},
+		{"-f(a)", "(-f(a))"},
+		{"f(a + b * c, d <= e)", "f((a + (b * c)), (d <= e))"},
+		{"a[b + c] * d", "((a[(b + c)]) * d)"},
+		{"a || b && c == d < e + f * g ** h", "(a || (b && (c == (d < (e + (f * (g ** h)))))))"},
+		{"a ** b * c + d < e == f && g || h", "(((((((a ** b) * c) + d) < e) == f) && g) || h)"},
+		{"1 + 2 <= 3 * 4 % 5", "((1 + 2) <= ((3 * 4) % 5))"},
+		{"x % 2 == 0 && x > 0", "(((x % 2) == 0) && (x > 0))"},
+	}
+
+	// 两个运算符的所有组合
+	for _, first := range referenceOperatorList {
+		for _, second := range referenceOperatorList {
+			input := buildExpression([]string{"a", "b", "c"}, []string{first, second})
+			tests = append(tests, struct {
+				input    string
+				expected string
+			}{input, referenceString(input)})
+		}
+	}
+
+	// 三个运算符的组合，每个优先级选一个运算符，再加上 - 和 ** 检查结合性
+	operators := []string{"||", "&&", "==", "<=", "+", "-", "%", "**"}
+	for _, first := range operators {
+		for _, second := range operators {
+			for _, third := range operators {
+				input := buildExpression([]string{"a", "b", "c", "d"}, []string{first, second, third})
+				tests = append(tests, struct {
+					input    string
+					expected string
+				}{input, referenceString(input)})
+			}
+		}
+	}
+
+	for _, tt := range tests {
+		parser := New(lexer.New(tt.input))
+		program := parser.ParseProgram()
+		if len(parser.Errors()) != 0 {
+			t.Errorf("unexpected errors for input %q: %v", tt.input, parser.Errors())
+			continue
+		}
+		if program.String() != tt.expected {
+			t.Errorf("program.String() for input %q = %q, want %q", tt.input, program.String(), tt.expected)
+		}
+	}
+}
+
+// 测试参考实现和 precedences 的运算符一致
+func TestReferenceOperators(t *testing.T) {
+	for _, operator := range referenceOperatorList {
+		parser := New(lexer.New(operator))
+		if _, ok := precedences[parser.currToken.Type]; !ok {
+			t.Errorf("operator %q has no precedence", operator)
+		}
+		if parser.infixParseFns[parser.currToken.Type] == nil {
+			t.Errorf("operator %q has no infix parse function", operator)
+		}
+	}
+	for i := 1; i < len(referenceOperatorList); i++ {
+		lower, higher := referenceOperatorList[i-1], referenceOperatorList[i]
+		if referenceOperators[lower].precedence > referenceOperators[higher].precedence {
+			t.Errorf("referenceOperatorList is not sorted: %q before %q", lower, higher)
+		}
+	}
+}
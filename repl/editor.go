@@ -0,0 +1,282 @@
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// 控制键
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlH     = 8
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+// 支持行编辑的终端输入
+// 读取时把终端切换到 raw 模式，自己处理光标移动、删除和历史记录的切换
+type editor struct {
+	in      *os.File
+	reader  *bufio.Reader
+	out     io.Writer
+	history *history
+
+	// 当前行的内容
+	line []rune
+	// 光标在 line 中的位置
+	cursor int
+	// 当前显示的历史记录下标，等于 len(history.entries) 时表示正在编辑新的一行
+	historyIdx int
+	// 切换历史记录前正在编辑的内容
+	pending []rune
+}
+
+// newEditor 创建行编辑器
+func newEditor(in *os.File, out io.Writer, h *history) *editor {
+	return &editor{
+		in:      in,
+		reader:  bufio.NewReader(in),
+		out:     out,
+		history: h,
+	}
+}
+
+// ReadLine 输出提示符并读取一行输入，支持以下按键：
+// 左右方向键、Ctrl+B/F 移动光标，Home、End、Ctrl+A/E 移动到行首行尾，
+// 上下方向键、Ctrl+P/N 切换历史记录，Backspace、Delete 删除字符，
+// Ctrl+K/U/W 删除到行尾/行首/前一个单词，Ctrl+L 清屏，
+// Ctrl+C 放弃当前输入，空行时 Ctrl+D 表示输入结束
+func (e *editor) ReadLine(prompt string) (string, error) {
+	state, err := makeRaw(e.in)
+	if err != nil {
+		// 切换 raw 模式失败时退化为普通的按行读取
+		reader := &plainReader{in: e.reader, out: e.out}
+		return reader.ReadLine(prompt)
+	}
+	defer restore(e.in, state)
+
+	e.line = e.line[:0]
+	e.cursor = 0
+	e.historyIdx = len(e.history.entries)
+	e.pending = nil
+	e.refresh(prompt)
+
+	for {
+		r, _, err := e.reader.ReadRune()
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case keyEnter, '\n':
+			io.WriteString(e.out, "\r\n")
+			return string(e.line), nil
+		case keyCtrlC:
+			io.WriteString(e.out, "\r\n")
+			return "", errInterrupted
+		case keyCtrlD:
+			if len(e.line) == 0 {
+				io.WriteString(e.out, "\r\n")
+				return "", io.EOF
+			}
+			e.deleteAt(e.cursor)
+		case keyBackspace, keyCtrlH:
+			if e.cursor > 0 {
+				e.cursor--
+				e.deleteAt(e.cursor)
+			}
+		case keyCtrlA:
+			e.cursor = 0
+		case keyCtrlE:
+			e.cursor = len(e.line)
+		case keyCtrlB:
+			e.moveCursor(-1)
+		case keyCtrlF:
+			e.moveCursor(1)
+		case keyCtrlP:
+			e.showHistory(e.historyIdx - 1)
+		case keyCtrlN:
+			e.showHistory(e.historyIdx + 1)
+		case keyCtrlK:
+			e.line = e.line[:e.cursor]
+		case keyCtrlU:
+			e.line = append(e.line[:0], e.line[e.cursor:]...)
+			e.cursor = 0
+		case keyCtrlW:
+			e.deleteWord()
+		case keyCtrlL:
+			io.WriteString(e.out, "\x1b[H\x1b[2J")
+		case keyEscape:
+			e.handleEscape()
+		default:
+			if r >= ' ' {
+				e.insert(r)
+			}
+		}
+		e.refresh(prompt)
+	}
+}
+
+// handleEscape 处理方向键等以 ESC 开头的转义序列
+func (e *editor) handleEscape() {
+	r, _, err := e.reader.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return
+	}
+	r, _, err = e.reader.ReadRune()
+	if err != nil {
+		return
+	}
+	switch r {
+	case 'A':
+		e.showHistory(e.historyIdx - 1)
+	case 'B':
+		e.showHistory(e.historyIdx + 1)
+	case 'C':
+		e.moveCursor(1)
+	case 'D':
+		e.moveCursor(-1)
+	case 'H':
+		e.cursor = 0
+	case 'F':
+		e.cursor = len(e.line)
+	default:
+		// 形如 ESC [ 3 ~ 的序列
+		if r < '0' || r > '9' {
+			return
+		}
+		code := r
+		for r != '~' {
+			if r, _, err = e.reader.ReadRune(); err != nil {
+				return
+			}
+		}
+		switch code {
+		case '1', '7':
+			e.cursor = 0
+		case '4', '8':
+			e.cursor = len(e.line)
+		case '3':
+			e.deleteAt(e.cursor)
+		}
+	}
+}
+
+// insert 在光标处插入字符
+func (e *editor) insert(r rune) {
+	e.line = append(e.line, 0)
+	copy(e.line[e.cursor+1:], e.line[e.cursor:])
+	e.line[e.cursor] = r
+	e.cursor++
+}
+
+// deleteAt 删除 idx 处的字符
+func (e *editor) deleteAt(idx int) {
+	if idx < 0 || idx >= len(e.line) {
+		return
+	}
+	e.line = append(e.line[:idx], e.line[idx+1:]...)
+}
+
+// deleteWord 删除光标前的一个单词
+func (e *editor) deleteWord() {
+	start := e.cursor
+	for start > 0 && e.line[start-1] == ' ' {
+		start--
+	}
+	for start > 0 && e.line[start-1] != ' ' {
+		start--
+	}
+	e.line = append(e.line[:start], e.line[e.cursor:]...)
+	e.cursor = start
+}
+
+// moveCursor 左右移动光标
+func (e *editor) moveCursor(delta int) {
+	cursor := e.cursor + delta
+	if cursor >= 0 && cursor <= len(e.line) {
+		e.cursor = cursor
+	}
+}
+
+// showHistory 把第 idx 条历史记录显示为当前行
+func (e *editor) showHistory(idx int) {
+	entries := e.history.entries
+	if idx < 0 || idx > len(entries) {
+		return
+	}
+	if e.historyIdx == len(entries) {
+		e.pending = append(e.pending[:0], e.line...)
+	}
+	e.historyIdx = idx
+	if idx == len(entries) {
+		e.line = append(e.line[:0], e.pending...)
+	} else {
+		// 多行的历史记录在编辑时显示为一行
+		e.line = []rune(strings.ReplaceAll(entries[idx], "\n", " "))
+	}
+	e.cursor = len(e.line)
+}
+
+// refresh 重新绘制当前行，并把光标移动到正确的位置
+func (e *editor) refresh(prompt string) {
+	var out strings.Builder
+	out.WriteString("\r")
+	out.WriteString(prompt)
+	out.WriteString(string(e.line))
+	out.WriteString("\x1b[K\r")
+	if column := displayWidth([]rune(prompt)) + displayWidth(e.line[:e.cursor]); column > 0 {
+		fmt.Fprintf(&out, "\x1b[%dC", column)
+	}
+	io.WriteString(e.out, out.String())
+}
+
+// AddHistory 添加一条历史记录
+func (e *editor) AddHistory(entry string) {
+	e.history.add(entry)
+}
+
+// Close 保存历史记录
+func (e *editor) Close() error {
+	return e.history.save()
+}
+
+// displayWidth 计算字符在终端中占用的列数，中文等全角字符占两列
+func displayWidth(runes []rune) int {
+	width := 0
+	for _, r := range runes {
+		if isWide(r) {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// isWide 判断字符是不是全角字符
+func isWide(r rune) bool {
+	return r >= 0x1100 && r <= 0x115F ||
+		r >= 0x2E80 && r <= 0xA4CF ||
+		r >= 0xAC00 && r <= 0xD7A3 ||
+		r >= 0xF900 && r <= 0xFAFF ||
+		r >= 0xFE30 && r <= 0xFE4F ||
+		r >= 0xFF00 && r <= 0xFF60 ||
+		r >= 0xFFE0 && r <= 0xFFE6 ||
+		r >= 0x1F300 && r <= 0x1F64F ||
+		r >= 0x20000 && r <= 0x3FFFD
+}
@@ -0,0 +1,104 @@
+package repl
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+const (
+	// 历史文件的名称，保存在用户目录下
+	HISTORY_FILE = ".holiya_history"
+	// 最多保留的历史记录条数
+	MAX_HISTORY = 1000
+)
+
+// 历史记录
+// 多行输入作为一条记录保存，写入文件时把换行符转义成 \n
+type history struct {
+	// 历史文件路径，为空时不读写文件
+	path string
+	// 历史记录，按输入的先后顺序排列
+	entries []string
+}
+
+// newHistory 创建历史记录，并从 path 中读取已有的记录
+func newHistory(path string) *history {
+	h := &history{path: path}
+	if path == "" {
+		return h
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return h
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			h.entries = append(h.entries, unescapeHistory(line))
+		}
+	}
+	h.trim()
+	return h
+}
+
+// add 添加一条历史记录，和上一条相同的记录不会重复添加
+func (h *history) add(entry string) {
+	if len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry {
+		return
+	}
+	h.entries = append(h.entries, entry)
+	h.trim()
+}
+
+// save 把历史记录写入历史文件
+func (h *history) save() error {
+	if h.path == "" {
+		return nil
+	}
+	file, err := os.OpenFile(h.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for _, entry := range h.entries {
+		writer.WriteString(escapeHistory(entry))
+		writer.WriteString("\n")
+	}
+	return writer.Flush()
+}
+
+// trim 只保留最近的 MAX_HISTORY 条记录
+func (h *history) trim() {
+	if len(h.entries) > MAX_HISTORY {
+		h.entries = h.entries[len(h.entries)-MAX_HISTORY:]
+	}
+}
+
+// escapeHistory 转义反斜杠和换行符，使每条记录占一行
+func escapeHistory(entry string) string {
+	entry = strings.ReplaceAll(entry, `\`, `\\`)
+	return strings.ReplaceAll(entry, "\n", `\n`)
+}
+
+// unescapeHistory 是 escapeHistory 的逆操作
+func unescapeHistory(line string) string {
+	var out strings.Builder
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' && i+1 < len(line) {
+			i++
+			if line[i] == 'n' {
+				out.WriteByte('\n')
+			} else {
+				out.WriteByte(line[i])
+			}
+			continue
+		}
+		out.WriteByte(line[i])
+	}
+	return out.String()
+}
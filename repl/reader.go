@@ -0,0 +1,55 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// 用户按下 Ctrl+C 时返回的错误
+var errInterrupted = errors.New("interrupted")
+
+// 按行读取输入的接口
+type lineReader interface {
+	// ReadLine 输出提示符并读取一行输入，不包含换行符
+	ReadLine(prompt string) (string, error)
+	// AddHistory 添加一条历史记录
+	AddHistory(entry string)
+	// Close 释放资源，保存历史记录
+	Close() error
+}
+
+// newLineReader 创建按行读取的 reader
+// 输入是终端时使用支持行编辑的 editor，否则逐行读取输入
+func newLineReader(in io.Reader, out io.Writer) lineReader {
+	if file, ok := in.(*os.File); ok && isTerminal(file) {
+		return newEditor(file, out, newHistory(historyPath()))
+	}
+	return &plainReader{in: bufio.NewReader(in), out: out}
+}
+
+// 普通的按行读取，用于输入不是终端的情况，比如管道
+type plainReader struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ReadLine 输出提示符并读取一行输入
+func (r *plainReader) ReadLine(prompt string) (string, error) {
+	io.WriteString(r.out, prompt)
+	line, err := r.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// AddHistory 不是终端时不记录历史
+func (r *plainReader) AddHistory(entry string) {}
+
+// Close 不需要释放资源
+func (r *plainReader) Close() error {
+	return nil
+}
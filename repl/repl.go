@@ -0,0 +1,137 @@
+package repl
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"holiya/engine"
+	"holiya/lexer"
+	"holiya/object"
+	"holiya/parser"
+	"holiya/token"
+)
+
+const (
+	// 提示符
+	PROMPT = ">> "
+	// 多行输入时的续行提示符
+	CONTINUE_PROMPT = ".. "
+)
+
+// Start 启动 REPL，从 in 中读取输入，用引擎 e 执行后将结果写入 out
+// 所有输入都由同一个引擎执行，前面定义的变量在后面的输入中仍然可以使用
+// 如果 in 是终端，则支持行编辑，并把历史记录保存到用户目录下的历史文件中
+// puts 等内置函数也输出到 out；调用 exit 函数时结束 REPL，返回 exit 的退出码，输入结束时返回 0
+func Start(in io.Reader, out io.Writer, e engine.Engine) int {
+	e.SetContext(&object.Context{Stdout: out, Stderr: os.Stderr, Stdin: in})
+	reader := newLineReader(in, out)
+	defer reader.Close()
+
+	for {
+		input, err := readInput(reader)
+		if errors.Is(err, errInterrupted) {
+			// Ctrl+C 只放弃当前的输入，不退出 REPL
+			io.WriteString(out, "^C\n")
+			continue
+		}
+		if err != nil {
+			// 输入结束（Ctrl+D）时退出
+			io.WriteString(out, "\n")
+			return 0
+		}
+		if strings.TrimSpace(input) == "" {
+			continue
+		}
+		reader.AddHistory(input)
+
+		l := lexer.New(input)
+		p := parser.New(l)
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			printParserErrors(out, p.Errors())
+			continue
+		}
+
+		evaluated := e.Run(program)
+		if err, ok := evaluated.(*object.Error); ok && err.Exit {
+			return err.Code
+		}
+		if evaluated != nil {
+			io.WriteString(out, evaluated.Inspect())
+			io.WriteString(out, "\n")
+		}
+		if err, ok := evaluated.(*object.Error); ok {
+			io.WriteString(out, object.FormatTrace(err.Trace))
+		}
+	}
+}
+
+// readInput 读取一次完整的输入
+// 如果输入中的 {、(、[ 没有闭合，则继续读取下一行，直到全部闭合为止
+func readInput(reader lineReader) (string, error) {
+	var lines []string
+	prompt := PROMPT
+	for {
+		line, err := reader.ReadLine(prompt)
+		if err != nil {
+			// 已经有输入时，Ctrl+D 结束当前输入，交给解析器报告错误
+			if err == io.EOF && len(lines) != 0 {
+				return strings.Join(lines, "\n"), nil
+			}
+			return "", err
+		}
+		lines = append(lines, line)
+		input := strings.Join(lines, "\n")
+		if !isIncomplete(input) {
+			return input, nil
+		}
+		prompt = CONTINUE_PROMPT
+	}
+}
+
+// isIncomplete 判断输入中是否有没有闭合的 {、(、[、#(、原始字符串或者块注释
+// 使用词法分析器统计括号，这样字符串和注释中的括号不会被计算在内
+func isIncomplete(input string) bool {
+	depth := 0
+	l := lexer.New(input)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LBRACE, token.LPAREN, token.LBRACKET, token.TUPLE_START:
+			depth++
+		case token.RBRACE, token.RPAREN, token.RBRACKET:
+			depth--
+		case token.ILLEGAL:
+			// 原始字符串可以跨行，没有闭合时继续读取下一行
+			if strings.HasPrefix(tok.Literal, "`") {
+				return true
+			}
+		}
+	}
+	// 块注释没有闭合时，词法分析器会一直跳到输入结束
+	for _, err := range l.Errors() {
+		if err.Kind == lexer.UNTERMINATED_COMMENT {
+			return true
+		}
+	}
+	return depth > 0
+}
+
+// printParserErrors 输出解析错误
+func printParserErrors(out io.Writer, errors parser.ErrorList) {
+	io.WriteString(out, "parser errors:\n")
+	for _, diagnostic := range errors {
+		io.WriteString(out, "\t"+diagnostic.Error()+"\n")
+	}
+}
+
+// historyPath 返回历史文件的路径，获取不到用户目录时返回空字符串
+func historyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, HISTORY_FILE)
+}
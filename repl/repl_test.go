@@ -0,0 +1,131 @@
+package repl
+
+import (
+	"bytes"
+	"holiya/engine"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestStart 测试 REPL 的执行结果
+func TestStart(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{
+			// 环境在多次输入之间保持
+			input:    "let a = 5;\na * 2;\n",
+			expected: ">> >> 10\n>> \n",
+		},
+		{
+			// 括号没有闭合时继续读取下一行
+			input:    "let add = fn(x, y) {\nx + y\n};\nadd(1,\n2);\n",
+			expected: ">> .. .. >> .. 3\n>> \n",
+		},
+		{
+			// 解析错误不会结束 REPL
+			input:    "1 +;\n1 + 1;\n",
+			expected: ">> parser errors:\n\t1:4: no prefix parse function for ; found\n>> 2\n>> \n",
+		},
+		{
+			// puts 的输出也写到 out
+			input:    "puts(1); 2\n",
+			expected: ">> 1\n2\n>> \n",
+		},
+		{
+			// 函数中出错时输出调用栈
+			input:    "let f = fn() { 1 + true };\nf();\n",
+			expected: ">> >> ERROR: 1:16: type mismatch: INTEGER + BOOLEAN\ntraceback (most recent call first):\n  `f` called at 1:1\n>> \n",
+		},
+		{
+			// 空行会被忽略
+			input:    "\n\n3\n",
+			expected: ">> >> >> 3\n>> \n",
+		},
+	}
+
+	// 两个引擎的输出相同
+	for _, name := range []string{engine.EVAL, engine.VM} {
+		for i, tt := range tests {
+			var out bytes.Buffer
+			e, _ := engine.New(name)
+			Start(strings.NewReader(tt.input), &out, e)
+			if out.String() != tt.expected {
+				t.Errorf("engine %s, test case %d: output wrong. expected=%q, got=%q", name, i, tt.expected, out.String())
+			}
+		}
+	}
+}
+
+// TestStartExit 测试调用 exit 函数时结束 REPL 并返回退出码
+func TestStartExit(t *testing.T) {
+	for _, name := range []string{engine.EVAL, engine.VM} {
+		var out bytes.Buffer
+		e, _ := engine.New(name)
+		code := Start(strings.NewReader("1\nexit(3)\n2\n"), &out, e)
+		if code != 3 {
+			t.Errorf("engine %s: exit code = %d, want 3", name, code)
+		}
+		if out.String() != ">> 1\n>> " {
+			t.Errorf("engine %s: output wrong. got=%q", name, out.String())
+		}
+	}
+}
+
+// TestIsIncomplete 测试括号是否闭合的判断
+func TestIsIncomplete(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"1 + 1", false},
+		{"fn(x) {", true},
+		{"fn(x) {\nx\n}", false},
+		{"[1, 2,", true},
+		{"add(1,", true},
+		{`"{"`, false},
+		{"// {", false},
+		{"}", false},
+		{"let s = `a", true},
+		{"let s = `a\nb`", false},
+		{`let s = "a`, false},
+		{"/* {", true},
+		{"/* a /* b */", true},
+		{"/* a /* b */ */ 1", false},
+		{"#(1,", true},
+		{"#(1, 2)", false},
+	}
+
+	for _, tt := range tests {
+		if actual := isIncomplete(tt.input); actual != tt.expected {
+			t.Errorf("isIncomplete(%q) = %t, want %t", tt.input, actual, tt.expected)
+		}
+	}
+}
+
+// TestHistory 测试历史记录的保存和读取
+func TestHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HISTORY_FILE)
+
+	h := newHistory(path)
+	h.add("let a = 1;")
+	h.add("let a = 1;")
+	h.add("fn(x) {\n\tx\n}")
+	h.add(`"a\nb"`)
+	if err := h.save(); err != nil {
+		t.Fatalf("save() returned error: %v", err)
+	}
+
+	loaded := newHistory(path)
+	expected := []string{"let a = 1;", "fn(x) {\n\tx\n}", `"a\nb"`}
+	if len(loaded.entries) != len(expected) {
+		t.Fatalf("history has wrong number of entries. expected=%d, got=%d", len(expected), len(loaded.entries))
+	}
+	for i, entry := range expected {
+		if loaded.entries[i] != entry {
+			t.Errorf("entries[%d] wrong. expected=%q, got=%q", i, entry, loaded.entries[i])
+		}
+	}
+}
@@ -0,0 +1,26 @@
+//go:build !linux && !darwin
+
+package repl
+
+import (
+	"errors"
+	"os"
+)
+
+// 终端原来的属性，其他平台不支持 raw 模式
+type termState struct{}
+
+// isTerminal 其他平台不支持行编辑，按普通输入处理
+func isTerminal(file *os.File) bool {
+	return false
+}
+
+// makeRaw 其他平台不支持 raw 模式
+func makeRaw(file *os.File) (*termState, error) {
+	return nil, errors.New("raw mode is not supported on this platform")
+}
+
+// restore 其他平台不需要恢复
+func restore(file *os.File, state *termState) error {
+	return nil
+}
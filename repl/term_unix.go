@@ -0,0 +1,45 @@
+//go:build linux || darwin
+
+package repl
+
+import (
+	"os"
+	"syscall"
+)
+
+// 终端原来的属性，用于退出 raw 模式
+type termState struct {
+	termios syscall.Termios
+}
+
+// isTerminal 判断文件是不是终端
+func isTerminal(file *os.File) bool {
+	_, err := getTermios(file)
+	return err == nil
+}
+
+// makeRaw 把终端切换到 raw 模式，返回终端原来的属性
+// raw 模式下输入不回显，也不会等到换行才交给程序，Ctrl+C 等按键也不会产生信号
+func makeRaw(file *os.File) (*termState, error) {
+	termios, err := getTermios(file)
+	if err != nil {
+		return nil, err
+	}
+	state := &termState{termios: *termios}
+
+	termios.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	termios.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	termios.Cflag &^= syscall.CSIZE | syscall.PARENB
+	termios.Cflag |= syscall.CS8
+	termios.Cc[syscall.VMIN] = 1
+	termios.Cc[syscall.VTIME] = 0
+	if err := setTermios(file, termios); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// restore 恢复终端原来的属性
+func restore(file *os.File, state *termState) error {
+	return setTermios(file, &state.termios)
+}
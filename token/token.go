@@ -1,5 +1,10 @@
 package token
 
+import (
+	"fmt"
+	"strings"
+)
+
 // token 类型
 type TokenType string
 
@@ -18,10 +23,27 @@ const (
 	FLOAT TokenType = "FLOAT"
 	// 字符串
 	STRING TokenType = "STRING"
+	// 插值字符串中第一个插值表达式之前的部分，如 "a ${b} c ${d} e" 中的 "a ${
+	INTERP_START TokenType = "INTERP_START"
+	// 插值字符串中两个插值表达式之间的部分，如 "a ${b} c ${d} e" 中的 } c ${
+	INTERP_MIDDLE TokenType = "INTERP_MIDDLE"
+	// 插值字符串中最后一个插值表达式之后的部分，如 "a ${b} c ${d} e" 中的 } e"
+	INTERP_END TokenType = "INTERP_END"
 
-	// 数学运算符
 	// 赋值运算符
 	ASSIGN TokenType = "="
+	// 加法赋值运算符
+	PLUS_ASSIGN TokenType = "+="
+	// 减法赋值运算符
+	MINUS_ASSIGN TokenType = "-="
+	// 乘法赋值运算符
+	MUL_ASSIGN TokenType = "*="
+	// 除法赋值运算符
+	DIV_ASSIGN TokenType = "/="
+	// 取余赋值运算符
+	MOD_ASSIGN TokenType = "%="
+
+	// 数学运算符
 	// 加法运算符
 	PLUS TokenType = "+"
 	// 减法运算符
@@ -32,6 +54,8 @@ const (
 	DIV TokenType = "/"
 	// 取余运算符
 	MOD TokenType = "%"
+	// 幂运算符
+	POW TokenType = "**"
 
 	// 逻辑运算符
 	// 取反运算符
@@ -65,6 +89,10 @@ const (
 	COMMA     TokenType = ","
 	SEMICOLON TokenType = ";"
 	COLON     TokenType = ":"
+	// 剩余参数，如 fn(a, ...rest)
+	ELLIPSIS TokenType = "..."
+	// 元组的开始，如 #(1, 2)
+	TUPLE_START TokenType = "#("
 
 	// 关键字
 	// 函数关键字，声明函数
@@ -81,6 +109,16 @@ const (
 	ELSE TokenType = "ELSE"
 	// 函数关键字，表示终止执行，如果后面跟着表达式，则表示返回对应表达式的值
 	RETURN TokenType = "RETURN"
+	// 循环关键字，表示 while
+	WHILE TokenType = "WHILE"
+	// 循环关键字，表示 for
+	FOR TokenType = "FOR"
+	// 循环关键字，表示 for (x in iterable) 中的 in
+	IN TokenType = "IN"
+	// 循环关键字，结束循环
+	BREAK TokenType = "BREAK"
+	// 循环关键字，开始下一次循环
+	CONTINUE TokenType = "CONTINUE"
 )
 
 // Token 结构体
@@ -89,17 +127,91 @@ type Token struct {
 	Type TokenType
 	// token 的字符串表示
 	Literal string
+	// token 第一个字符的位置
+	Pos Position
+	// token 最后一个字符之后的位置
+	End Position
+	// token 之前的文档注释，没有时为 nil
+	Doc *CommentGroup
+}
+
+// Comment 一行文档注释，如 /// 返回两个数的和
+type Comment struct {
+	// 注释开头 / 的位置
+	Pos Position
+	// 注释的原始内容，包含开头的 ///，不包含换行符
+	Text string
+}
+
+// CommentGroup 连续的文档注释，作为 trivia 附加在之后的第一个 token 上
+type CommentGroup struct {
+	List []*Comment
+}
+
+// Text 返回文档注释的内容，每一行去掉开头的 /// 和一个空格，行之间用换行符连接
+func (g *CommentGroup) Text() string {
+	if g == nil {
+		return ""
+	}
+	lines := make([]string, len(g.List))
+	for i, c := range g.List {
+		line := strings.TrimPrefix(c.Text, "///")
+		lines[i] = strings.TrimPrefix(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Position 源码中的位置
+type Position struct {
+	// 文件名，可以为空
+	Filename string
+	// 偏移量，按字节计算，从 0 开始
+	Offset int
+	// 行号，从 1 开始
+	Line int
+	// 列号，按字符计算，从 1 开始
+	Column int
+}
+
+// IsValid 判断位置是否有效，行号大于 0 的位置才是有效的
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String 返回位置的字符串表示，格式如下：
+//
+//	file:line:column    有文件名的有效位置
+//	line:column         没有文件名的有效位置
+//	file                有文件名的无效位置
+//	-                   没有文件名的无效位置
+func (p Position) String() string {
+	s := p.Filename
+	if p.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	if s == "" {
+		s = "-"
+	}
+	return s
 }
 
 // 关键字 map
 var keywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+	"fn":       FUNCTION,
+	"let":      LET,
+	"true":     TRUE,
+	"false":    FALSE,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
 }
 
 // LookupIdent 查看是不是关键字，不是的话返回标识符
@@ -143,6 +143,11 @@ func TestLookupIdentifier(t *testing.T) {
 		{"if", IF},
 		{"else", ELSE},
 		{"return", RETURN},
+		{"while", WHILE},
+		{"for", FOR},
+		{"in", IN},
+		{"break", BREAK},
+		{"continue", CONTINUE},
 		{"unknown", IDENTIFIER}, // 非关键字应返回 IDENTIFIER
 	}
 
@@ -153,3 +158,22 @@ func TestLookupIdentifier(t *testing.T) {
 		}
 	}
 }
+
+// 测试 Position 的字符串表示
+func TestPositionString(t *testing.T) {
+	tests := []struct {
+		input    Position
+		expected string
+	}{
+		{Position{}, "-"},
+		{Position{Filename: "main.holiya"}, "main.holiya"},
+		{Position{Line: 1, Column: 5}, "1:5"},
+		{Position{Filename: "main.holiya", Offset: 20, Line: 12, Column: 7}, "main.holiya:12:7"},
+	}
+
+	for _, tt := range tests {
+		if tt.input.String() != tt.expected {
+			t.Errorf("Position.String() wrong. expected=%q, got=%q", tt.expected, tt.input.String())
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package vm
+
+import (
+	"holiya/code"
+	"holiya/object"
+)
+
+// Frame 调用栈中的一帧，保存正在执行的闭包和它的执行状态
+type Frame struct {
+	cl *object.Closure
+	// 下一条要执行的指令的位置
+	ip int
+	// 局部变量在栈中的起始位置
+	basePointer int
+}
+
+// NewFrame 创建调用帧
+func NewFrame(cl *object.Closure, basePointer int) *Frame {
+	return &Frame{cl: cl, basePointer: basePointer}
+}
+
+// Instructions 返回帧中闭包的指令
+func (f *Frame) Instructions() code.Instructions {
+	return f.cl.Fn.Instructions
+}
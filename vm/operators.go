@@ -0,0 +1,308 @@
+package vm
+
+import (
+	"math"
+
+	"holiya/code"
+	"holiya/object"
+)
+
+// 二元运算的操作码对应的运算符，用于错误信息
+var binaryOperators = map[code.Opcode]string{
+	code.OpAdd:          "+",
+	code.OpSub:          "-",
+	code.OpMul:          "*",
+	code.OpDiv:          "/",
+	code.OpMod:          "%",
+	code.OpPow:          "**",
+	code.OpEqual:        "==",
+	code.OpNotEqual:     "!=",
+	code.OpLessThan:     "<",
+	code.OpLessEqual:    "<=",
+	code.OpGreaterThan:  ">",
+	code.OpGreaterEqual: ">=",
+}
+
+// 计算二元运算的结果，运算规则和错误信息都和求值器相同
+func binaryOperation(operator string, left, right object.Object) object.Object {
+	switch {
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
+		return integerOperation(operator, left.(*object.Integer).Value, right.(*object.Integer).Value)
+	case left.Type() == object.FLOAT_OBJ && right.Type() == object.FLOAT_OBJ:
+		return floatOperation(operator, left.(*object.Float).Value, right.(*object.Float).Value)
+	case isNumber(left) && isNumber(right):
+		return numberOperation(operator, left, right)
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		if operator != "+" {
+			return comparisonOperation(operator, left, right)
+		}
+		return &object.String{Value: left.(*object.String).Value + right.(*object.String).Value}
+	case operator == "==":
+		return nativeBoolToBooleanObject(object.Equal(left, right))
+	case operator == "!=":
+		return nativeBoolToBooleanObject(!object.Equal(left, right))
+	case left.Type() == right.Type() && (left.Type() == object.ARRAY_OBJ || left.Type() == object.TUPLE_OBJ):
+		return comparisonOperation(operator, left, right)
+	case left.Type() != right.Type():
+		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// 计算整数和浮点数的运算结果，和求值器相同：
+// 比较运算用 object.Equal 和 object.Compare 按准确的数值比较，和 NaN 比较大小时结果都是 false；
+// 其他运算中整数先转换为浮点数
+func numberOperation(operator string, left, right object.Object) object.Object {
+	leftValue, rightValue := toFloat(left), toFloat(right)
+	if operator == "==" || operator == "!=" ||
+		isComparisonOperator(operator) && !math.IsNaN(leftValue) && !math.IsNaN(rightValue) {
+		return comparisonOperation(operator, left, right)
+	}
+	return floatOperation(operator, leftValue, rightValue)
+}
+
+// 用 object.Compare 计算比较运算的结果，用于字符串、数组和元组
+func comparisonOperation(operator string, left, right object.Object) object.Object {
+	if operator == "==" || operator == "!=" {
+		return nativeBoolToBooleanObject(object.Equal(left, right) == (operator == "=="))
+	}
+	if !isComparisonOperator(operator) {
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+	result, ok := object.Compare(left, right)
+	if !ok {
+		return newError("cannot compare %s %s %s: elements are not ordered", left.Type(), operator, right.Type())
+	}
+	switch operator {
+	case "<":
+		return nativeBoolToBooleanObject(result < 0)
+	case "<=":
+		return nativeBoolToBooleanObject(result <= 0)
+	case ">":
+		return nativeBoolToBooleanObject(result > 0)
+	default:
+		return nativeBoolToBooleanObject(result >= 0)
+	}
+}
+
+// 计算两个整数的运算结果
+func integerOperation(operator string, left, right int64) object.Object {
+	switch operator {
+	case "+":
+		return &object.Integer{Value: left + right}
+	case "-":
+		return &object.Integer{Value: left - right}
+	case "*":
+		return &object.Integer{Value: left * right}
+	case "/":
+		if right == 0 {
+			return newError("Division by zero")
+		}
+		return &object.Integer{Value: left / right}
+	case "%":
+		if right == 0 {
+			return newError("Division by zero")
+		}
+		return &object.Integer{Value: left % right}
+	case "**":
+		if right < 0 {
+			return &object.Float{Value: math.Pow(float64(left), float64(right))}
+		}
+		result, ok := integerPow(left, right)
+		if !ok {
+			return newError("integer overflow: %d ** %d", left, right)
+		}
+		return &object.Integer{Value: result}
+	case ">":
+		return nativeBoolToBooleanObject(left > right)
+	case ">=":
+		return nativeBoolToBooleanObject(left >= right)
+	case "<":
+		return nativeBoolToBooleanObject(left < right)
+	case "<=":
+		return nativeBoolToBooleanObject(left <= right)
+	case "==":
+		return nativeBoolToBooleanObject(left == right)
+	case "!=":
+		return nativeBoolToBooleanObject(left != right)
+	}
+	return newError("unknown operator: %s %s %s", object.INTEGER_OBJ, operator, object.INTEGER_OBJ)
+}
+
+// 判断是否是比较大小的运算符
+func isComparisonOperator(operator string) bool {
+	return operator == "<" || operator == "<=" || operator == ">" || operator == ">="
+}
+
+// 计算两个浮点数的运算结果，整数和浮点数运算时整数先转换为浮点数
+func floatOperation(operator string, left, right float64) object.Object {
+	switch operator {
+	case "+":
+		return &object.Float{Value: left + right}
+	case "-":
+		return &object.Float{Value: left - right}
+	case "*":
+		return &object.Float{Value: left * right}
+	case "/":
+		if right == 0 {
+			return newError("Division by zero")
+		}
+		return &object.Float{Value: left / right}
+	case "%":
+		if right == 0 {
+			return newError("Division by zero")
+		}
+		return &object.Float{Value: math.Mod(left, right)}
+	case "**":
+		return &object.Float{Value: math.Pow(left, right)}
+	case ">":
+		return nativeBoolToBooleanObject(left > right)
+	case ">=":
+		return nativeBoolToBooleanObject(left >= right)
+	case "<":
+		return nativeBoolToBooleanObject(left < right)
+	case "<=":
+		return nativeBoolToBooleanObject(left <= right)
+	case "==":
+		return nativeBoolToBooleanObject(left == right)
+	case "!=":
+		return nativeBoolToBooleanObject(left != right)
+	}
+	return newError("unknown operator: %s %s %s", object.FLOAT_OBJ, operator, object.FLOAT_OBJ)
+}
+
+// 判断对象是否是数字
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+// 把数字对象转换为浮点数
+func toFloat(obj object.Object) float64 {
+	if integer, ok := obj.(*object.Integer); ok {
+		return float64(integer.Value)
+	}
+	return obj.(*object.Float).Value
+}
+
+// 计算整数的幂，指数不能是负数，结果超出 int64 的范围时返回 false
+func integerPow(base, exponent int64) (int64, bool) {
+	result := int64(1)
+	ok := true
+	for exponent > 0 {
+		if exponent&1 == 1 {
+			if result, ok = multiplyInt64(result, base); !ok {
+				return 0, false
+			}
+		}
+		exponent >>= 1
+		if exponent > 0 {
+			if base, ok = multiplyInt64(base, base); !ok {
+				return 0, false
+			}
+		}
+	}
+	return result, true
+}
+
+// 计算两个整数的积，结果超出 int64 的范围时返回 false
+func multiplyInt64(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	c := a * b
+	if c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return c, true
+}
+
+// 计算 - 前缀运算的结果
+func minusOperation(right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Integer:
+		return &object.Integer{Value: -right.Value}
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	}
+	return newError("unknown operator: -%s", right.Type())
+}
+
+// 计算索引运算的结果，和求值器一样，数组、元组和字符串越界时结果是 null
+func indexOperation(left, index object.Object) object.Object {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		elements := left.(*object.Array).Elements
+		idx := index.(*object.Integer).Value
+		if idx < 0 || idx > int64(len(elements)-1) {
+			return NULL
+		}
+		return elements[idx]
+	case left.Type() == object.TUPLE_OBJ && index.Type() == object.INTEGER_OBJ:
+		elements := left.(*object.Tuple).Elements
+		idx := index.(*object.Integer).Value
+		if idx < 0 || idx >= int64(len(elements)) {
+			return NULL
+		}
+		return elements[idx]
+	case left.Type() == object.HASH_OBJ:
+		key, ok := object.AsHashable(index)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+		pair, ok := left.(*object.Hashmap).Get(key)
+		if !ok {
+			return NULL
+		}
+		return pair.Value
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		str := left.(*object.String)
+		idx := index.(*object.Integer).Value
+		if idx < 0 || idx >= int64(str.Len()) {
+			return NULL
+		}
+		return &object.String{Value: str.CharAt(int(idx))}
+	}
+	return newError("index operator not supported: %s", left.Type())
+}
+
+// 给数组的元素或者哈希表的键赋值
+func setIndexOperation(left, index, value object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		idx, ok := index.(*object.Integer)
+		if !ok {
+			return newError("array index must be INTEGER, got %s", index.Type())
+		}
+		if idx.Value < 0 || idx.Value >= int64(len(left.Elements)) {
+			return newError("index out of range: %d", idx.Value)
+		}
+		left.Elements[idx.Value] = value
+		return value
+	case *object.Hashmap:
+		key, ok := object.AsHashable(index)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+		left.Set(key, value)
+		return value
+	}
+	return newError("index assignment not supported: %s", left.Type())
+}
+
+// 判断对象是否为真值，false 和 null 为假，其他值都为真
+func isTruthy(obj object.Object) bool {
+	switch obj {
+	case FALSE, NULL:
+		return false
+	}
+	return true
+}
+
+// 将原生布尔值转换为布尔对象
+func nativeBoolToBooleanObject(input bool) object.Object {
+	if input {
+		return TRUE
+	}
+	return FALSE
+}
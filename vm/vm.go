@@ -0,0 +1,579 @@
+package vm
+
+import (
+	"fmt"
+	"strings"
+
+	"holiya/code"
+	"holiya/compiler"
+	"holiya/object"
+)
+
+const (
+	// 栈的初始大小，不够时自动扩大
+	StackSize = 2048
+	// 全局变量的最大数量，和 OpGetGlobal 操作数的范围相同
+	GlobalsSize = 65536
+)
+
+var (
+	// null、true 和 false 和求值器共用同一个实例
+	NULL  = object.NULL
+	TRUE  = object.TRUE
+	FALSE = object.FALSE
+)
+
+// VM 基于栈的虚拟机，执行编译器生成的字节码
+type VM struct {
+	constants []object.Object
+
+	globals     []object.Object
+	globalNames []string
+
+	// 栈扩大时会移动到新的位置，所以引用局部变量的捕获变量也要随之修改
+	stack []object.Object
+	// 栈顶的下一个位置
+	sp int
+
+	frames      []*Frame
+	framesIndex int
+
+	// 还没有关闭的捕获变量，按局部变量在栈中的位置从小到大排列，
+	// 函数返回时只需要从末尾开始关闭，不用检查所有的捕获变量
+	openUpvalues []openUpvalue
+
+	// 内置函数执行时的上下文
+	ctx *object.Context
+}
+
+// New 创建虚拟机
+func New(bytecode *compiler.Bytecode) *VM {
+	return NewWithGlobalsStore(bytecode, make([]object.Object, GlobalsSize))
+}
+
+// NewWithGlobalsStore 使用已有的全局变量创建虚拟机
+// REPL 中每次输入都创建新的虚拟机，但是需要保留前面定义的全局变量
+func NewWithGlobalsStore(bytecode *compiler.Bytecode, globals []object.Object) *VM {
+	mainClosure := &object.Closure{Fn: bytecode.Main}
+	frames := []*Frame{NewFrame(mainClosure, 0)}
+
+	return &VM{
+		constants:   bytecode.Constants,
+		globals:     globals,
+		globalNames: bytecode.GlobalNames,
+		stack:       make([]object.Object, StackSize),
+		sp:          bytecode.Main.NumLocals,
+		frames:      frames,
+		framesIndex: 1,
+		ctx:         object.DefaultContext(),
+	}
+}
+
+// SetContext 设置内置函数执行时的上下文，默认使用进程的标准输出、标准错误和标准输入
+func (vm *VM) SetContext(ctx *object.Context) {
+	vm.ctx = ctx
+}
+
+// Run 执行字节码
+// 返回程序最后一条表达式语句的值，最后一条语句不是表达式语句时返回 nil，
+// 出错时返回 *object.Error，错误的位置是出错的指令对应的源码位置，同时记下这时的调用栈
+func (vm *VM) Run() object.Object {
+	for {
+		frame := vm.currentFrame()
+		ip := frame.ip
+		result, err := vm.step(frame)
+		if err != nil {
+			// 内置函数调用的 Holiya 代码中出错时，错误已经有了位置和调用栈
+			if !err.Pos.IsValid() {
+				err.Pos = frame.cl.Fn.PositionAt(ip)
+				if !err.Exit {
+					err.Trace = vm.trace()
+				}
+			}
+			return err
+		}
+		if vm.framesIndex == 0 {
+			return result
+		}
+	}
+}
+
+// trace 返回当前的调用栈，最内层的调用在前面，和求值器的调用栈相同
+// 第 0 帧是顶层代码，其他每一帧的调用位置是上一帧正在执行的 OpCall 指令的位置
+func (vm *VM) trace() []object.Frame {
+	var trace []object.Frame
+	for i := vm.framesIndex - 1; i > 0; i-- {
+		caller := vm.frames[i-1]
+		trace = append(trace, object.Frame{
+			Function: vm.frames[i].cl.Fn.Name,
+			Pos:      caller.cl.Fn.PositionAt(caller.ip - 1),
+		})
+	}
+	return trace
+}
+
+// Call 用参数 args 调用函数 fn 并返回结果，用于在 Go 代码中调用 Holiya 函数
+// 虚拟机要用定义函数时的常量池和全局变量创建，原来的字节码不会执行
+// 出错时返回 *object.Error
+func (vm *VM) Call(fn object.Object, args ...object.Object) object.Object {
+	if len(args) > 255 {
+		return newError("too many arguments: %d", len(args))
+	}
+	// 用只包含调用指令的函数代替顶层代码
+	main := &object.CompiledFunction{
+		Instructions: append(code.Make(code.OpCall, len(args)), code.Make(code.OpReturnValue)...),
+	}
+	vm.frames[0] = NewFrame(&object.Closure{Fn: main}, 0)
+	vm.framesIndex = 1
+	vm.sp = 0
+	for _, obj := range append([]object.Object{fn}, args...) {
+		if err := vm.push(obj); err != nil {
+			return err
+		}
+	}
+	return vm.Run()
+}
+
+// 执行一条指令，顶层代码返回时 framesIndex 为 0，result 是程序的结果
+func (vm *VM) step(frame *Frame) (result object.Object, err *object.Error) {
+	ins := frame.Instructions()
+	ip := frame.ip
+	op := code.Opcode(ins[ip])
+	def, _ := code.Lookup(byte(op))
+	operands, read := code.ReadOperands(def, ins[ip+1:])
+	frame.ip = ip + 1 + read
+
+	switch op {
+	case code.OpConstant:
+		return nil, vm.push(vm.constants[operands[0]])
+
+	case code.OpPop:
+		vm.pop()
+
+	case code.OpDup2:
+		if err := vm.push(vm.stack[vm.sp-2]); err != nil {
+			return nil, err
+		}
+		return nil, vm.push(vm.stack[vm.sp-2])
+
+	case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpMod, code.OpPow,
+		code.OpEqual, code.OpNotEqual, code.OpLessThan, code.OpLessEqual,
+		code.OpGreaterThan, code.OpGreaterEqual:
+		right := vm.pop()
+		left := vm.pop()
+		return nil, vm.pushResult(binaryOperation(binaryOperators[op], left, right))
+
+	case code.OpTrue:
+		return nil, vm.push(TRUE)
+	case code.OpFalse:
+		return nil, vm.push(FALSE)
+	case code.OpNull:
+		return nil, vm.push(NULL)
+
+	case code.OpMinus:
+		return nil, vm.pushResult(minusOperation(vm.pop()))
+	case code.OpBang:
+		switch vm.pop() {
+		case FALSE, NULL:
+			return nil, vm.push(TRUE)
+		}
+		return nil, vm.push(FALSE)
+
+	case code.OpJump:
+		frame.ip = operands[0]
+	case code.OpJumpNotTruthy:
+		if !isTruthy(vm.pop()) {
+			frame.ip = operands[0]
+		}
+	case code.OpJumpIfBound:
+		if vm.stack[frame.basePointer+operands[0]] != nil {
+			frame.ip = operands[1]
+		}
+
+	case code.OpGetGlobal:
+		value := vm.globals[operands[0]]
+		if value == nil {
+			return nil, newError("identifier not found: %s", vm.globalNames[operands[0]])
+		}
+		return nil, vm.push(value)
+	case code.OpSetGlobal:
+		vm.globals[operands[0]] = vm.pop()
+	case code.OpAssignGlobal:
+		if vm.globals[operands[0]] == nil {
+			return nil, newError("cannot assign to undeclared variable: %s", vm.globalNames[operands[0]])
+		}
+		vm.globals[operands[0]] = vm.pop()
+
+	case code.OpGetLocal:
+		value := vm.stack[frame.basePointer+operands[0]]
+		if value == nil {
+			return nil, newError("identifier not found: %s", frame.cl.Fn.LocalNames[operands[0]])
+		}
+		return nil, vm.push(value)
+	case code.OpSetLocal:
+		vm.stack[frame.basePointer+operands[0]] = vm.pop()
+
+	case code.OpGetFree:
+		value := *frame.cl.Free[operands[0]].Location
+		if value == nil {
+			return nil, newError("identifier not found: %s", frame.cl.Fn.FreeVariables[operands[0]].Name)
+		}
+		return nil, vm.push(value)
+	case code.OpSetFree:
+		*frame.cl.Free[operands[0]].Location = vm.pop()
+
+	case code.OpGetBuiltin:
+		return nil, vm.push(object.Builtins[operands[0]].Builtin)
+
+	case code.OpArray:
+		elements := make([]object.Object, operands[0])
+		copy(elements, vm.stack[vm.sp-operands[0]:vm.sp])
+		vm.sp -= operands[0]
+		return nil, vm.push(&object.Array{Elements: elements})
+	case code.OpTuple:
+		elements := make([]object.Object, operands[0])
+		copy(elements, vm.stack[vm.sp-operands[0]:vm.sp])
+		vm.sp -= operands[0]
+		return nil, vm.push(&object.Tuple{Elements: elements})
+	case code.OpDestructure:
+		elements, err := object.Unpack(vm.pop(), operands[0])
+		if err != nil {
+			return nil, err
+		}
+		for _, element := range elements {
+			if err := vm.push(element); err != nil {
+				return nil, err
+			}
+		}
+	case code.OpHash:
+		hash, err := vm.buildHash(vm.sp-2*operands[0], vm.sp)
+		if err != nil {
+			return nil, err
+		}
+		vm.sp -= 2 * operands[0]
+		return nil, vm.push(hash)
+	case code.OpInterpolate:
+		var out strings.Builder
+		for _, part := range vm.stack[vm.sp-operands[0] : vm.sp] {
+			out.WriteString(part.Inspect())
+		}
+		vm.sp -= operands[0]
+		return nil, vm.push(&object.String{Value: out.String()})
+	case code.OpIndex:
+		index := vm.pop()
+		left := vm.pop()
+		return nil, vm.pushResult(indexOperation(left, index))
+	case code.OpSetIndex:
+		value := vm.pop()
+		index := vm.pop()
+		left := vm.pop()
+		return nil, vm.pushResult(setIndexOperation(left, index, value))
+
+	case code.OpCall:
+		return nil, vm.callFunction(operands[0])
+	case code.OpReturnValue:
+		return vm.returnFromFrame(vm.pop())
+	case code.OpReturn:
+		return vm.returnFromFrame(nil)
+	case code.OpClosure:
+		return nil, vm.pushClosure(operands[0])
+	case code.OpCloseUpvalues:
+		vm.closeUpvalues(frame.basePointer + operands[0])
+
+	case code.OpIterStart:
+		iter, err := newIterator(vm.pop())
+		if err != nil {
+			return nil, err
+		}
+		return nil, vm.push(iter)
+	case code.OpIterNext:
+		iter := vm.stack[frame.basePointer+operands[0]].(*iterator)
+		if iter.next >= len(iter.items) {
+			frame.ip = operands[1]
+			return nil, nil
+		}
+		iter.next++
+		return nil, vm.push(iter.items[iter.next-1])
+
+	default:
+		return nil, newError("unknown opcode %d", op)
+	}
+	return nil, nil
+}
+
+// 用栈中 [start, end) 范围内的键和值创建哈希表，键值对按在栈中的顺序插入
+func (vm *VM) buildHash(start, end int) (object.Object, *object.Error) {
+	hash := &object.Hashmap{}
+	for i := start; i < end; i += 2 {
+		key := vm.stack[i]
+		value := vm.stack[i+1]
+
+		hashKey, ok := object.AsHashable(key)
+		if !ok {
+			return nil, newError("unusable as hash key: %s", key.Type())
+		}
+		hash.Set(hashKey, value)
+	}
+	return hash, nil
+}
+
+// 调用函数，栈中依次是函数和 numArgs 个参数
+func (vm *VM) callFunction(numArgs int) *object.Error {
+	callee := vm.stack[vm.sp-1-numArgs]
+	switch callee := callee.(type) {
+	case *object.Closure:
+		return vm.callClosure(callee, numArgs)
+	case *object.Builtin:
+		args := make([]object.Object, numArgs)
+		copy(args, vm.stack[vm.sp-numArgs:vm.sp])
+		vm.sp = vm.sp - numArgs - 1
+
+		result := callee.Fn(vm.ctx, args...)
+		if result == nil {
+			result = NULL
+		}
+		// 内置函数调用的 Holiya 代码出错时，错误的位置在被调用的代码中，
+		// 它的调用栈之后接上这个内置函数的调用和当前的调用栈，和求值器相同
+		if err, ok := result.(*object.Error); ok && err.Pos.IsValid() && !err.Exit {
+			frame := vm.currentFrame()
+			err.Trace = append(err.Trace, object.Frame{Function: callee.Name, Pos: frame.cl.Fn.PositionAt(frame.ip - 1)})
+			err.Trace = append(err.Trace, vm.trace()...)
+		}
+		return vm.pushResult(result)
+	default:
+		return newError("not a function: %s", callee.Type())
+	}
+}
+
+// 调用闭包，检查参数的数量，把多出的参数放到剩余参数的数组中，
+// 没有传入的参数和其他局部变量都设置为 nil
+func (vm *VM) callClosure(cl *object.Closure, numArgs int) *object.Error {
+	fn := cl.Fn
+	if err := checkArity(fn, numArgs); err != nil {
+		return err
+	}
+
+	// 调用的深度和求值器的上限相同，第 0 帧是顶层代码
+	if vm.framesIndex > object.MaxCallDepth {
+		return newError("stack overflow")
+	}
+	basePointer := vm.sp - numArgs
+	vm.ensureStack(basePointer + fn.NumLocals)
+
+	if fn.HasRest {
+		rest := []object.Object{}
+		if numArgs > fn.NumParameters {
+			rest = append(rest, vm.stack[basePointer+fn.NumParameters:vm.sp]...)
+		}
+		// 没有传入的参数要先清空，否则 OpJumpIfBound 会把栈中残留的值当作传入的参数
+		for i := basePointer + numArgs; i < basePointer+fn.NumParameters; i++ {
+			vm.stack[i] = nil
+		}
+		vm.stack[basePointer+fn.NumParameters] = &object.Array{Elements: rest}
+		numArgs = fn.NumParameters + 1
+	}
+	for i := basePointer + numArgs; i < basePointer+fn.NumLocals; i++ {
+		vm.stack[i] = nil
+	}
+
+	vm.pushFrame(NewFrame(cl, basePointer))
+	vm.sp = basePointer + fn.NumLocals
+	return nil
+}
+
+// 检查调用函数时传入的参数数量，错误信息和求值器相同
+func checkArity(fn *object.CompiledFunction, got int) *object.Error {
+	if got >= fn.NumRequired && (got <= fn.NumParameters || fn.HasRest) {
+		return nil
+	}
+
+	var want string
+	switch {
+	case fn.HasRest:
+		want = fmt.Sprintf(">=%d", fn.NumRequired)
+	case fn.NumRequired == fn.NumParameters:
+		want = fmt.Sprintf("%d", fn.NumParameters)
+	default:
+		want = fmt.Sprintf("%d..%d", fn.NumRequired, fn.NumParameters)
+	}
+
+	name := "anonymous function"
+	if fn.Name != "" {
+		name = "`" + fn.Name + "`"
+	}
+	return newError("wrong number of arguments to %s. got=%d, want=%s", name, got, want)
+}
+
+// 从当前函数返回，关闭函数的局部变量上的捕获变量，把返回值压入调用者的栈中
+// 顶层代码返回时，返回值就是程序的结果
+func (vm *VM) returnFromFrame(returnValue object.Object) (object.Object, *object.Error) {
+	frame := vm.popFrame()
+	vm.closeUpvalues(frame.basePointer)
+	if vm.framesIndex == 0 {
+		return returnValue, nil
+	}
+
+	if returnValue == nil {
+		returnValue = NULL
+	}
+	vm.sp = frame.basePointer - 1
+	return nil, vm.push(returnValue)
+}
+
+// 创建闭包，捕获当前函数的局部变量或者当前函数捕获的变量
+func (vm *VM) pushClosure(constIndex int) *object.Error {
+	fn := vm.constants[constIndex].(*object.CompiledFunction)
+	frame := vm.currentFrame()
+
+	free := make([]*object.Upvalue, len(fn.FreeVariables))
+	for i, variable := range fn.FreeVariables {
+		if variable.IsLocal {
+			free[i] = vm.captureUpvalue(frame.basePointer + variable.Index)
+		} else {
+			free[i] = frame.cl.Free[variable.Index]
+		}
+	}
+	return vm.push(&object.Closure{Fn: fn, Free: free})
+}
+
+// 还没有关闭的捕获变量和它引用的局部变量在栈中的位置
+type openUpvalue struct {
+	index   int
+	upvalue *object.Upvalue
+}
+
+// 捕获栈中的局部变量，同一个局部变量只创建一个捕获变量，这样多个闭包共享同一个变量
+// 被捕获的通常是当前函数的局部变量，在栈的顶部，所以从末尾开始查找插入的位置
+func (vm *VM) captureUpvalue(index int) *object.Upvalue {
+	i := len(vm.openUpvalues)
+	for i > 0 && vm.openUpvalues[i-1].index > index {
+		i--
+	}
+	if i > 0 && vm.openUpvalues[i-1].index == index {
+		return vm.openUpvalues[i-1].upvalue
+	}
+	upvalue := &object.Upvalue{Location: &vm.stack[index]}
+	vm.openUpvalues = append(vm.openUpvalues, openUpvalue{})
+	copy(vm.openUpvalues[i+1:], vm.openUpvalues[i:])
+	vm.openUpvalues[i] = openUpvalue{index: index, upvalue: upvalue}
+	return upvalue
+}
+
+// 关闭栈中位置不小于 from 的局部变量上的捕获变量，把变量的值复制到捕获变量中
+// 这些捕获变量都在列表的末尾，从栈顶往下关闭，遇到位置小于 from 的就停止
+func (vm *VM) closeUpvalues(from int) {
+	n := len(vm.openUpvalues)
+	for n > 0 && vm.openUpvalues[n-1].index >= from {
+		n--
+		upvalue := vm.openUpvalues[n].upvalue
+		upvalue.Closed = *upvalue.Location
+		upvalue.Location = &upvalue.Closed
+		vm.openUpvalues[n] = openUpvalue{}
+	}
+	vm.openUpvalues = vm.openUpvalues[:n]
+}
+
+// 压入运算的结果，结果是错误时返回错误
+func (vm *VM) pushResult(result object.Object) *object.Error {
+	if err, ok := result.(*object.Error); ok {
+		return err
+	}
+	return vm.push(result)
+}
+
+// 压入值
+func (vm *VM) push(o object.Object) *object.Error {
+	vm.ensureStack(vm.sp + 1)
+	vm.stack[vm.sp] = o
+	vm.sp++
+	return nil
+}
+
+// 保证栈中至少有 size 个位置，不够时把栈扩大一倍，
+// 还没有关闭的捕获变量改为引用新的栈中的局部变量
+func (vm *VM) ensureStack(size int) {
+	if size <= len(vm.stack) {
+		return
+	}
+	newSize := 2 * len(vm.stack)
+	for newSize < size {
+		newSize *= 2
+	}
+	stack := make([]object.Object, newSize)
+	copy(stack, vm.stack)
+	vm.stack = stack
+	for _, open := range vm.openUpvalues {
+		open.upvalue.Location = &vm.stack[open.index]
+	}
+}
+
+// 弹出栈顶的值
+func (vm *VM) pop() object.Object {
+	o := vm.stack[vm.sp-1]
+	vm.sp--
+	return o
+}
+
+// 返回当前的调用帧
+func (vm *VM) currentFrame() *Frame {
+	return vm.frames[vm.framesIndex-1]
+}
+
+// 压入调用帧
+func (vm *VM) pushFrame(f *Frame) {
+	if vm.framesIndex == len(vm.frames) {
+		vm.frames = append(vm.frames, f)
+	} else {
+		vm.frames[vm.framesIndex] = f
+	}
+	vm.framesIndex++
+}
+
+// 弹出调用帧
+func (vm *VM) popFrame() *Frame {
+	vm.framesIndex--
+	return vm.frames[vm.framesIndex]
+}
+
+// iterator for-in 语句使用的迭代器
+// 创建时复制被遍历的元素，循环体中修改被遍历的对象不影响遍历
+type iterator struct {
+	items []object.Object
+	// 下一个元素的下标
+	next int
+}
+
+// 返回迭代器类型
+func (it *iterator) Type() object.ObjectType {
+	return "ITERATOR"
+}
+
+// 返回迭代器的字符串表示
+func (it *iterator) Inspect() string {
+	return "iterator"
+}
+
+// 创建迭代器，依次返回数组或元组的元素、字符串的字符或哈希表的键
+func newIterator(iterable object.Object) (*iterator, *object.Error) {
+	var items []object.Object
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		items = append(items, iterable.Elements...)
+	case *object.Tuple:
+		items = iterable.Elements
+	case *object.String:
+		items = iterable.Chars()
+	case *object.Hashmap:
+		for _, pair := range iterable.Pairs {
+			items = append(items, pair.Key)
+		}
+	default:
+		return nil, newError("cannot iterate over %s", iterable.Type())
+	}
+	return &iterator{items: items}, nil
+}
+
+// 创建一个新的 Error 对象，包含格式化的错误信息
+func newError(format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...)}
+}
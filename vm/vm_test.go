@@ -0,0 +1,191 @@
+package vm
+
+import (
+	"bytes"
+	"testing"
+
+	"holiya/compiler"
+	"holiya/lexer"
+	"holiya/object"
+	"holiya/parser"
+)
+
+// 虚拟机测试用例，expected 是结果的 Inspect，nil 表示没有结果
+type vmTestCase struct {
+	input    string
+	expected interface{}
+}
+
+// 测试闭包捕获变量，闭包之间共享捕获的变量，函数返回后变量仍然可用
+func TestClosures(t *testing.T) {
+	tests := []vmTestCase{
+		{"let newAdder = fn(a) { fn(b) { a + b } }; newAdder(2)(3);", "5"},
+		{"let counter = fn() { let n = 0; [fn() { n += 1 }, fn() { n }] }(); counter[0](); counter[0](); counter[1]();", "2"},
+		{"let f = fn(a) { fn() { fn() { a } } }; f(7)()();", "7"},
+		{"let fib = fn(n) { if (n < 2) { return n; } fib(n - 1) + fib(n - 2) }; fib(15);", "610"},
+		{"let outer = fn() { let inner = fn(n) { if (n == 0) { 0 } else { inner(n - 1) } }; inner(3) }; outer();", "0"},
+		{"let fs = []; for (i in [1, 2, 3]) { fs = push(fs, fn() { i }) }; fs[0]() + fs[1]() * 10 + fs[2]() * 100;", "321"},
+		{"let fs = []; for (let i = 0; i < 3; i += 1) { fs = push(fs, fn() { i }) }; fs[0]();", "3"},
+		{"let make = fn() { let fs = []; for (let i = 0; i < 2; i += 1) { fs = push(fs, fn() { i }) }; fs }; let a = make(); let b = make(); a[0]() + b[1]();", "4"},
+		{"let x = 1; let f = fn() { x = x + 1 }; f(); f(); x;", "3"},
+		{"let f = fn() { let a = 1; let b = 2; let g = fn() { b }; let h = fn() { a }; let k = fn() { a + b }; b = 10; a = 20; [g(), h(), k()] }; f();", "[10, 20, 30]"},
+		{"let outer = fn() { let x = 1; let inner = fn() { let y = 2; fn() { x + y } }; let c = inner(); x = 5; c() }; outer();", "7"},
+	}
+
+	runVmTests(t, tests)
+}
+
+// 测试参数的默认值和剩余参数
+func TestCallingFunctions(t *testing.T) {
+	tests := []vmTestCase{
+		{"let f = fn(a, b = a + 1, ...rest) { [a, b, rest] }; f(1);", "[1, 2, []]"},
+		{"let f = fn(a, b = a + 1, ...rest) { [a, b, rest] }; f(1, 5, 6, 7);", "[1, 5, [6, 7]]"},
+		{"let f = fn(a) { a }; f();", "ERROR: wrong number of arguments to `f`. got=0, want=1"},
+		{"fn(a, b = 1) { a }(1, 2, 3);", "ERROR: wrong number of arguments to anonymous function. got=3, want=1..2"},
+		{"let f = fn() { let x = 1; }; f();", "null"},
+		{"puts()", "null"},
+		{"len(1)", "ERROR: argument to `len` not supported, got INTEGER"},
+		{"let one = 1; one();", "ERROR: not a function: INTEGER"},
+	}
+
+	runVmTests(t, tests)
+}
+
+// 测试循环，break 和 continue 可以出现在表达式中间
+func TestLoops(t *testing.T) {
+	tests := []vmTestCase{
+		{"let s = 0; for (let i = 0; i < 10; i += 1) { if (i % 2 == 0) { continue; } s += i; }; s;", "25"},
+		{"let s = 0; for (x in [1, 2, 3]) { s += 10 * if (x == 2) { break; } else { x } }; s;", "10"},
+		{"let s = \"\"; for (c in \"abc\") { s = c + s; }; s;", "cba"},
+		{"let i = 0; while (true) { i += 1; if (i == 5) { break; } }; i;", "5"},
+		{"for (x in 1) { x }", "ERROR: cannot iterate over INTEGER"},
+		{"while (false) { 1 }", nil},
+	}
+
+	runVmTests(t, tests)
+}
+
+// 测试整数和浮点数按准确的数值比较，结果和数组元素的比较一致
+func TestNumberComparison(t *testing.T) {
+	tests := []vmTestCase{
+		{"9007199254740993 == 9007199254740992.0", "false"},
+		{"9007199254740993 != 9007199254740992.0", "true"},
+		{"9007199254740993 > 9007199254740992.0", "true"},
+		{"9007199254740992.0 < 9007199254740993", "true"},
+		{"9007199254740993 >= 9007199254740992.0", "true"},
+		{"9007199254740992 == 9007199254740992.0", "true"},
+		{"[9007199254740993] == [9007199254740992.0]", "false"},
+		{"1 + 0.5", "1.500000"},
+	}
+
+	runVmTests(t, tests)
+}
+
+// 测试运行时错误的位置
+func TestErrorPosition(t *testing.T) {
+	tests := []vmTestCase{
+		{"let x = 1;\nx + true;", "ERROR: 2:1: type mismatch: INTEGER + BOOLEAN"},
+		{"let f = fn() {\n  y\n};\nf();", "ERROR: 2:3: identifier not found: y"},
+		{"let a = [1];\na[5] = 2;", "ERROR: 2:1: index out of range: 5"},
+		{"x = 1", "ERROR: 1:1: cannot assign to undeclared variable: x"},
+	}
+
+	for _, tt := range tests {
+		result := run(t, tt.input)
+		err, ok := result.(*object.Error)
+		if !ok {
+			t.Errorf("expected error for %q, got %v", tt.input, result)
+			continue
+		}
+		if err.Inspect() != tt.expected {
+			t.Errorf("wrong error for %q. got=%q, want=%q", tt.input, err.Inspect(), tt.expected)
+		}
+	}
+}
+
+// 测试多次执行时保留全局变量
+func TestGlobalsStore(t *testing.T) {
+	symbolTable := compiler.NewSymbolTable()
+	constants := []object.Object{}
+	globals := make([]object.Object, GlobalsSize)
+
+	var result object.Object
+	for _, input := range []string{"let x = 2;", "let double = fn() { x * 2 };", "double() + x"} {
+		c := compiler.NewWithState(symbolTable, constants)
+		if err := c.Compile(parser.New(lexer.New(input)).ParseProgram()); err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+		bytecode := c.Bytecode()
+		constants = bytecode.Constants
+		result = NewWithGlobalsStore(bytecode, globals).Run()
+	}
+	if result == nil || result.Inspect() != "6" {
+		t.Errorf("result = %v, want 6", result)
+	}
+}
+
+// 测试内置函数输出到虚拟机的上下文中
+func TestContext(t *testing.T) {
+	c := compiler.New()
+	input := `let f = fn(n) { println("n =", n); eprint("done") }; f(1); printf("%d%%", 5)`
+	if err := c.Compile(parser.New(lexer.New(input)).ParseProgram()); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	vm := New(c.Bytecode())
+	vm.SetContext(&object.Context{Stdout: &stdout, Stderr: &stderr})
+	if err, ok := vm.Run().(*object.Error); ok {
+		t.Fatalf("vm error: %s", err.Message)
+	}
+	if stdout.String() != "n = 1\n5%" {
+		t.Errorf("stdout = %q, want %q", stdout.String(), "n = 1\n5%")
+	}
+	if stderr.String() != "done" {
+		t.Errorf("stderr = %q, want %q", stderr.String(), "done")
+	}
+}
+
+// 执行虚拟机测试用例
+func runVmTests(t *testing.T, tests []vmTestCase) {
+	t.Helper()
+
+	for _, tt := range tests {
+		result := run(t, tt.input)
+		if tt.expected == nil {
+			if result != nil {
+				t.Errorf("expected no result for %q, got %s", tt.input, result.Inspect())
+			}
+			continue
+		}
+		if result == nil {
+			t.Errorf("expected %v for %q, got no result", tt.expected, tt.input)
+			continue
+		}
+
+		got := result.Inspect()
+		if err, ok := result.(*object.Error); ok {
+			got = "ERROR: " + err.Message
+		}
+		if got != tt.expected {
+			t.Errorf("wrong result for %q. got=%q, want=%q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+// 编译并执行程序
+func run(t *testing.T, input string) object.Object {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+
+	c := compiler.New()
+	if err := c.Compile(program); err != nil {
+		t.Fatalf("compiler error for %q: %s", input, err)
+	}
+	return New(c.Bytecode()).Run()
+}